@@ -3,9 +3,13 @@
 // # Usage
 //
 //	meow run <file.nyan>              Run a .nyan file
-//	meow build <file.nyan> [-o name]  Build a binary
+//	meow build [file.nyan] [-o name]  Build a binary
 //	meow transpile <file.nyan>        Show generated Go code
 //	meow test [files...]              Run _test.nyan files
+//	meow cover -func|-html|-merge     Show or merge coverage profiles
+//	meow mod tidy|download|vendor     Lock, fetch or vendor Go modules
+//	meow init [name]                  Make the current directory a project
+//	meow new app|lib <name>           Make a new project
 //	meow version                      Show version info
 //	meow help [command]               Show help for a command
 //	meow <file.nyan>                  Shorthand for 'meow run'
@@ -13,4 +17,5 @@
 // # Flags
 //
 //	--verbose, -v    Enable debug logging
+//	--offline        Build from meow.lock without the network
 package main
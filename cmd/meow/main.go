@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -8,14 +10,19 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/135yshr/meow/compiler"
 	"github.com/135yshr/meow/pkg/formatter"
 	"github.com/135yshr/meow/pkg/lexer"
 	"github.com/135yshr/meow/pkg/linter"
 	"github.com/135yshr/meow/pkg/parser"
+	"github.com/135yshr/meow/pkg/watch"
+	"github.com/135yshr/meow/runtime/coverage"
 )
 
 var (
@@ -42,11 +49,23 @@ func main() {
 	programArguments = theirs
 
 	verbose := false
+	offline := false
 	filtered := make([]string, 0, len(ours))
+	command := ""
 	for _, a := range ours {
-		if a == "--verbose" || a == "-v" {
+		switch {
+		case a == "-v" && command == "test":
+			// After test, -v is the tests' own, as it is for go test: each
+			// test as it runs, rather than the compiler's debug log.
+			filtered = append(filtered, a)
+		case a == "--verbose" || a == "-v":
 			verbose = true
-		} else {
+		case a == "--offline":
+			offline = true
+		default:
+			if command == "" && !strings.HasPrefix(a, "-") {
+				command = a
+			}
 			filtered = append(filtered, a)
 		}
 	}
@@ -59,6 +78,7 @@ func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
 
 	c := compiler.New(logger)
+	c.SetOffline(offline)
 
 	switch args[0] {
 	case "help":
@@ -70,25 +90,21 @@ func main() {
 	case "version":
 		fmt.Printf("meow version %s (commit: %s, built: %s)\n", version, commit, date)
 	case "run":
-		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "Hiss! Please specify a .nyan file, nya~")
-			os.Exit(1)
+		watching := len(args) >= 2 && (args[1] == "--watch" || args[1] == "-watch")
+		if watching {
+			args = append(args[:1], args[2:]...)
 		}
-		runProgram(c, args[1])
-	case "build":
 		if len(args) < 2 {
 			fmt.Fprintln(os.Stderr, "Hiss! Please specify a .nyan file, nya~")
 			os.Exit(1)
 		}
-		output := ""
-		if len(args) >= 4 && args[2] == "-o" {
-			output = args[3]
-		}
-		if err := c.Build(args[1], output); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+		if watching {
+			watchProgram(c, args[1])
+			return
 		}
-		fmt.Println("Build complete, nya~!")
+		runProgram(c, args[1])
+	case "build":
+		runBuildCommand(c, args[1:])
 	case "transpile":
 		if len(args) < 2 {
 			fmt.Fprintln(os.Stderr, "Hiss! Please specify a .nyan file, nya~")
@@ -111,6 +127,14 @@ func main() {
 		runFmtCommand(args[1:])
 	case "lint":
 		runLintCommand(args[1:])
+	case "cover":
+		runCoverCommand(args[1:])
+	case "mod":
+		runModCommand(c, args[1:])
+	case "init":
+		runInitCommand(args[1:])
+	case "new":
+		runNewCommand(args[1:])
 	default:
 		// Treat as "run" if the argument looks like a file
 		if len(args) >= 1 && len(args[0]) > 0 && args[0][0] != '-' {
@@ -169,16 +193,209 @@ func splitAtRunTarget(args []string) (ours, theirs []string) {
 	return args, nil
 }
 
+// runBuildCommand builds a binary, or with --lib a Go package for Go code to
+// import. The flags may come before the file or after it.
+func runBuildCommand(c *compiler.Compiler, args []string) {
+	file := ""
+	output := ""
+	lib := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--lib" || args[i] == "-lib":
+			lib = true
+		case args[i] == "-o":
+			if i+1 < len(args) {
+				i++
+				output = args[i]
+			}
+		case !strings.HasPrefix(args[i], "-") && file == "":
+			file = args[i]
+		}
+	}
+	if file == "" {
+		m := projectManifest()
+		if m == nil {
+			fmt.Fprintln(os.Stderr, "Hiss! Please specify a .nyan file, or run meow init, nya~")
+			os.Exit(1)
+		}
+		if m.Main == "" {
+			fmt.Fprintf(os.Stderr, "Hiss! %s has no main or lib line to build, nya~\n", m.Path(compiler.ManifestFile))
+			os.Exit(1)
+		}
+		file, lib = m.Path(m.Main), lib || m.Lib
+		if output == "" {
+			output = m.Path(m.Project)
+		}
+	}
+
+	if lib {
+		if err := c.BuildLib(file, output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("Build complete, nya~! See go.mod.snippet for what the importing module needs.")
+		return
+	}
+	if err := c.Build(file, output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("Build complete, nya~!")
+}
+
+// runModCommand runs `meow mod tidy|download|vendor [dir]`. Each works on the
+// .nyan programs under dir, and the meow.lock beside them.
+func runModCommand(c *compiler.Compiler, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Hiss! Please say tidy, download or vendor, nya~")
+		os.Exit(1)
+	}
+	dir := "."
+	if m := projectManifest(); m != nil {
+		dir = m.Dir
+	}
+	if len(args) >= 2 {
+		dir = args[1]
+	}
+
+	var err error
+	var done string
+	switch args[0] {
+	case "tidy":
+		err = c.TidyModules(dir)
+		done = "Wrote " + filepath.Join(dir, compiler.LockFile) + ", nya~!"
+	case "download":
+		err = c.DownloadModules(dir)
+		done = "Modules downloaded, nya~! Builds can go --offline now."
+	case "vendor":
+		err = c.VendorModules(dir)
+		done = "Modules vendored into " + filepath.Join(dir, "vendor") + ", nya~!"
+	default:
+		fmt.Fprintf(os.Stderr, "Hiss! Unknown mod command %q, nya~\n", args[0])
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(done)
+}
+
+// projectManifest reads the meow.mod of the project the current directory is
+// in, or answers nil outside any project.
+func projectManifest() *compiler.Manifest {
+	m, err := compiler.FindManifest(".")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// runInitCommand runs `meow init [name]`, making the current directory a
+// project. It is named after the directory unless told otherwise.
+func runInitCommand(args []string) {
+	name := ""
+	if len(args) >= 1 {
+		name = args[0]
+	} else if wd, err := os.Getwd(); err == nil {
+		name = strings.ToLower(strings.ReplaceAll(filepath.Base(wd), "-", "_"))
+	}
+	m, err := compiler.InitProject(".", name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s for %s, nya~!\n", compiler.ManifestFile, m.Project)
+	if m.Main == "" {
+		fmt.Println("Add a main line to it for meow build to know what to build.")
+	}
+}
+
+// runNewCommand runs `meow new app|lib <name> [dir]`, making a project that
+// builds and tests as it is.
+func runNewCommand(args []string) {
+	if len(args) < 2 || (args[0] != "app" && args[0] != "lib") {
+		fmt.Fprintln(os.Stderr, "Hiss! Please say meow new app <name> or meow new lib <name>, nya~")
+		os.Exit(1)
+	}
+	name, dir := args[1], args[1]
+	if len(args) >= 3 {
+		dir = args[2]
+	}
+	m, err := compiler.NewProject(dir, name, args[0] == "lib")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Made %s in %s, nya~! Try:\n\n  cd %s\n  meow test\n  meow build\n", m.Project, dir, dir)
+}
+
 func runTestCommand(c *compiler.Compiler, args []string) {
 	var files []string
 	fuzz := false
 	fuzzTime := ""
 	mutate := false
+	var mutation compiler.MutationOptions
 	cover := false
 	coverProfile := ""
+	coverMode := ""
+	watching := false
+	bench := ""
+	benchTime := ""
+	count := 0
+	var testOpts compiler.TestOptions
+	jsonOut := false
+	junitPath := ""
+	parallel := 1
 
 	for i := 0; i < len(args); i++ {
 		switch {
+		case args[i] == "-p" || strings.HasPrefix(args[i], "-p="):
+			v := flagValue(args, &i, "-p")
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Hiss! -p wants how many files to test at once, not %q, nya~\n", v)
+				os.Exit(1)
+			}
+			parallel = n
+		case args[i] == "-run" || strings.HasPrefix(args[i], "-run="):
+			testOpts.Run = flagValue(args, &i, "-run")
+		case args[i] == "-v":
+			testOpts.Verbose = true
+		case args[i] == "-json":
+			jsonOut = true
+		case args[i] == "-junit" || strings.HasPrefix(args[i], "-junit="):
+			junitPath = flagValue(args, &i, "-junit")
+		case args[i] == "-failfast":
+			testOpts.FailFast = true
+		case args[i] == "-update":
+			testOpts.Update = true
+		case args[i] == "-timeout" || strings.HasPrefix(args[i], "-timeout="):
+			v := flagValue(args, &i, "-timeout")
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Hiss! -timeout wants a duration like 30s, not %q, nya~\n", v)
+				os.Exit(1)
+			}
+			testOpts.Timeout = d
+		case args[i] == "-shuffle" || strings.HasPrefix(args[i], "-shuffle="):
+			v := flagValue(args, &i, "-shuffle")
+			switch v {
+			case "off":
+				testOpts.Shuffle = false
+			case "on":
+				// One seed for every file, so that the line each prints
+				// repeats the whole run.
+				testOpts.Shuffle, testOpts.Seed = true, time.Now().UnixNano()
+			default:
+				seed, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Hiss! -shuffle wants on, off or a seed, not %q, nya~\n", v)
+					os.Exit(1)
+				}
+				testOpts.Shuffle, testOpts.Seed = true, seed
+			}
 		case args[i] == "-fuzz":
 			fuzz = true
 		case args[i] == "-fuzztime":
@@ -188,6 +405,34 @@ func runTestCommand(c *compiler.Compiler, args []string) {
 			}
 		case args[i] == "-mutate":
 			mutate = true
+		case args[i] == "-mutate-nocache":
+			mutation.NoCache = true
+		case args[i] == "-report" || strings.HasPrefix(args[i], "-report="):
+			for _, r := range strings.Split(flagValue(args, &i, "-report"), ",") {
+				mutation.Reports = append(mutation.Reports, strings.TrimSpace(r))
+			}
+		case args[i] == "-mutate-threshold" || strings.HasPrefix(args[i], "-mutate-threshold="):
+			v := flagValue(args, &i, "-mutate-threshold")
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil || t < 0 || t > 100 {
+				fmt.Fprintf(os.Stderr, "Hiss! -mutate-threshold wants a score from 0 to 100, not %q, nya~\n", v)
+				os.Exit(1)
+			}
+			mutation.Threshold = t
+		case args[i] == "-watch" || args[i] == "--watch":
+			watching = true
+		case args[i] == "-bench" || strings.HasPrefix(args[i], "-bench="):
+			bench = flagValue(args, &i, "-bench")
+		case args[i] == "-benchtime" || strings.HasPrefix(args[i], "-benchtime="):
+			benchTime = flagValue(args, &i, "-benchtime")
+		case args[i] == "-count" || strings.HasPrefix(args[i], "-count="):
+			v := flagValue(args, &i, "-count")
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Hiss! -count wants a number of runs, not %q, nya~\n", v)
+				os.Exit(1)
+			}
+			count = n
 		case args[i] == "-cover":
 			cover = true
 		case strings.HasPrefix(args[i], "-coverprofile="):
@@ -199,6 +444,9 @@ func runTestCommand(c *compiler.Compiler, args []string) {
 				coverProfile = args[i]
 				cover = true
 			}
+		case args[i] == "-covermode" || strings.HasPrefix(args[i], "-covermode="):
+			coverMode = flagValue(args, &i, "-covermode")
+			cover = true
 		default:
 			if len(args[i]) > 0 && args[i][0] != '-' {
 				files = append(files, args[i])
@@ -206,9 +454,61 @@ func runTestCommand(c *compiler.Compiler, args []string) {
 		}
 	}
 
+	// With nothing named on the command line, the project's manifest says
+	// what to test and how. A flag that was given still has the last word.
+	if m := projectManifest(); m != nil && len(files) == 0 {
+		paths := m.Test.Paths
+		if len(paths) == 0 {
+			paths = []string{"./..."}
+		}
+		wd, _ := os.Getwd()
+		for _, p := range paths {
+			files = append(files, manifestTestPath(m, wd, p))
+		}
+		if m.Test.Cover && !cover {
+			cover = true
+			if m.Test.CoverProfile != "" {
+				coverProfile = m.Path(m.Test.CoverProfile)
+			}
+		}
+		if fuzzTime == "" {
+			fuzzTime = m.Test.FuzzTime
+		}
+	}
+
+	if !mutate && (len(mutation.Reports) > 0 || mutation.Threshold > 0) {
+		fmt.Fprintln(os.Stderr, "Hiss! -report and -mutate-threshold are for -mutate, nya~")
+		os.Exit(1)
+	}
+	if watching && (fuzz || mutate) {
+		fmt.Fprintln(os.Stderr, "Hiss! -watch runs tests, not -fuzz or -mutate, nya~")
+		os.Exit(1)
+	}
+	if watching && (jsonOut || junitPath != "") {
+		fmt.Fprintln(os.Stderr, "Hiss! -watch runs tests for you to read, not for -json or -junit, nya~")
+		os.Exit(1)
+	}
+	if jsonOut && bench != "" {
+		fmt.Fprintln(os.Stderr, "Hiss! -bench writes go test's own text, which -json cannot be, nya~")
+		os.Exit(1)
+	}
+
 	if cover {
 		c.EnableCoverage(coverProfile)
+		if coverMode == "" {
+			coverMode = "set"
+		}
+		if err := c.SetCoverMode(coverMode); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	testOpts.Color = colorTerminal()
+	if err := c.SetTestOptions(testOpts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+	report := reportTestEvents(c, jsonOut, junitPath)
 
 	if fuzz {
 		if len(files) == 0 {
@@ -231,61 +531,270 @@ func runTestCommand(c *compiler.Compiler, args []string) {
 			os.Exit(1)
 		}
 		for _, f := range files {
-			fmt.Fprintf(os.Stdout, "=== Fuzzing %s ===\n", f)
+			if !jsonOut {
+				fmt.Fprintf(os.Stdout, "=== Fuzzing %s ===\n", f)
+			}
 			if err := c.RunFuzz(f, fuzzTime); err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				if report == nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				report.finish()
 				os.Exit(1)
 			}
 		}
+		report.finish()
 		return
 	}
 
 	if mutate {
+		if err := c.SetMutationOptions(mutation); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		runMutateCommand(c, files)
 		return
 	}
 
+	patterns := files
+	resolve := func() ([]string, error) {
+		if len(patterns) == 0 {
+			return discoverTestFiles(".")
+		}
+		return resolveTestPaths(patterns)
+	}
+	files, err := resolve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	if len(files) == 0 {
-		var err error
-		files, err = discoverTestFiles(".")
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Hiss! No test files found, nya~")
+		os.Exit(1)
+	}
+
+	if watching {
+		// The patterns are resolved again at every look, so a test file
+		// written while watching is run from then on.
+		watched := func() []string {
+			found, _ := resolve()
+			watched := found
+			for _, f := range found {
+				watched = append(watched, compiler.CompanionFiles(f)...)
+			}
+			return watched
 		}
-	} else {
-		var err error
-		files, err = resolveTestPaths(files)
+		watchUntilInterrupted(c, watched, func(ctx context.Context) {
+			found, err := resolve()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			runTests(ctx, c, found, testRun{coverProfile: coverProfile, coverMode: coverMode, failFast: testOpts.FailFast, parallel: parallel})
+		})
+		return
+	}
+
+	passed := runTests(context.Background(), c, files, testRun{coverProfile: coverProfile, coverMode: coverMode, failFast: testOpts.FailFast, parallel: parallel, events: report})
+	report.finish()
+	if !passed {
+		os.Exit(1)
+	}
+	// As with go test, the benchmarks run only once the tests have passed:
+	// how fast a wrong answer comes back is not worth knowing.
+	if bench != "" {
+		runBenchmarks(c, files, compiler.BenchOptions{Pattern: bench, BenchTime: benchTime, Count: count})
+	}
+}
+
+// colorTerminal reports whether stdout is a terminal that color can be
+// written to: one NO_COLOR has not turned it off for.
+func colorTerminal() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// runBenchmarks runs the bench_ functions of the test files that the pattern
+// matches.
+func runBenchmarks(c *compiler.Compiler, files []string, opts compiler.BenchOptions) {
+	ran := 0
+	for _, f := range files {
+		fmt.Fprintf(os.Stdout, "=== Benchmarking %s ===\n", f)
+		n, err := c.RunBench(f, opts)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
+			reportTestError(os.Stderr, err)
 			os.Exit(1)
 		}
+		ran += n
+	}
+	if ran == 0 {
+		fmt.Fprintf(os.Stderr, "Hiss! No bench_ functions match %q, nya~\n", opts.Pattern)
+		os.Exit(1)
 	}
+}
 
-	if len(files) == 0 {
-		fmt.Fprintln(os.Stderr, "Hiss! No test files found, nya~")
+// flagValue reads the value of a flag given as -name=value or -name value,
+// moving *i past it in the second case.
+func flagValue(args []string, i *int, name string) string {
+	if v, ok := strings.CutPrefix(args[*i], name+"="); ok {
+		return v
+	}
+	if *i+1 >= len(args) {
+		fmt.Fprintf(os.Stderr, "Hiss! %s needs a value, nya~\n", name)
 		os.Exit(1)
 	}
+	*i++
+	return args[*i]
+}
 
+// runTests runs each test file, and reports whether they all passed.
+// testRun is how runTests runs the test files.
+type testRun struct {
+	coverProfile string
+	coverMode    string
+	failFast     bool
+	// parallel is how many files are tested at once.
+	parallel int
+	// events, when set, has the results as events, which say what went
+	// wrong themselves.
+	events *testEventReport
+}
+
+func runTests(ctx context.Context, c *compiler.Compiler, files []string, run testRun) bool {
+	coverProfile := run.coverProfile
 	if coverProfile != "" {
-		if err := os.WriteFile(coverProfile, []byte("mode: set\n"), 0644); err != nil {
+		if err := os.WriteFile(coverProfile, []byte("mode: "+run.coverMode+"\n"), 0644); err != nil {
 			fmt.Fprintf(os.Stderr, "Hiss! Cannot write coverage profile header, nya~: %v\n", err)
 			os.Exit(1)
 		}
+		// The test binaries append their branches as they do their blocks,
+		// so those of the last run must not be there to be added to.
+		if err := os.Remove(coverage.BranchesPath(coverProfile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Hiss! Cannot clear the branches of the last run, nya~: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	hasFailure := false
-	for _, f := range files {
-		fmt.Fprintf(os.Stdout, "=== Testing %s ===\n", f)
-		if err := c.RunTest(f); err != nil {
-			hasFailure = true
+	passed := true
+	header := func(f string) {
+		if run.events == nil || !run.events.json {
+			fmt.Fprintf(os.Stdout, "=== Testing %s ===\n", f)
+		}
+	}
+	// failed says what went wrong with a file, and whether to go on to the
+	// next.
+	failed := func(err error) bool {
+		passed = false
+		if ctx.Err() == nil && run.events == nil {
 			reportTestError(os.Stderr, err)
 		}
+		return !run.failFast
 	}
-	if hasFailure {
+
+	if run.parallel > 1 {
+		c.RunTestsParallel(ctx, files, run.parallel, func(r compiler.TestFileResult) bool {
+			header(r.Path)
+			os.Stdout.Write(r.Output)
+			return r.Err == nil || failed(r.Err)
+		})
+		return passed && ctx.Err() == nil
+	}
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return false
+		}
+		header(f)
+		if err := c.RunTestContext(ctx, f); err != nil && !failed(err) {
+			return false
+		}
+	}
+	return passed
+}
+
+// testEventReport is where the events of a test run go under -json and
+// -junit.
+type testEventReport struct {
+	json      bool
+	junit     *compiler.JUnitReport
+	junitPath string
+}
+
+// reportTestEvents has c report its test runs as events when -json or
+// -junit asks for them, and answers nil otherwise. Under -json the events
+// are written to stdout as go test -json writes them; under -junit alone
+// their output is, so that what is read is what it would have been.
+func reportTestEvents(c *compiler.Compiler, jsonOut bool, junitPath string) *testEventReport {
+	if !jsonOut && junitPath == "" {
+		return nil
+	}
+	r := &testEventReport{json: jsonOut, junitPath: junitPath}
+	if junitPath != "" {
+		r.junit = compiler.NewJUnitReport()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	c.SetTestEvents(func(e compiler.TestEvent) {
+		if r.json {
+			enc.Encode(e)
+		} else if e.Action == "output" {
+			fmt.Fprint(os.Stdout, e.Output)
+		}
+		if r.junit != nil {
+			r.junit.Add(e)
+		}
+	})
+	return r
+}
+
+// finish writes the JUnit report, when there is one to write.
+func (r *testEventReport) finish() {
+	if r == nil || r.junit == nil {
+		return
+	}
+	if err := r.junit.WriteFile(r.junitPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// watchUntilInterrupted runs run whenever the files change, or any file the
+// builds of c have read, until Ctrl+C.
+func watchUntilInterrupted(c *compiler.Compiler, files func() []string, run func(ctx context.Context)) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := watch.DefaultWatcher(files)
+	w.Read = c.FilesRead
+	w.Run(ctx, func(ctx context.Context) {
+		run(ctx)
+		if ctx.Err() == nil {
+			fmt.Fprintln(os.Stderr, "\nWatching for changes, nya~ (Ctrl+C to stop)")
+		}
+	})
+}
+
+// watchProgram runs a .nyan file, and runs it again each time it, its
+// companion test, or anything else its build read — the meow.mod and
+// meow.lock of its project — changes. A run still going when a change is
+// saved is stopped first.
+func watchProgram(c *compiler.Compiler, nyanPath string) {
+	files := func() []string {
+		return append([]string{nyanPath}, compiler.CompanionFiles(nyanPath)...)
+	}
+	watchUntilInterrupted(c, files, func(ctx context.Context) {
+		err := c.RunContext(ctx, nyanPath, programArguments...)
+		var exit *exec.ExitError
+		switch {
+		case err == nil || ctx.Err() != nil:
+		case errors.As(err, &exit):
+			fmt.Fprintf(os.Stderr, "\nHiss! %s ended with status %d, nya~\n", nyanPath, exit.ExitCode())
+		default:
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+}
+
 // reportTestError says what went wrong with a test file, unless the file's own
 // test binary has already said it.
 //
@@ -333,6 +842,25 @@ func discoverFilesRecursive(root string, match func(string) bool) ([]string, err
 	return files, nil
 }
 
+// manifestTestPath is p, a path or pattern the manifest m names, made
+// relative to wd for resolvePaths. A pattern keeps its /... and a path under
+// wd its ./, so that the project root's "./..." stays a pattern rather than
+// becoming "...", a file that is not there.
+func manifestTestPath(m *compiler.Manifest, wd, p string) string {
+	pattern := strings.HasSuffix(p, "/...")
+	p = m.Path(strings.TrimSuffix(p, "/..."))
+	if rel, err := filepath.Rel(wd, p); err == nil {
+		p = rel
+		if p != "." && p != ".." && !strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			p = "." + string(filepath.Separator) + p
+		}
+	}
+	if pattern {
+		p += "/..."
+	}
+	return p
+}
+
 func resolvePaths(patterns []string, discover func(string) ([]string, error), discoverRecursive func(string) ([]string, error)) ([]string, error) {
 	var result []string
 	seen := make(map[string]struct{})
@@ -447,6 +975,86 @@ func runFmtCommand(args []string) {
 	}
 }
 
+func runCoverCommand(args []string) {
+	action := ""
+	output := ""
+	var profiles []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-func" || args[i] == "-html" || args[i] == "-merge":
+			if action != "" && action != args[i] {
+				fmt.Fprintf(os.Stderr, "Hiss! cover does one of -func, -html and -merge, not %s and %s, nya~\n", action, args[i])
+				os.Exit(1)
+			}
+			action = args[i]
+		case args[i] == "-o" || strings.HasPrefix(args[i], "-o="):
+			output = flagValue(args, &i, "-o")
+		case strings.HasPrefix(args[i], "-"):
+			fmt.Fprintf(os.Stderr, "Hiss! Unknown flag for cover: %s, nya~\n", args[i])
+			os.Exit(1)
+		default:
+			profiles = append(profiles, args[i])
+		}
+	}
+	if action == "" || len(profiles) == 0 {
+		printSubcommandHelp("cover")
+		os.Exit(1)
+	}
+
+	report, err := compiler.ReadCoverReport(profiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	write := report.WriteFuncs
+	switch action {
+	case "-html":
+		write = report.WriteHTML
+		if output == "" {
+			output = "coverage.html"
+		}
+	case "-merge":
+		write = func(w io.Writer) error {
+			_, err := report.Profile.WriteTo(w)
+			return err
+		}
+	}
+	if output == "" {
+		if err := write(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	err = writeFile(output, write)
+	if err == nil && action == "-merge" && len(report.Profile.Branches) > 0 {
+		err = writeFile(coverage.BranchesPath(output), func(w io.Writer) error {
+			_, err := report.Profile.WriteBranchesTo(w)
+			return err
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Hiss! Cannot write %s, nya~: %v\n", output, err)
+		os.Exit(1)
+	}
+	if action == "-html" {
+		fmt.Printf("Coverage report written to %s, nya~\n", output)
+	}
+}
+
+// writeFile creates the file at path and has write write it.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
 func runLintCommand(args []string) {
 	var patterns []string
 	for _, a := range args {
@@ -469,6 +1077,12 @@ func runLintCommand(args []string) {
 	}
 
 	l := linter.New()
+	if m := projectManifest(); m != nil {
+		if err := l.Disable(m.LintDisable...); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", m.Path(compiler.ManifestFile), err)
+			os.Exit(1)
+		}
+	}
 	hasIssues := false
 
 	for _, f := range files {
@@ -523,6 +1137,7 @@ func runMutateCommand(c *compiler.Compiler, files []string) {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		finishMutation(c, false)
 		return
 	}
 
@@ -591,7 +1206,19 @@ func runMutateCommand(c *compiler.Compiler, files []string) {
 			hasFailure = true
 		}
 	}
-	if hasFailure {
+	finishMutation(c, hasFailure)
+}
+
+// finishMutation writes the mutation reports and checks the score against
+// the threshold, exiting with a failure if either fails or failed already
+// says a source file could not be tested. The reports are written either way,
+// for the files that could.
+func finishMutation(c *compiler.Compiler, failed bool) {
+	if err := c.FinishMutationTests(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		failed = true
+	}
+	if failed {
 		os.Exit(1)
 	}
 }
@@ -619,28 +1246,40 @@ Usage:
 
 Commands:
   run <file.nyan> [args...]    Run a .nyan file, passing args to the program
-  build <file.nyan> [-o name]  Build a binary
+                               (--watch to run it again on every save)
+  build [file.nyan] [-o name]  Build a binary (--lib for a Go package)
   transpile <file.nyan>        Show generated Go code
   test [files...]              Run _test.nyan files
   fmt [-w] <files...>          Format .nyan source files
   lint [files/patterns...]     Run static analysis
+  cover -func|-html|-merge <profiles...>
+                               Show or merge coverage profiles of meow test
+  mod tidy|download|vendor     Lock, fetch or vendor the Go modules of nab go
+  init [name]                  Make the current directory a project (meow.mod)
+  new app|lib <name>           Make a new project with an example test
   version                      Show version info
   help [command]               Show help for a command
 
   meow <file.nyan>             Shorthand for 'meow run'
 
 Flags:
-  --verbose, -v                Enable debug logging
+  --verbose, -v                Enable debug logging (after test, -v is the
+                               tests' own; see meow help test)
+  --offline                    Build from meow.lock without the network
 
 Use "meow help <command>" for more information about a command.`)
 }
 
 func printSubcommandHelp(cmd string) {
 	helps := map[string]string{
-		"run": `Usage: meow run <file.nyan> [program arguments...]
+		"run": `Usage: meow run [--watch] <file.nyan> [program arguments...]
 
 Run a .nyan program. The file is compiled to Go and executed immediately.
 
+With --watch, run it again each time it, its _test.nyan companion, or the
+meow.mod or meow.lock of its project is saved, clearing the screen first. A run still going when a change is saved is stopped,
+and a burst of saves is one run. Ctrl+C stops watching.
+
 Everything after the .nyan file is passed to the program, where env.haul reads
 it, so a program may use flags of its own spelling — including -v. meow exits
 with whatever status the program ended on.
@@ -648,18 +1287,32 @@ with whatever status the program ended on.
 Examples:
   meow run hello.nyan
   meow run examples/hello.nyan
-  meow run check.nyan --target https://example.com`,
+  meow run check.nyan --target https://example.com
+  meow run --watch hello.nyan`,
 
-		"build": `Usage: meow build <file.nyan> [-o name]
+		"build": `Usage: meow build [--lib] [file.nyan] [-o name]
 
 Compile a .nyan file into a standalone binary.
 
+Without a file, build the main (or lib) of the project's meow.mod, named
+after the project.
+
+With --lib, compile it into a Go package instead, for Go code to import. The
+package is named after the output directory, and its exported functions are
+the file's top-level ones in Go's spelling: price_with_tax becomes
+PriceWithTax. A fully typed function keeps its native Go signature; any other
+takes and returns meowrt.Value. A function whose name starts with _ stays
+unexported. go.mod.snippet, written beside the package, holds the lines the
+importing module's go.mod needs.
+
 Flags:
-  -o <name>  Set the output binary name
+  -o <name>  Set the output binary name, or with --lib the package directory
+  --lib      Build an importable Go package rather than a binary
 
 Examples:
   meow build hello.nyan
-  meow build hello.nyan -o hello`,
+  meow build hello.nyan -o hello
+  meow build --lib pricing.nyan -o ./gen/pricing`,
 
 		"transpile": `Usage: meow transpile <file.nyan>
 
@@ -670,8 +1323,9 @@ Examples:
 
 		"test": `Usage: meow test [flags] [files/patterns...]
 
-Run test files. Without arguments, discovers and runs all *_test.nyan files
-in the current directory.
+Run test files. Without arguments, runs what the project's meow.mod names
+with test paths (./... when it names nothing), with its test settings; outside
+a project, discovers and runs all *_test.nyan files in the current directory.
 
 Patterns:
   ./...                  Recursively find all *_test.nyan in current directory
@@ -680,11 +1334,49 @@ Patterns:
   file_test.nyan         Run a specific test file
 
 Flags:
+  -run <regexp>          Run only the test_ and catwalk_ functions whose names
+                         match
+  -v                     Announce each test as it starts, and give how long it
+                         took
+  -timeout <duration>    Stop the tests, and name the one still running, when a
+                         single test takes longer than this (default: no limit)
+  -failfast              Stop at the first test that fails
+  -shuffle <on|off|N>    Run each file's tests in a random order; the seed is
+                         printed, and -shuffle N runs that order again
+  -json                  Write what happens as go test -json events, with each
+                         .nyan file as the package
+  -junit <file>          Also write the results to file as JUnit XML
+  -update                Write what testing.snapshot is given as the snapshot,
+                         rather than compare it with the one there is
+  -p <n>                 Test n files at once, built together (default: 1);
+                         a test that calls parallel() first runs beside the
+                         others in its file either way
   -fuzz                  Run fuzz tests
   -fuzztime <duration>   Fuzz test duration (default: 10s)
-  -mutate                Run mutation tests (explicit or auto-discover pairs)
-  -cover                 Enable statement coverage
+  -mutate                Run mutation tests (explicit or auto-discover pairs),
+                         a mutant to each CPU at once, with only the tests that
+                         reach it; a source and tests unchanged since the last
+                         run give back its results
+  -mutate-nocache        Test every mutant again, rather than take results
+                         from the cache
+  -report <html|json>    With -mutate, also write the results as mutation.html,
+                         the source with the mutants the tests miss shown on
+                         their lines, or as mutation.json, a Stryker report;
+                         -report html,json writes both
+  -mutate-threshold <n>  With -mutate, fail when the mutation score of all the
+                         files together is below n percent
+  -cover                 Enable statement and branch coverage
   -coverprofile=<file>   Write coverage profile to file (Go-compatible format)
+  -covermode <mode>      How coverage counts a statement: set, whether it ran
+                         (default); count, how many times; atomic, how many
+                         times, with tests running at once counted safely
+  -watch                 Run the tests again each time a test file, the source
+                         it tests, or the project's meow.mod or meow.lock is
+                         saved
+  -bench <regexp>        Once the tests pass, run the bench_ functions whose
+                         names match, as Go benchmarks (-bench . runs them all)
+  -benchtime <d|Nx>      How long each benchmark runs (1s), or how many times
+  -count <n>             Run each benchmark n times, for benchstat to compare
 
 Examples:
   meow test
@@ -692,12 +1384,24 @@ Examples:
   meow test testdata/...
   meow test testdata/
   meow test math_test.nyan
+  meow test -run 'add|sub' -v math_test.nyan
+  meow test -timeout 5s -failfast ./...
+  meow test -shuffle on ./...
+  meow test -json ./... > results.jsonl
+  meow test -junit report.xml ./...
+  meow test -p 8 ./...
+  meow test -update snapshot_test.nyan
   meow test -fuzz math_test.nyan
   meow test -fuzz -fuzztime 30s math_test.nyan
   meow test -mutate math.nyan math_test.nyan
   meow test -mutate ./...
+  meow test -mutate -report html -mutate-threshold 80 ./...
   meow test -cover math_test.nyan
-  meow test -coverprofile=coverage.out ./...`,
+  meow test -coverprofile=coverage.out ./...
+  meow test -covermode=count -coverprofile=coverage.out ./...
+  meow test -watch ./...
+  meow test -bench . math_test.nyan
+  meow test -bench sort -benchtime 2s -count 10 ./... > new.txt`,
 
 		"fmt": `Usage: meow fmt [-w] <files...>
 
@@ -711,6 +1415,36 @@ Examples:
   meow fmt -w hello.nyan
   meow fmt examples/fibonacci.nyan`,
 
+		"cover": `Usage: meow cover -func|-html|-merge [-o file] <profiles...>
+
+Show what the coverage profiles meow test -coverprofile wrote say, or merge
+them. More than one profile — of several runs, or of different files — is
+merged first: a statement more than one of them has counts the runs it had in
+each, and for -covermode set, whether any of them ran it. The branches meow
+test writes beside a profile, in <profile>.branches, are read and merged the
+same way.
+
+The .nyan files a profile covers are read where it names them, from the
+current directory and then from the profile's own.
+
+Flags:
+  -func                  Print the percentage of the statements of each
+                         function that ran, and of them all, then of the
+                         branches taken, and each branch never taken
+  -html                  Write the files as a page, each line marked by whether
+                         its statements ran and, for -covermode count or
+                         atomic, how many times, and by the branches on it
+                         never taken (default: coverage.html)
+  -merge                 Write the profiles merged into one (default: stdout),
+                         and with -o, their branches beside it
+  -o <file>              Write to file
+
+Examples:
+  meow test -coverprofile=unit.out ./...
+  meow cover -func unit.out
+  meow cover -html unit.out -o coverage.html
+  meow cover -merge unit.out integration.out -o all.out`,
+
 		"lint": `Usage: meow lint [files/patterns...]
 
 Run static analysis on .nyan files. Without arguments, checks all *.nyan files
@@ -733,6 +1467,61 @@ Examples:
   meow lint ./...
   meow lint examples/`,
 
+		"mod": `Usage: meow mod <tidy|download|vendor> [dir]
+
+Manage the Go modules that the .nyan programs under dir (default: the current
+directory) fetch with nab go.
+
+  tidy      Resolve every nab go of the programs and write meow.lock beside
+            them: the imports, their pins, the version of every module the
+            build needs and its go.sum hash. Builds of programs below a
+            meow.lock start from those versions, and refuse an import or a
+            pin the lock does not have.
+  download  Fetch the locked modules into the module cache, so that builds
+            can go --offline.
+  vendor    Copy the locked modules into dir/vendor. Builds below it use that
+            copy and need neither the network nor the module cache.
+
+Run meow mod tidy again after adding or changing a nab go.
+
+Examples:
+  meow mod tidy
+  meow mod download
+  meow --offline build server.nyan
+  meow mod vendor ./tools`,
+
+		"init": `Usage: meow init [name]
+
+Make the current directory a Meow project by writing its meow.mod. The project
+is named after the directory unless a name is given; main.nyan, if there is
+one, is its entry point.
+
+meow.mod holds one thing per line:
+
+  project <name>           What the project and its binary are called
+  main <file.nyan>         What meow build builds
+  lib <file.nyan>          ... or, instead, a Go package it builds
+  pin <import path> <ver>  The version of a nab go that names none
+  lint disable <rules...>  Lint rules the project turns off
+  test paths <patterns...> What meow test runs without arguments
+  test cover               Turn on statement coverage
+  test coverprofile <file> ... and write its profile
+  test fuzztime <duration> How long each fuzz test runs
+
+Examples:
+  meow init
+  meow init shelter`,
+
+		"new": `Usage: meow new app|lib <name> [dir]
+
+Make a new project called name in dir (default: name), with a meow.mod, an
+entry point and a test of it. An app builds a program; a lib builds a Go
+package for Go code to import.
+
+Examples:
+  meow new app shelter
+  meow new lib pricing ./libs/pricing`,
+
 		"version": `Usage: meow version
 
 Print the version, commit hash, and build date of the meow compiler.`,
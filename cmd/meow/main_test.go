@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMeowTestWithNoArgumentsTestsANewProject(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "meow")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	// The project is made inside the checkout, for the meow it runs to link
+	// against the runtime there rather than a published one.
+	parent, err := os.MkdirTemp(".", "project-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(parent) })
+
+	meow := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("meow %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+	meow(parent, "new", "app", "shelter")
+	out := meow(filepath.Join(parent, "shelter"), "test")
+	if !strings.Contains(out, "=== Testing main_test.nyan ===") || !strings.Contains(out, "All 1 tests passed") {
+		t.Errorf("meow test did not test the project's own test file:\n%s", out)
+	}
+}
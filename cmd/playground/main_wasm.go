@@ -4,10 +4,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/135yshr/meow/pkg/checker"
 	"github.com/135yshr/meow/pkg/interpreter"
@@ -55,7 +57,11 @@ func runMeow(_ js.Value, args []js.Value) interface{} {
 	interp.SetTypeInfo(ti)
 	// Explicit playground step limit — adjust here if playground limit should differ from default
 	interp.SetStepLimit(10_000_000)
-	if err := interp.RunSafe(prog); err != nil {
+	// A step is not a fixed amount of time, so a program that keeps to the step
+	// limit can still hold the page up. This is what bounds that.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := interp.RunSafe(ctx, prog); err != nil {
 		b, _ := json.Marshal(result{Output: buf.String(), Error: err.Error()})
 		return string(b)
 	}
@@ -0,0 +1,159 @@
+package compiler
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+// BenchOptions say which benchmarks of a test file run, and for how long.
+type BenchOptions struct {
+	// Pattern is a regular expression the bench_ functions to run are
+	// matched against, by the name the file gives them.
+	Pattern string
+	// BenchTime is how long each benchmark runs — 2s — or how many times —
+	// 100x. Empty is Go's default.
+	BenchTime string
+	// Count is how many times each benchmark is run; 0 is once.
+	Count int
+}
+
+// CompileBenchToGo compiles a test file to the Go source of its benchmarks:
+// the program itself, the benchmarks beside it, and the names of the bench_
+// functions they run.
+func (c *Compiler) CompileBenchToGo(source, filename string) (program, benchTests string, benchNames []string, err error) {
+	c.logger.Debug("lexing", "file", filename)
+	l := lexer.New(source, filename)
+
+	c.logger.Debug("parsing", "file", filename)
+	p := parser.New(l.Tokens())
+	prog, errs := p.Parse()
+	if len(errs) > 0 {
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return "", "", nil, fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+
+	if err := c.recordGoPins(prog); err != nil {
+		return "", "", nil, err
+	}
+
+	c.logger.Debug("type checking", "file", filename)
+	ch := c.newChecker(prog)
+	typeInfo, typeErrs := ch.Check(prog)
+	if len(typeErrs) > 0 {
+		var msgs []string
+		for _, e := range typeErrs {
+			msgs = append(msgs, e.Error())
+		}
+		return "", "", nil, fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+
+	if err := c.resolveGoInterfaces(prog); err != nil {
+		return "", "", nil, err
+	}
+
+	c.logger.Debug("generating benchmark Go code", "file", filename)
+	gen := codegen.NewTest()
+	gen.SetTypeInfo(typeInfo)
+	gen.SetGoInterfaces(c.goInterfaces)
+	program, benchTests, benchNames, err = gen.GenerateBench(prog)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if formatted, fmtErr := format.Source([]byte(program)); fmtErr == nil {
+		program = string(formatted)
+	}
+	if formatted, fmtErr := format.Source([]byte(benchTests)); fmtErr == nil {
+		benchTests = string(formatted)
+	}
+	return program, benchTests, benchNames, nil
+}
+
+// RunBench compiles a _test.nyan file, with its companion source, and runs
+// those of its bench_ functions opts.Pattern matches as Go benchmarks. What
+// `go test -bench` prints is passed through as it is, allocations included,
+// so that benchstat can read it. It answers how many benchmarks ran, which is
+// none, and no error, for a file with none that match.
+func (c *Compiler) RunBench(nyanPath string, opts BenchOptions) (int, error) {
+	match, err := regexp.Compile(opts.Pattern)
+	if err != nil {
+		return 0, fmt.Errorf("Hiss! -bench %q is not a regular expression, nya~: %w", opts.Pattern, err)
+	}
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return 0, err
+	}
+	source, err := c.readWithCompanion(nyanPath)
+	if err != nil {
+		return 0, err
+	}
+	program, benchTests, names, err := c.CompileBenchToGo(source, filepath.Base(nyanPath))
+	if err != nil {
+		return 0, err
+	}
+	var run []string
+	for _, name := range names {
+		if match.MatchString(name) {
+			run = append(run, regexp.QuoteMeta(codegen.BenchName(name)))
+		}
+	}
+	if len(run) == 0 {
+		return 0, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "meow-bench-*")
+	if err != nil {
+		return 0, fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(program), 0644); err != nil {
+		return 0, fmt.Errorf("Hiss! Cannot write main.go, nya~: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "main_test.go"), []byte(benchTests), 0644); err != nil {
+		return 0, fmt.Errorf("Hiss! Cannot write main_test.go, nya~: %w", err)
+	}
+	if err := c.prepareModule(tmpDir); err != nil {
+		return 0, err
+	}
+
+	// -run matches no test, so only the benchmarks run.
+	args := []string{"test", "-run=^$", "-bench=^(" + strings.Join(run, "|") + ")$", "-benchmem"}
+	if opts.BenchTime != "" {
+		args = append(args, "-benchtime="+opts.BenchTime)
+	}
+	if opts.Count > 0 {
+		args = append(args, "-count="+strconv.Itoa(opts.Count))
+	}
+	c.logger.Debug("running benchmarks", "file", nyanPath, "args", args)
+	cmd := c.goCmd(tmpDir, args...)
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		return len(run), &TestsFailed{Err: err}
+	}
+	return len(run), nil
+}
+
+// readWithCompanion reads a test file, preceded by the source file it tests
+// when there is one, as a single text.
+func (c *Compiler) readWithCompanion(nyanPath string) (string, error) {
+	files, err := c.readTestSources(nyanPath)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, len(files))
+	for i, f := range files {
+		texts[i] = f.text
+	}
+	return strings.Join(texts, "\n"), nil
+}
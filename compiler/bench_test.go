@@ -0,0 +1,60 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+func TestBenchFunctionsRunAsGoBenchmarks(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "fib.nyan", `meow fib(n int) int {
+  sniff (n <= 1) {
+    bring n
+  }
+  bring fib(n - 1) + fib(n - 2)
+}
+`)
+	test := writeProgram(t, dir, "fib_test.nyan", `meow bench_fib(b Bench) {
+  purr (b.loop()) {
+    fib(10)
+  }
+}
+
+meow bench_fib_counted(b Bench) {
+  purr i (b.n) {
+    fib(10)
+  }
+}
+`)
+	c := compiler.New(nil)
+	for _, tt := range []struct {
+		pattern string
+		want    int
+	}{
+		{".", 2},
+		{"counted$", 1},
+		{"nothing", 0},
+	} {
+		ran, err := c.RunBench(test, compiler.BenchOptions{Pattern: tt.pattern, BenchTime: "10x", Count: 1})
+		if err != nil || ran != tt.want {
+			t.Errorf("RunBench(%q) = %d, %v; want %d benchmarks run", tt.pattern, ran, err, tt.want)
+		}
+	}
+	if _, err := c.RunBench(test, compiler.BenchOptions{Pattern: "("}); err == nil || !strings.Contains(err.Error(), "not a regular expression") {
+		t.Errorf("RunBench(%q) = %v, want the pattern refused", "(", err)
+	}
+}
+
+func TestABenchmarkThatFailsFailsTheRun(t *testing.T) {
+	dir := t.TempDir()
+	test := writeProgram(t, dir, "broken_test.nyan", `meow bench_broken(b Bench) {
+  hiss("no cats were timed")
+}
+`)
+	_, err := compiler.New(nil).RunBench(test, compiler.BenchOptions{Pattern: "."})
+	if _, failed := err.(*compiler.TestsFailed); !failed {
+		t.Errorf("RunBench = %v, want the benchmark's failure", err)
+	}
+}
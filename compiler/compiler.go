@@ -1,26 +1,33 @@
 package compiler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go/format"
+	gotoken "go/token"
+	gotypes "go/types"
+	"io"
+	"iter"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/135yshr/meow/pkg/ast"
-	"github.com/135yshr/meow/pkg/checker"
 	"github.com/135yshr/meow/pkg/codegen"
 	"github.com/135yshr/meow/pkg/lexer"
 	"github.com/135yshr/meow/pkg/mutation"
 	"github.com/135yshr/meow/pkg/parser"
+	"github.com/135yshr/meow/pkg/token"
 )
 
 // Compiler orchestrates the compilation pipeline.
@@ -28,11 +35,57 @@ type Compiler struct {
 	logger       *slog.Logger
 	coverEnabled bool
 	coverProfile string
+	coverMode    string
+	// testOptions are how RunTest runs tests; see SetTestOptions.
+	testOptions TestOptions
+	// testEvents, when set, is given what happens in a test run rather than
+	// stdout; see SetTestEvents.
+	testEvents func(TestEvent)
 	// goPins holds the versions the program pinned its Go imports to, by
 	// import path. It is read where the program is, and used where the build's
 	// go.mod is written. An import with no pin is left for the toolchain to
 	// resolve like any other.
 	goPins map[string]string
+	// goPaths are the import paths the program fetches with `nab go`, in
+	// the order it fetches them.
+	goPaths []string
+	// lock is the meow.lock the build is held to, read from lockDir, or nil
+	// when there is none; see useProjectFor.
+	lock    *Lock
+	lockDir string
+	// projectPins are the pins of the meow.mod above the program, which
+	// hold its imports that name no version; see useProjectFor.
+	projectPins map[string]string
+	// offline keeps every go command off the network; see SetOffline.
+	offline bool
+	// goPackages holds what the program's `nab go` packages export, by
+	// import path, and goAPIErr why they could not be read; see readGoAPI.
+	goPackages map[string]*gotypes.Package
+	goAPIErr   error
+	// goAPIs are the packages readGoAPI has read, by goAPIKey, kept for
+	// every later compile of this Compiler that fetches the same ones.
+	goAPIs map[string]goAPIRead
+	// fromModuleCache keeps go commands to the modules already in the
+	// module cache, while readGoAPI runs them; see goEnv.
+	fromModuleCache bool
+	// ctx stops the go commands of the build in progress when it is done;
+	// see runningUnder.
+	ctx context.Context
+	// vendoring has a build resolve its modules afresh rather than read the
+	// vendor directory VendorModules is replacing.
+	vendoring bool
+	// goInterfaces are the Go interfaces the program's kitties are groomed
+	// as, read from their packages; see resolveGoInterfaces.
+	goInterfaces []codegen.GoInterface
+	// mutationOptions are how RunMutationTest tests mutants.
+	mutationOptions MutationOptions
+	// mutationFiles are what RunMutationTest came to for each source file,
+	// for FinishMutationTests to report together.
+	mutationFiles []mutation.FileResult
+	// read are the files the builds have read, guarded by readMu, as builds
+	// of test files run at once; see FilesRead.
+	readMu sync.Mutex
+	read   map[string]bool
 }
 
 // New creates a new Compiler.
@@ -49,6 +102,20 @@ func (c *Compiler) EnableCoverage(profile string) {
 	c.coverProfile = profile
 }
 
+// CoverModes are how coverage can count the runs of a statement: whether it
+// ran, how many times, and how many times with tests running at once.
+var CoverModes = []string{"set", "count", "atomic"}
+
+// SetCoverMode sets how coverage counts the runs of a statement, one of
+// CoverModes.
+func (c *Compiler) SetCoverMode(mode string) error {
+	if !slices.Contains(CoverModes, mode) {
+		return fmt.Errorf("Hiss! -covermode wants set, count or atomic, not %q, nya~", mode)
+	}
+	c.coverMode = mode
+	return nil
+}
+
 // CompileToGo compiles a .nyan file to Go source code.
 func (c *Compiler) CompileToGo(source, filename string) (string, error) {
 	c.logger.Debug("lexing", "file", filename)
@@ -65,8 +132,12 @@ func (c *Compiler) CompileToGo(source, filename string) (string, error) {
 		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
 
+	if err := c.recordGoPins(prog); err != nil {
+		return "", err
+	}
+
 	c.logger.Debug("type checking", "file", filename)
-	ch := checker.New()
+	ch := c.newChecker(prog)
 	typeInfo, typeErrs := ch.Check(prog)
 	if len(typeErrs) > 0 {
 		var msgs []string
@@ -76,13 +147,14 @@ func (c *Compiler) CompileToGo(source, filename string) (string, error) {
 		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
 
-	if err := c.recordGoPins(prog); err != nil {
+	if err := c.resolveGoInterfaces(prog); err != nil {
 		return "", err
 	}
 
 	c.logger.Debug("generating Go code", "file", filename)
 	gen := codegen.New()
 	gen.SetTypeInfo(typeInfo)
+	gen.SetGoInterfaces(c.goInterfaces)
 	raw, err := gen.Generate(prog)
 	if err != nil {
 		return "", err
@@ -102,9 +174,16 @@ func (c *Compiler) CompileToGo(source, filename string) (string, error) {
 // whichever came last.
 func (c *Compiler) recordGoPins(prog *ast.Program) error {
 	pins := make(map[string]string)
+	var paths []string
 	for _, stmt := range prog.Stmts {
 		fs, ok := stmt.(*ast.FetchStmt)
-		if !ok || !fs.Go || fs.Version == "" {
+		if !ok || !fs.Go {
+			continue
+		}
+		if !slices.Contains(paths, fs.Path) {
+			paths = append(paths, fs.Path)
+		}
+		if fs.Version == "" {
 			continue
 		}
 		if had, pinned := pins[fs.Path]; pinned && had != fs.Version {
@@ -112,7 +191,10 @@ func (c *Compiler) recordGoPins(prog *ast.Program) error {
 		}
 		pins[fs.Path] = fs.Version
 	}
-	c.goPins = pins
+	if err := applyProjectPins(paths, pins, c.projectPins); err != nil {
+		return err
+	}
+	c.goPins, c.goPaths = pins, paths
 	return nil
 }
 
@@ -136,10 +218,7 @@ func (c *Compiler) fetchGoPins(dir string) error {
 	sort.Strings(specs)
 
 	c.logger.Debug("fetching pinned packages", "specs", specs)
-	cmd := exec.Command("go", append([]string{"get"}, specs...)...)
-	cmd.Dir = dir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := c.goCmd(dir, append([]string{"get"}, specs...)...).Run(); err != nil {
 		return fmt.Errorf("Hiss! Cannot fetch %s, nya~: %w", strings.Join(specs, " "), err)
 	}
 	return nil
@@ -147,6 +226,35 @@ func (c *Compiler) fetchGoPins(dir string) error {
 
 // Build compiles a .nyan file to an executable binary.
 func (c *Compiler) Build(nyanPath, outputPath string) error {
+	return c.BuildContext(context.Background(), nyanPath, outputPath)
+}
+
+// BuildContext is Build, with the go commands it runs killed if ctx is done
+// before they end, in which case the error is ctx's.
+func (c *Compiler) BuildContext(ctx context.Context, nyanPath, outputPath string) error {
+	defer c.runningUnder(ctx)()
+	if err := c.build(nyanPath, outputPath); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// runningUnder has the go commands c runs killed when ctx is done, until the
+// func it answers is called. A watched program saved again mid-build starts
+// over at once rather than after a go build nobody wants any more.
+func (c *Compiler) runningUnder(ctx context.Context) (restore func()) {
+	prev := c.ctx
+	c.ctx = ctx
+	return func() { c.ctx = prev }
+}
+
+func (c *Compiler) build(nyanPath, outputPath string) error {
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return err
+	}
 	source, err := os.ReadFile(nyanPath)
 	if err != nil {
 		return fmt.Errorf("Hiss! Cannot read %s, nya~: %w", nyanPath, err)
@@ -169,28 +277,10 @@ func (c *Compiler) Build(nyanPath, outputPath string) error {
 	}
 
 	// Create go.mod in temp dir
-	modRoot := c.findModuleRoot()
-	goVersion := goVersionFor(modRoot)
-	modContent, err := buildModContent(goVersion, modRoot)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
-		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
-	}
-
-	if err := c.fetchGoPins(tmpDir); err != nil {
+	if err := c.prepareModule(tmpDir); err != nil {
 		return err
 	}
 
-	// Run go mod tidy to generate go.sum
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = tmpDir
-	tidyCmd.Stderr = os.Stderr
-	if err := tidyCmd.Run(); err != nil {
-		return fmt.Errorf("Hiss! go mod tidy failed, nya~: %w", err)
-	}
-
 	if outputPath == "" {
 		base := strings.TrimSuffix(filepath.Base(nyanPath), ".nyan")
 		outputPath = base
@@ -199,10 +289,7 @@ func (c *Compiler) Build(nyanPath, outputPath string) error {
 	absOutput, _ := filepath.Abs(outputPath)
 
 	c.logger.Debug("building", "output", absOutput)
-	cmd := exec.Command("go", "build", "-o", absOutput, ".")
-	cmd.Dir = tmpDir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := c.goCmd(tmpDir, "build", "-o", absOutput, ".").Run(); err != nil {
 		return fmt.Errorf("Hiss! go build failed, nya~: %w", err)
 	}
 
@@ -216,6 +303,12 @@ func (c *Compiler) Build(nyanPath, outputPath string) error {
 // back as an *exec.ExitError, which the caller reports as its own — a program
 // that scrams with 3 is no use if the tool that ran it answers 1.
 func (c *Compiler) Run(nyanPath string, args ...string) error {
+	return c.RunContext(context.Background(), nyanPath, args...)
+}
+
+// RunContext is Run, with the program killed if ctx is done before it ends,
+// in which case the error is ctx's.
+func (c *Compiler) RunContext(ctx context.Context, nyanPath string, args ...string) error {
 	tmpBin, err := os.CreateTemp("", "meow-run-*")
 	if err != nil {
 		return err
@@ -223,25 +316,29 @@ func (c *Compiler) Run(nyanPath string, args ...string) error {
 	tmpBin.Close()
 	defer os.Remove(tmpBin.Name())
 
-	if err := c.Build(nyanPath, tmpBin.Name()); err != nil {
+	if err := c.BuildContext(ctx, nyanPath, tmpBin.Name()); err != nil {
 		return err
 	}
 
-	cmd := exec.Command(tmpBin.Name(), args...)
+	cmd := exec.CommandContext(ctx, tmpBin.Name(), args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
 }
 
-// CompileTestToGo compiles a .nyan file to Go source in test mode.
-func (c *Compiler) CompileTestToGo(source, filename string) (string, error) {
-	// First pass: extract catwalk output expectations from comments.
-	c.logger.Debug("extracting catwalk outputs", "file", filename)
-	l1 := lexer.New(source, filename)
-	catwalkOutputs := codegen.ExtractCatwalkOutputs(l1.Tokens())
+// CompileLibToGo compiles a .nyan file to the Go source of a library package
+// named pkg, for Go code to import rather than a program to run.
+func (c *Compiler) CompileLibToGo(source, filename, pkg string) (string, error) {
+	c.logger.Debug("extracting doc comments", "file", filename)
+	docs := codegen.ExtractDocComments(lexer.New(source, filename).Tokens())
 
-	// Second pass: normal lex + parse.
 	c.logger.Debug("lexing", "file", filename)
 	l := lexer.New(source, filename)
 
@@ -256,8 +353,12 @@ func (c *Compiler) CompileTestToGo(source, filename string) (string, error) {
 		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
 
+	if err := c.recordGoPins(prog); err != nil {
+		return "", err
+	}
+
 	c.logger.Debug("type checking", "file", filename)
-	ch := checker.New()
+	ch := c.newChecker(prog)
 	typeInfo, typeErrs := ch.Check(prog)
 	if len(typeErrs) > 0 {
 		var msgs []string
@@ -267,15 +368,201 @@ func (c *Compiler) CompileTestToGo(source, filename string) (string, error) {
 		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
 
+	if err := c.resolveGoInterfaces(prog); err != nil {
+		return "", err
+	}
+
+	c.logger.Debug("generating library Go code", "file", filename, "package", pkg)
+	gen := codegen.NewLib(pkg, filename)
+	gen.SetTypeInfo(typeInfo)
+	gen.SetGoInterfaces(c.goInterfaces)
+	gen.SetDocComments(docs)
+	raw, err := gen.GenerateLib(prog)
+	if err != nil {
+		return "", err
+	}
+	formatted, err := format.Source([]byte(raw))
+	if err != nil {
+		return raw, nil
+	}
+	return string(formatted), nil
+}
+
+// BuildLib compiles a .nyan file into a Go package in outDir, named after the
+// directory: ./gen/pricing holds package pricing.
+//
+// The package is built once in a scratch module before anything is written, so
+// what lands in outDir is known to compile. Beside it goes go.mod.snippet, the
+// requirements the importing module needs to add — the package is handed over
+// as source, and the module it lands in is the importer's own.
+func (c *Compiler) BuildLib(nyanPath, outDir string) error {
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return err
+	}
+	source, err := os.ReadFile(nyanPath)
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot read %s, nya~: %w", nyanPath, err)
+	}
+	base := strings.TrimSuffix(filepath.Base(nyanPath), ".nyan")
+	if outDir == "" {
+		outDir = base
+	}
+	pkg := libPackageName(filepath.Base(outDir))
+	if pkg == "" {
+		pkg = libPackageName(base)
+	}
+	if pkg == "" {
+		return fmt.Errorf("Hiss! Cannot tell what to call the package in %s, nya~", outDir)
+	}
+
+	goCode, err := c.CompileLibToGo(string(source), filepath.Base(nyanPath), pkg)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "meow-build-*")
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	pkgDir := filepath.Join(tmpDir, pkg)
+	if err := os.Mkdir(pkgDir, 0755); err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, base+".go"), []byte(goCode), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
+	}
+
+	if err := c.prepareModule(tmpDir); err != nil {
+		return err
+	}
+
+	c.logger.Debug("building", "package", pkg)
+	if err := c.goCmd(tmpDir, "build", "./...").Run(); err != nil {
+		return fmt.Errorf("Hiss! go build failed, nya~: %w", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("Hiss! Cannot create %s, nya~: %w", outDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, base+".go"), []byte(goCode), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
+	}
+	snippet, err := libModSnippet(pkg, c.findModuleRoot(), c.goPins)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "go.mod.snippet"), []byte(snippet), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.mod.snippet, nya~: %w", err)
+	}
+	return nil
+}
+
+// libPackageName makes a Go package name out of a directory name, keeping the
+// letters, digits and underscores of it. It gives back "" when nothing usable
+// is left.
+func libPackageName(dir string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || name == "_" || (name[0] >= '0' && name[0] <= '9') || gotoken.IsKeyword(name) {
+		return ""
+	}
+	return name
+}
+
+// libModSnippet writes the go.mod lines a module importing a generated library
+// needs: the runtime the package links against, pinned the way a build pins
+// it, and the Go packages the program pinned itself. Those are named as
+// `go get` commands rather than require lines, since a pin names an import
+// path and only the toolchain knows which module provides it.
+func libModSnippet(pkg, modRoot string, pins map[string]string) (string, error) {
+	if strings.ContainsAny(modRoot, "\n\r") {
+		return "", fmt.Errorf("hiss! module root path contains invalid characters, nya~")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// What package %s needs in the go.mod of the module that imports it.\n", pkg)
+	b.WriteString("// Add these lines to it, then run go mod tidy.\n\n")
+	switch version, ok := runtimeRequirement(); {
+	case modRoot != "":
+		fmt.Fprintf(&b, "require %s v0.0.0\n\nreplace %s => %s\n",
+			meowModulePath, meowModulePath, strconv.Quote(modRoot))
+	case ok:
+		fmt.Fprintf(&b, "require %s %s\n", meowModulePath, version)
+	default:
+		fmt.Fprintf(&b, "// No release of the meow runtime is known to this compiler; run\n//\n//\tgo get %s\n", meowModulePath)
+	}
+	if len(pins) > 0 {
+		specs := make([]string, 0, len(pins))
+		for path, version := range pins {
+			specs = append(specs, path+"@"+version)
+		}
+		sort.Strings(specs)
+		b.WriteString("\n// The program pins these Go packages; fetch them with\n//\n")
+		fmt.Fprintf(&b, "//\tgo get %s\n", strings.Join(specs, " "))
+	}
+	return b.String(), nil
+}
+
+// CompileTestToGo compiles a .nyan file to Go source in test mode.
+func (c *Compiler) CompileTestToGo(source, filename string) (string, error) {
+	return c.compileTestSources([]sourceFile{{path: filename, text: source}})
+}
+
+// compileTestSources compiles a test file, preceded by the source file it
+// tests when there is one, to Go source in test mode.
+func (c *Compiler) compileTestSources(files []sourceFile) (string, error) {
+	filename := files[len(files)-1].name()
+
+	// First pass: extract catwalk output expectations from comments.
+	c.logger.Debug("extracting catwalk outputs", "file", filename)
+	catwalkOutputs := codegen.ExtractCatwalkOutputs(sourceTokens(files))
+
+	// Second pass: normal lex + parse.
+	c.logger.Debug("parsing", "file", filename)
+	p := parser.New(sourceTokens(files))
+	prog, errs := p.Parse()
+	if len(errs) > 0 {
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+
 	if err := c.recordGoPins(prog); err != nil {
 		return "", err
 	}
 
+	c.logger.Debug("type checking", "file", filename)
+	ch := c.newChecker(prog)
+	typeInfo, typeErrs := ch.Check(prog)
+	if len(typeErrs) > 0 {
+		var msgs []string
+		for _, e := range typeErrs {
+			msgs = append(msgs, e.Error())
+		}
+		return "", fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+
+	if err := c.resolveGoInterfaces(prog); err != nil {
+		return "", err
+	}
+
 	c.logger.Debug("generating test Go code", "file", filename)
 	gen := codegen.NewTest()
 	gen.SetTypeInfo(typeInfo)
+	gen.SetGoInterfaces(c.goInterfaces)
 	if c.coverEnabled {
 		gen.EnableCoverage(filename)
+		for _, f := range files {
+			gen.SetCoverPath(f.name(), filepath.ToSlash(f.path))
+		}
 	}
 	if len(catwalkOutputs) > 0 {
 		gen.SetCatwalkOutput(catwalkOutputs)
@@ -295,23 +582,15 @@ func (c *Compiler) CompileTestToGo(source, filename string) (string, error) {
 // If a companion source file exists (e.g. math.nyan for math_test.nyan),
 // it is automatically prepended so the test can call its functions.
 func (c *Compiler) BuildTest(nyanPath, outputPath string) error {
-	source, err := os.ReadFile(nyanPath)
-	if err != nil {
-		return fmt.Errorf("Hiss! Cannot read %s, nya~: %w", nyanPath, err)
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return err
 	}
-
-	combined := string(source)
-	if companionPath := companionSourcePath(nyanPath); companionPath != "" {
-		companionData, err := os.ReadFile(companionPath)
-		if err == nil {
-			c.logger.Debug("including companion source", "file", companionPath)
-			combined = string(companionData) + "\n" + combined
-		} else if !os.IsNotExist(err) {
-			return fmt.Errorf("Hiss! Cannot read companion %s, nya~: %w", companionPath, err)
-		}
+	files, err := c.readTestSources(nyanPath)
+	if err != nil {
+		return err
 	}
 
-	goCode, err := c.CompileTestToGo(combined, filepath.Base(nyanPath))
+	goCode, err := c.compileTestSources(files)
 	if err != nil {
 		return err
 	}
@@ -327,26 +606,9 @@ func (c *Compiler) BuildTest(nyanPath, outputPath string) error {
 		return fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
 	}
 
-	modRoot := c.findModuleRoot()
-	goVersion := goVersionFor(modRoot)
-	modContent, err := buildModContent(goVersion, modRoot)
-	if err != nil {
+	if err := c.prepareModule(tmpDir); err != nil {
 		return err
 	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
-		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
-	}
-
-	if err := c.fetchGoPins(tmpDir); err != nil {
-		return err
-	}
-
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = tmpDir
-	tidyCmd.Stderr = os.Stderr
-	if err := tidyCmd.Run(); err != nil {
-		return fmt.Errorf("Hiss! go mod tidy failed, nya~: %w", err)
-	}
 
 	if outputPath == "" {
 		base := strings.TrimSuffix(filepath.Base(nyanPath), ".nyan")
@@ -356,10 +618,7 @@ func (c *Compiler) BuildTest(nyanPath, outputPath string) error {
 	absOutput, _ := filepath.Abs(outputPath)
 
 	c.logger.Debug("building test", "output", absOutput)
-	cmd := exec.Command("go", "build", "-o", absOutput, ".")
-	cmd.Dir = tmpDir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := c.goCmd(tmpDir, "build", "-o", absOutput, ".").Run(); err != nil {
 		return fmt.Errorf("Hiss! go build failed, nya~: %w", err)
 	}
 
@@ -369,6 +628,17 @@ func (c *Compiler) BuildTest(nyanPath, outputPath string) error {
 // CompileFuzzToGo compiles a .nyan file to fuzz test Go source.
 // Returns helper code and fuzz test code separately.
 func (c *Compiler) CompileFuzzToGo(source, filename string) (helpers, fuzzTests string, fuzzNames []string, err error) {
+	var targets []fuzzTarget
+	helpers, fuzzTests, targets, err = c.compileFuzz(source, filename, "")
+	for _, t := range targets {
+		fuzzNames = append(fuzzNames, t.goName)
+	}
+	return helpers, fuzzTests, fuzzNames, err
+}
+
+// compileFuzz is CompileFuzzToGo, with the seeds saved under seedDir added to
+// those the fuzz_ functions give, if seedDir is not "".
+func (c *Compiler) compileFuzz(source, filename, seedDir string) (helpers, fuzzTests string, targets []fuzzTarget, err error) {
 	c.logger.Debug("lexing", "file", filename)
 	l := lexer.New(source, filename)
 
@@ -382,9 +652,18 @@ func (c *Compiler) CompileFuzzToGo(source, filename string) (helpers, fuzzTests
 		}
 		return "", "", nil, fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
+	if seedDir != "" {
+		if err := addSavedSeeds(prog, seedDir); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	if pinErr := c.recordGoPins(prog); pinErr != nil {
+		return "", "", nil, pinErr
+	}
 
 	c.logger.Debug("type checking", "file", filename)
-	ch := checker.New()
+	ch := c.newChecker(prog)
 	typeInfo, typeErrs := ch.Check(prog)
 	if len(typeErrs) > 0 {
 		var msgs []string
@@ -394,17 +673,14 @@ func (c *Compiler) CompileFuzzToGo(source, filename string) (helpers, fuzzTests
 		return "", "", nil, fmt.Errorf("%s", strings.Join(msgs, "\n"))
 	}
 
-	if pinErr := c.recordGoPins(prog); pinErr != nil {
-		return "", "", nil, pinErr
-	}
-
 	c.logger.Debug("generating fuzz Go code", "file", filename)
 	gen := codegen.New()
 	gen.SetTypeInfo(typeInfo)
-	helpers, fuzzTests, fuzzNames, err = gen.GenerateFuzz(prog)
+	helpers, fuzzTests, fuzzNames, err := gen.GenerateFuzz(prog)
 	if err != nil {
 		return "", "", nil, err
 	}
+	targets = fuzzTargets(prog, fuzzNames)
 
 	if formatted, fmtErr := format.Source([]byte(helpers)); fmtErr == nil {
 		helpers = string(formatted)
@@ -412,23 +688,41 @@ func (c *Compiler) CompileFuzzToGo(source, filename string) (helpers, fuzzTests
 	if formatted, fmtErr := format.Source([]byte(fuzzTests)); fmtErr == nil {
 		fuzzTests = string(formatted)
 	}
-	return helpers, fuzzTests, fuzzNames, nil
+	return helpers, fuzzTests, targets, nil
 }
 
 // RunFuzz compiles a .nyan file and runs Go fuzz testing.
 // Each fuzz_ function in the file is executed individually.
 func (c *Compiler) RunFuzz(nyanPath, fuzzTime string) error {
+	if c.testEvents == nil {
+		return c.runFuzz(nyanPath, fuzzTime, nil)
+	}
+	began := time.Now()
+	events := c.newEventStream(nyanPath, decodeFuzzEvent)
+	events.start()
+	err := c.runFuzz(nyanPath, fuzzTime, events)
+	events.done(began, err)
+	return err
+}
+
+// runFuzz runs the fuzz targets of a file, writing `go test -json` to events
+// when it is set, and go test's own text otherwise.
+func (c *Compiler) runFuzz(nyanPath, fuzzTime string, events *eventStream) error {
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return err
+	}
 	source, err := os.ReadFile(nyanPath)
 	if err != nil {
 		return fmt.Errorf("Hiss! Cannot read %s, nya~: %w", nyanPath, err)
 	}
 
-	helpers, fuzzTests, fuzzNames, err := c.CompileFuzzToGo(string(source), filepath.Base(nyanPath))
+	seedDir := filepath.Join(filepath.Dir(nyanPath), fuzzSeedDir)
+	helpers, fuzzTests, targets, err := c.compileFuzz(string(source), filepath.Base(nyanPath), seedDir)
 	if err != nil {
 		return err
 	}
 
-	if len(fuzzNames) == 0 {
+	if len(targets) == 0 {
 		return fmt.Errorf("Hiss! No fuzz_ functions found in %s, nya~", nyanPath)
 	}
 
@@ -445,40 +739,36 @@ func (c *Compiler) RunFuzz(nyanPath, fuzzTime string) error {
 		return fmt.Errorf("Hiss! Cannot write main_test.go, nya~: %w", err)
 	}
 
-	modRoot := c.findModuleRoot()
-	goVersion := goVersionFor(modRoot)
-	modContent, err := buildModContent(goVersion, modRoot)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
-		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
-	}
-
-	if err := c.fetchGoPins(tmpDir); err != nil {
+	if err := c.prepareModule(tmpDir); err != nil {
 		return err
 	}
 
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = tmpDir
-	tidyCmd.Stderr = os.Stderr
-	if err := tidyCmd.Run(); err != nil {
-		return fmt.Errorf("Hiss! go mod tidy failed, nya~: %w", err)
-	}
-
 	if fuzzTime == "" {
 		fuzzTime = "10s"
 	}
 
 	// Run each fuzz function individually (Go requires -fuzz to match exactly one target)
-	for _, name := range fuzzNames {
+	for _, target := range targets {
+		name := target.goName
 		c.logger.Debug("running fuzz", "target", name, "fuzztime", fuzzTime)
-		fmt.Fprintf(os.Stdout, "  --- %s ---\n", name)
-		cmd := exec.Command("go", "test", fmt.Sprintf("-fuzz=^%s$", regexp.QuoteMeta(name)), fmt.Sprintf("-fuzztime=%s", fuzzTime))
-		cmd.Dir = tmpDir
+		args := []string{"test", fmt.Sprintf("-fuzz=^%s$", regexp.QuoteMeta(name)), fmt.Sprintf("-fuzztime=%s", fuzzTime)}
+		if events != nil {
+			args = append(args, "-json")
+		} else {
+			fmt.Fprintf(os.Stdout, "  --- %s ---\n", name)
+		}
+		cmd := c.goCmd(tmpDir, args...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
+		if events != nil {
+			cmd.Stdout, cmd.Stderr = events, events
+		}
 		if err := cmd.Run(); err != nil {
+			saved := io.Writer(os.Stdout)
+			if events != nil {
+				saved = os.Stderr
+			}
+			c.saveFailingInputs(tmpDir, target, seedDir, saved)
 			return fmt.Errorf("Hiss! fuzz %s failed, nya~: %w", name, err)
 		}
 	}
@@ -502,6 +792,24 @@ func (e *TestsFailed) Unwrap() error { return e.Err }
 
 // RunTest compiles and runs a _test.nyan file.
 func (c *Compiler) RunTest(nyanPath string) error {
+	return c.RunTestContext(context.Background(), nyanPath)
+}
+
+// RunTestContext is RunTest, with the tests killed if ctx is done before they
+// end.
+func (c *Compiler) RunTestContext(ctx context.Context, nyanPath string) error {
+	if c.testEvents == nil {
+		return c.runTest(ctx, nyanPath, os.Stdout, os.Stderr)
+	}
+	began := time.Now()
+	events := c.newEventStream(nyanPath, decodeMeowEvent)
+	events.start()
+	err := c.runTest(ctx, nyanPath, events, events)
+	events.done(began, err)
+	return err
+}
+
+func (c *Compiler) runTest(ctx context.Context, nyanPath string, stdout, stderr io.Writer) error {
 	tmpBin, err := os.CreateTemp("", "meow-test-run-*")
 	if err != nil {
 		return err
@@ -509,24 +817,39 @@ func (c *Compiler) RunTest(nyanPath string) error {
 	tmpBin.Close()
 	defer os.Remove(tmpBin.Name())
 
-	if err := c.BuildTest(nyanPath, tmpBin.Name()); err != nil {
+	restore := c.runningUnder(ctx)
+	err = c.BuildTest(nyanPath, tmpBin.Name())
+	restore()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return err
 	}
+	return c.execTest(ctx, nyanPath, tmpBin.Name(), stdout, stderr)
+}
 
-	cmd := exec.Command(tmpBin.Name())
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	if c.coverProfile != "" {
-		cmd.Env = append(os.Environ(), "MEOW_COVERPROFILE="+c.coverProfile)
+// execTest runs the test binary built from nyanPath.
+func (c *Compiler) execTest(ctx context.Context, nyanPath, bin string, stdout, stderr io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Stdin = os.Stdin
+	cmd.Env = append(os.Environ(), c.testEnv(nyanPath)...)
 	if err := cmd.Run(); err != nil {
 		// An exit status means the binary ran and spoke for itself. Anything
 		// else means it never started, and only this says so.
 		var exited *exec.ExitError
-		if errors.As(err, &exited) {
+		if errors.As(err, &exited) && ctx.Err() == nil {
 			return &TestsFailed{Err: err}
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return err
 	}
 	return nil
@@ -534,6 +857,9 @@ func (c *Compiler) RunTest(nyanPath string) error {
 
 // RunMutationTest runs mutation testing on a source file using the given test files.
 func (c *Compiler) RunMutationTest(sourcePath string, testPaths []string) error {
+	if err := c.useProjectFor(sourcePath); err != nil {
+		return err
+	}
 	// Read and parse the source file
 	source, err := os.ReadFile(sourcePath)
 	if err != nil {
@@ -561,6 +887,9 @@ func (c *Compiler) RunMutationTest(sourcePath string, testPaths []string) error
 
 	// Parse test files and combine ASTs (source AST nodes are shared so mutant closures remain valid)
 	combinedProg := &ast.Program{Stmts: append([]ast.Stmt{}, prog.Stmts...)}
+	var testSources [][]byte
+	var testProgs []*ast.Program
+	var testFiles []mutation.File
 	for _, tp := range testPaths {
 		data, err := os.ReadFile(tp)
 		if err != nil {
@@ -577,6 +906,30 @@ func (c *Compiler) RunMutationTest(sourcePath string, testPaths []string) error
 			return fmt.Errorf("%s", strings.Join(msgs, "\n"))
 		}
 		combinedProg.Stmts = append(combinedProg.Stmts, testProg.Stmts...)
+		testSources = append(testSources, data)
+		testProgs = append(testProgs, testProg)
+		testFiles = append(testFiles, mutation.File{Source: data, Prog: testProg})
+	}
+
+	// The same source and tests have the same mutants, which come to the
+	// same ends.
+	opts := c.mutationOptions
+	var cache *mutation.Cache
+	base := mutation.BaseKey(toolID(), c.buildInputs()...)
+	key := mutation.Key(base, source, testSources, mutants)
+	if !opts.NoCache {
+		if cache, err = mutation.DefaultCache(); err != nil {
+			c.logger.Debug("no mutation cache", "err", err)
+			cache = nil
+		}
+	}
+	if cache != nil {
+		if results, ok := cache.Load(key); ok && len(results) == len(mutants) {
+			fmt.Println("Unchanged since the last run; results from the cache, nya~")
+			mutation.Report(os.Stdout, mutants, results)
+			c.recordMutationFile(sourcePath, source, mutants, results)
+			return nil
+		}
 	}
 
 	// Build schema using source-only mutants to avoid mutating test code
@@ -586,9 +939,15 @@ func (c *Compiler) RunMutationTest(sourcePath string, testPaths []string) error
 	if pinErr := c.recordGoPins(combinedProg); pinErr != nil {
 		return pinErr
 	}
+	if err := c.resolveGoInterfaces(combinedProg); err != nil {
+		return err
+	}
 
 	gen := codegen.NewTest()
 	gen.SetMutations(schema)
+	gen.SetGoInterfaces(c.goInterfaces)
+	// Coverage is what tells which tests reach each mutant.
+	gen.EnableCoverage(filepath.Base(sourcePath))
 	raw, err := gen.GenerateTest(combinedProg)
 	if err != nil {
 		return err
@@ -610,41 +969,37 @@ func (c *Compiler) RunMutationTest(sourcePath string, testPaths []string) error
 		return fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
 	}
 
-	modRoot := c.findModuleRoot()
-	goVersion := goVersionFor(modRoot)
-	modContent, err := buildModContent(goVersion, modRoot)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
-		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
-	}
-
-	if err := c.fetchGoPins(tmpDir); err != nil {
+	if err := c.prepareModule(tmpDir); err != nil {
 		return err
 	}
 
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = tmpDir
-	tidyCmd.Stderr = os.Stderr
-	if err := tidyCmd.Run(); err != nil {
-		return fmt.Errorf("Hiss! go mod tidy failed, nya~: %w", err)
-	}
-
 	binPath := filepath.Join(tmpDir, "mutant_test")
-	buildCmd := exec.Command("go", "build", "-o", binPath, ".")
-	buildCmd.Dir = tmpDir
-	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
+	if err := c.goCmd(tmpDir, "build", "-o", binPath, ".").Run(); err != nil {
 		return fmt.Errorf("Hiss! go build failed, nya~: %w", err)
 	}
 
 	// Run mutation tests
-	runner := mutation.NewRunner(binPath, 10*time.Second)
-	results := runner.RunAll(mutants)
+	runner := mutation.NewRunner(binPath, mutantTimeout)
+	if opts.Workers > 0 {
+		runner.Workers = opts.Workers
+	}
+	if tests := testNames(testProgs); len(tests) > 0 {
+		cov, err := mutationCoverage(binPath, tmpDir, tests, runner.Workers)
+		if err != nil {
+			return err
+		}
+		runner.Coverage = cov
+	}
+	results := c.runMutants(runner, cache, base, mutation.File{Source: source, Prog: prog}, testFiles, mutants)
+	if cache != nil {
+		if err := cache.Store(key, results); err != nil {
+			c.logger.Debug("cannot cache mutation results", "err", err)
+		}
+	}
 
 	// Report
 	mutation.Report(os.Stdout, mutants, results)
+	c.recordMutationFile(sourcePath, source, mutants, results)
 	return nil
 }
 
@@ -765,6 +1120,57 @@ func buildModContent(goVersion, modRoot string) (string, error) {
 		goVersion, meowModulePath, meowModulePath, strconv.Quote(modRoot)), nil
 }
 
+// sourceFile is a .nyan file and what it says.
+type sourceFile struct {
+	path string
+	text string
+}
+
+// name is what the positions in f are of: the file's own name, as in the
+// messages about it.
+func (f sourceFile) name() string {
+	return filepath.Base(f.path)
+}
+
+// readTestSources reads a test file, preceded by the source file it tests
+// when there is one.
+func (c *Compiler) readTestSources(nyanPath string) ([]sourceFile, error) {
+	source, err := os.ReadFile(nyanPath)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", nyanPath, err)
+	}
+	files := []sourceFile{{path: nyanPath, text: string(source)}}
+	if companionPath := companionSourcePath(nyanPath); companionPath != "" {
+		companionData, err := os.ReadFile(companionPath)
+		if err == nil {
+			c.readFrom(companionPath)
+			c.logger.Debug("including companion source", "file", companionPath)
+			files = append([]sourceFile{{path: companionPath, text: string(companionData)}}, files...)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("Hiss! Cannot read companion %s, nya~: %w", companionPath, err)
+		}
+	}
+	return files, nil
+}
+
+// sourceTokens are the tokens of files one after the other, as a single
+// program. Each file is lexed as itself, so that a token is at its own file
+// and line, rather than at where it falls in the files run together.
+func sourceTokens(files []sourceFile) iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for i, f := range files {
+			for tok := range lexer.New(f.text, f.name()).Tokens() {
+				if tok.Type == token.EOF && i < len(files)-1 {
+					break
+				}
+				if !yield(tok) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // companionSourcePath returns the inferred source file path for a test file.
 // e.g. "testdata/math_test.nyan" → "testdata/math.nyan"
 func companionSourcePath(testPath string) string {
@@ -777,6 +1183,19 @@ func companionSourcePath(testPath string) string {
 	return filepath.Join(dir, name+".nyan")
 }
 
+// CompanionFiles are the files that go with the .nyan file at path and exist:
+// the source a test file is compiled with, or the test file of a source file.
+func CompanionFiles(path string) []string {
+	companion := companionSourcePath(path)
+	if companion == "" {
+		companion = strings.TrimSuffix(path, ".nyan") + "_test.nyan"
+	}
+	if _, err := os.Stat(companion); err != nil {
+		return nil
+	}
+	return []string{companion}
+}
+
 // findModuleRoot locates the meow source tree to compile against, or returns ""
 // when there is none in scope.
 //
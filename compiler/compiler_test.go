@@ -2,8 +2,10 @@ package compiler_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -278,6 +280,23 @@ func TestRunReportsNoErrorWhenTheProgramSucceeds(t *testing.T) {
 	}
 }
 
+// The go commands of a build are killed with the context it runs under, so a
+// build nobody is waiting for any more does not run on to the end.
+func TestABuildStopsWithItsContext(t *testing.T) {
+	dir := t.TempDir()
+	nyanPath := writeProgram(t, dir, "prog.nyan", "nya(\"hello\")\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := filepath.Join(dir, "prog")
+	if err := compiler.New(nil).BuildContext(ctx, nyanPath, out); !errors.Is(err, context.Canceled) {
+		t.Errorf("BuildContext = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("a binary was built after the context was done")
+	}
+}
+
 // A fully typed function returns a native Go type and cannot pass a Furball
 // back, so a refused status is raised there rather than dropped. Emitting a
 // bare call left the program running as if nothing had been asked: this printed
@@ -507,3 +526,175 @@ func TestAPackageMeowDoesNotHaveSaysWhatThereIs(t *testing.T) {
 		}
 	}
 }
+
+// Whether a groom answers the interface it names is a question about the Go
+// package, and the answer should be Meow's, at the groom, rather than the Go
+// compiler's about a generated adapter.
+func TestAGroomThatDoesNotAnswerItsInterfaceIsRefused(t *testing.T) {
+	tests := []struct {
+		name  string
+		groom string
+		want  []string
+	}{
+		{
+			"a method missing",
+			"groom Cat as io.ReadWriter {\n  meow write(p litter) int {\n    bring 0\n  }\n}\n",
+			[]string{"Cat is groomed as io.ReadWriter but has no method answering Read", "cat.nyan:7:14"},
+		},
+		{
+			"a method taking too much",
+			"groom Cat as io.Closer {\n  meow close(now bool) {\n    nya(now)\n  }\n}\n",
+			[]string{"close takes 1 arguments, but io.Closer.Close takes 0"},
+		},
+		{
+			"not an interface",
+			"groom Cat as io.SectionReader {\n}\n",
+			[]string{"io.SectionReader is not an interface"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := compiler.New(nil)
+			_, err := c.CompileToGo("nab go \"io\"\n\nkitty Cat {\n  name: string\n}\n\n"+tt.groom, "cat.nyan")
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("says %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAGoCallIsCheckedAgainstThePackage(t *testing.T) {
+	tests := []struct {
+		name string
+		call string
+		want []string
+	}{
+		{
+			"too few arguments",
+			`nyan loud = strings.repeat("nya")`,
+			[]string{"strings.repeat expects 2 arguments but got 1", "cat.nyan:3:27"},
+		},
+		{
+			"the wrong argument",
+			`nyan loud = strings.repeat("nya", "3")`,
+			[]string{"Argument 2 for strings.repeat: expected int but got string"},
+		},
+		{
+			"the result used as what it is not",
+			`nyan n = strings.count("nya", "a") + "s"`,
+			[]string{"Cannot add int and string"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := compiler.New(nil)
+			_, err := c.CompileToGo("nab go \"strings\"\n\n"+tt.call+"\n", "cat.nyan")
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("says %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// Every test file of a run is compiled by the one Compiler, and most fetch the
+// same packages: those are read once.
+func TestGoPackagesAreReadOncePerCompiler(t *testing.T) {
+	var log bytes.Buffer
+	c := compiler.New(slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	for _, name := range []string{"a.nyan", "b.nyan"} {
+		if _, err := c.CompileToGo("nab go \"strings\"\n\nnya(strings.repeat(\"nya\", 2))\n", name); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if n := strings.Count(log.String(), "reading Go packages"); n != 1 {
+		t.Errorf("read the packages %d times, want once:\n%s", n, log.String())
+	}
+}
+
+// A package the module cache does not hold is the build's to fetch; until it
+// is there, the calls into it go unchecked, and the user is told so.
+func TestAnUnreadGoPackageIsWarnedAbout(t *testing.T) {
+	var log bytes.Buffer
+	c := compiler.New(slog.New(slog.NewTextHandler(&log, nil)))
+	if _, err := c.CompileToGo("nab go \"example.com/nowhere/cat\"\n\nnya(cat.purr(1))\n", "cat.nyan"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(log.String(), "level=WARN") || !strings.Contains(log.String(), "not checked") {
+		t.Errorf("logged %q, want a warning that the calls are not checked", log.String())
+	}
+}
+
+// A library is only worth building if Go code can import it: the generated
+// package and the go.mod lines beside it are all an importing module needs.
+func TestBuildLibIsImportableFromGo(t *testing.T) {
+	dir := t.TempDir()
+	nyanPath := filepath.Join(dir, "pricing.nyan")
+	source := `# with_tax adds tax to a price in yen.
+trill meow with_tax(price int) int {
+  bring price + price * 10 / 100
+}
+
+meow count(items litter) int {
+  bring len(items)
+}
+`
+	if err := os.WriteFile(nyanPath, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkgDir := filepath.Join(dir, "gen", "pricing")
+	if err := compiler.New(nil).BuildLib(nyanPath, pkgDir); err != nil {
+		t.Fatalf("BuildLib: %v", err)
+	}
+	snippet, err := os.ReadFile(filepath.Join(pkgDir, "go.mod.snippet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := "module example.com/shop\n\ngo 1.26\n\n" + string(snippet)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mainGo := `package main
+
+import (
+	"fmt"
+
+	"example.com/shop/gen/pricing"
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+func main() {
+	fmt.Println(pricing.WithTax(1000), pricing.Count(meowrt.NewList(meowrt.NewInt(1))))
+}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "cmd", "shop"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmd", "shop", "main.go"), []byte(mainGo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tidy := exec.Command("go", "mod", "tidy")
+	tidy.Dir = dir
+	if out, err := tidy.CombinedOutput(); err != nil {
+		t.Fatalf("go mod tidy: %v\n%s", err, out)
+	}
+	run := exec.Command("go", "run", "./cmd/shop")
+	run.Dir = dir
+	out, err := run.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "1100 1" {
+		t.Errorf("got %q, want %q", got, "1100 1")
+	}
+}
@@ -0,0 +1,326 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+// CoverReport is what `meow cover` makes of coverage profiles: the profiles
+// merged into one, and the .nyan files it covers, read back to show it
+// against. A file is looked for where the profile names it, from the
+// directory meow cover is run in and then from the profiles' own. The
+// branches of a profile are read from beside it, when it has any.
+type CoverReport struct {
+	Profile *coverage.Profile
+	files   []*coverFile
+}
+
+// coverFile is a file a profile covers, and the blocks and branches it has of
+// it.
+type coverFile struct {
+	name     string
+	lines    []string
+	blocks   []coverage.Block
+	branches []coverage.Branch
+	funcs    []coverFunc
+}
+
+// coverFunc is a function of a covered file, from the line it starts on to
+// the line the next top-level statement does.
+type coverFunc struct {
+	name      string
+	line, end int
+}
+
+// ReadCoverReport reads the profiles at paths, merges them, and reads the
+// files they cover.
+func ReadCoverReport(paths []string) (*CoverReport, error) {
+	var profiles []*coverage.Profile
+	dirs := []string{"."}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+		}
+		p, err := coverage.ReadProfile(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Hiss! %s is not a coverage profile, nya~: %w", path, err)
+		}
+		if p.Branches, err = readBranches(coverage.BranchesPath(path)); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	merged, err := coverage.Merge(profiles...)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot merge the profiles, nya~: %w", err)
+	}
+	r := &CoverReport{Profile: merged}
+	for _, b := range merged.Blocks {
+		f, err := r.file(b.FileName, dirs)
+		if err != nil {
+			return nil, err
+		}
+		f.blocks = append(f.blocks, b)
+	}
+	for _, b := range merged.Branches {
+		f, err := r.file(b.FileName, dirs)
+		if err != nil {
+			return nil, err
+		}
+		f.branches = append(f.branches, b)
+	}
+	return r, nil
+}
+
+// readBranches reads the branches kept at path, and none when there is no
+// file there: a profile of a run that had none, or that go test wrote.
+func readBranches(path string) ([]coverage.Branch, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	defer f.Close()
+	bs, err := coverage.ReadBranches(f)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! %s is not a list of branches, nya~: %w", path, err)
+	}
+	return bs, nil
+}
+
+// file is the covered file the profile names name, read the first time it is
+// asked for.
+func (r *CoverReport) file(name string, dirs []string) (*coverFile, error) {
+	for _, f := range r.files {
+		if f.name == name {
+			return f, nil
+		}
+	}
+	f, err := readCoverFile(name, dirs)
+	if err != nil {
+		return nil, err
+	}
+	r.files = append(r.files, f)
+	return f, nil
+}
+
+// readCoverFile reads the file a profile names name, from the first of dirs
+// it is found in, and finds its functions.
+func readCoverFile(name string, dirs []string) (*coverFile, error) {
+	var data []byte
+	err := os.ErrNotExist
+	for _, dir := range dirs {
+		path := filepath.FromSlash(name)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if data, err = os.ReadFile(path); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot find %s, which the profile covers, nya~", name)
+	}
+	text := string(data)
+	f := &coverFile{name: name, lines: strings.Split(strings.TrimRight(text, "\n"), "\n")}
+
+	// A file that no longer parses is still shown; it only has no
+	// functions to give percentages of.
+	prog, errs := parser.New(lexer.New(text, filepath.Base(name)).Tokens()).Parse()
+	if len(errs) > 0 {
+		return f, nil
+	}
+	for i, stmt := range prog.Stmts {
+		end := len(f.lines) + 1
+		if i+1 < len(prog.Stmts) {
+			end = prog.Stmts[i+1].Pos().Line
+		}
+		switch s := stmt.(type) {
+		case *ast.FuncStmt:
+			f.funcs = append(f.funcs, coverFunc{name: s.Name, line: s.Pos().Line, end: end})
+		case *ast.LearnStmt:
+			for j, m := range s.Methods {
+				methodEnd := end
+				if j+1 < len(s.Methods) {
+					methodEnd = s.Methods[j+1].Pos().Line
+				}
+				f.funcs = append(f.funcs, coverFunc{name: s.TypeName + "." + m.Name, line: m.Pos().Line, end: methodEnd})
+			}
+		}
+	}
+	return f, nil
+}
+
+// WriteFuncs writes the percentage of the statements of each function that
+// ran, as go tool cover -func does, and of all the statements last. When
+// there are branches, the share of them taken follows, and each that never
+// was, where it is.
+func (r *CoverReport) WriteFuncs(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, ' ', 0)
+	for _, f := range r.files {
+		for _, fn := range f.funcs {
+			var in []coverage.Block
+			for _, b := range f.blocks {
+				if b.StartLine >= fn.line && b.StartLine < fn.end {
+					in = append(in, b)
+				}
+			}
+			fmt.Fprintf(tw, "%s:%d:\t%s\t%.1f%%\n", f.name, fn.line, fn.name, coverage.Percent(in))
+		}
+	}
+	fmt.Fprintf(tw, "total:\t(statements)\t%.1f%%\n", coverage.Percent(r.Profile.Blocks))
+	if len(r.Profile.Branches) > 0 {
+		fmt.Fprintf(tw, "total:\t(branches)\t%.1f%%\n", coverage.BranchPercent(r.Profile.Branches))
+		for _, b := range r.Profile.Branches {
+			if b.Count == 0 {
+				fmt.Fprintf(tw, "%s:%d:%d:\t%s\tnever taken\n", b.FileName, b.Line, b.Col, b.Label)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteHTML writes the covered files as a page, each line marked by whether
+// the statements that start on it ran, and under a counting mode with how
+// many times, and beside it the branches on it that were never taken.
+func (r *CoverReport) WriteHTML(w io.Writer) error {
+	page := coverPage{Percent: coverage.Percent(r.Profile.Blocks), Mode: r.Profile.Mode}
+	if len(r.Profile.Branches) > 0 {
+		page.Branches = fmt.Sprintf("%.1f%% of %d", coverage.BranchPercent(r.Profile.Branches), len(r.Profile.Branches))
+	}
+	for _, f := range r.files {
+		page.Files = append(page.Files, f.html(r.Profile.Mode != "set"))
+	}
+	return coverTemplate.Execute(w, page)
+}
+
+type coverPage struct {
+	Percent float64
+	Mode    string
+	// Branches is the share of the branches taken, and of how many, and ""
+	// when there are none.
+	Branches string
+	Files    []coverPageFile
+}
+
+type coverPageFile struct {
+	Name    string
+	Percent float64
+	Lines   []coverPageLine
+}
+
+type coverPageLine struct {
+	Number int
+	Text   string
+	// Class is "covered" when every statement that starts on the line ran,
+	// "uncovered" when none did, "partial" when some did, and "" when none
+	// starts there.
+	Class string
+	Count string
+	// Untaken names the branches on the line no test took.
+	Untaken string
+}
+
+func (f *coverFile) html(counting bool) coverPageFile {
+	pf := coverPageFile{Name: f.name, Percent: coverage.Percent(f.blocks)}
+	pf.Lines = make([]coverPageLine, len(f.lines))
+	ran := make([]int, len(f.lines))
+	missed := make([]int, len(f.lines))
+	most := make([]int64, len(f.lines))
+	for _, b := range f.blocks {
+		i := b.StartLine - 1
+		if i < 0 || i >= len(f.lines) {
+			continue
+		}
+		if b.Count > 0 {
+			ran[i]++
+		} else {
+			missed[i]++
+		}
+		most[i] = max(most[i], b.Count)
+	}
+	untaken := make([][]string, len(f.lines))
+	for _, b := range f.branches {
+		i := b.Line - 1
+		if b.Count == 0 && i >= 0 && i < len(f.lines) {
+			untaken[i] = append(untaken[i], b.Label)
+		}
+	}
+	for i, text := range f.lines {
+		line := coverPageLine{Number: i + 1, Text: text}
+		switch {
+		case ran[i] > 0 && missed[i] > 0:
+			line.Class = "partial"
+		case ran[i] > 0:
+			line.Class = "covered"
+		case missed[i] > 0:
+			line.Class = "uncovered"
+		}
+		if counting && line.Class != "" {
+			line.Count = strconv.FormatInt(most[i], 10) + "×"
+		}
+		if len(untaken[i]) > 0 {
+			line.Untaken = strings.Join(untaken[i], ", ") + " never taken"
+		}
+		pf.Lines[i] = line
+	}
+	return pf
+}
+
+var coverTemplate = template.Must(template.New("cover").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table.summary { border-collapse: collapse; margin-bottom: 2em; }
+table.summary th, table.summary td { padding: 0.3em 1em; text-align: right; border-bottom: 1px solid #ddd; }
+table.summary th:first-child, table.summary td:first-child { text-align: left; }
+table.source { border-collapse: collapse; font-family: monospace; width: 100%; }
+table.source td { padding: 0 0.5em; white-space: pre; }
+td.number { color: #888; text-align: right; user-select: none; width: 3em; }
+td.count { color: #555; text-align: right; width: 5em; font-size: 0.85em; }
+tr.covered td.code { background: #dafbe1; }
+tr.uncovered td.code { background: #ffebe9; }
+tr.partial td.code { background: #fff8c5; }
+td.untaken { color: #bc4c00; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>Coverage report</h1>
+<p>Mode: {{.Mode}}. Coverage: <strong>{{printf "%.1f" .Percent}}%</strong> of statements{{with .Branches}}; branches: <strong>{{.}}</strong> taken{{end}}.</p>
+<table class="summary">
+<tr><th>File</th><th>Coverage</th></tr>
+{{- range $i, $f := .Files}}
+<tr><td><a href="#file-{{$i}}">{{$f.Name}}</a></td><td>{{printf "%.1f" $f.Percent}}%</td></tr>
+{{- end}}
+</table>
+{{- range $i, $f := .Files}}
+<h2 id="file-{{$i}}">{{$f.Name}} {{printf "%.1f" $f.Percent}}%</h2>
+<table class="source">
+{{- range $f.Lines}}
+<tr id="file-{{$i}}-L{{.Number}}" class="{{.Class}}"><td class="number">{{.Number}}</td><td class="count">{{.Count}}</td><td class="code">{{.Text}}</td><td class="untaken">{{.Untaken}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,201 @@
+package compiler_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+func TestCoverageCountsEachFileAtItsOwnLines(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "calc.nyan", `meow add(a int, b int) int {
+  bring a + b
+}
+
+meow big(n int) bool {
+  sniff (n > 10) {
+    bring yarn
+  }
+  bring hairball
+}
+`)
+	test := writeProgram(t, dir, "calc_test.nyan", `meow test_calc() {
+  expect(add(1, 2), 3)
+  expect(add(2, 2), 4)
+  expect(big(20), yarn)
+}
+`)
+	profile := filepath.Join(dir, "cover.out")
+	if err := os.WriteFile(profile, []byte("mode: count\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := compiler.New(nil)
+	c.EnableCoverage(profile)
+	if err := c.SetCoverMode("count"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RunTest(test); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := compiler.ReadCoverReport([]string{profile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var funcs strings.Builder
+	if err := report.WriteFuncs(&funcs); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"calc.nyan:1:", "add", "100.0%",
+		"calc.nyan:5:", "big", "66.7%",
+		"calc_test.nyan:1:", "test_calc",
+		"total:", "(statements)", "85.7%",
+	} {
+		if !strings.Contains(funcs.String(), want) {
+			t.Errorf("-func output has no %q:\n%s", want, funcs.String())
+		}
+	}
+
+	var page strings.Builder
+	if err := report.WriteHTML(&page); err != nil {
+		t.Fatal(err)
+	}
+	// add ran twice, and the bring at the end of big not at all.
+	for _, want := range []string{
+		`class="covered"><td class="number">2</td><td class="count">2×</td><td class="code">  bring a &#43; b</td>`,
+		`class="uncovered"><td class="number">9</td><td class="count">0×</td>`,
+	} {
+		if !strings.Contains(page.String(), want) {
+			t.Errorf("page has no %s:\n%s", want, page.String())
+		}
+	}
+}
+
+func TestCoverageListsTheBranchesNoTestTook(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "safe.nyan", `meow risky(x int) int {
+  sniff (x < 0) {
+    hiss("negative")
+  }
+  bring x
+}
+
+meow safe(x int) int {
+  bring risky(x) ~> 0
+}
+
+meow both(a bool, b bool) bool {
+  bring a && b
+}
+
+meow show(x int) {
+  nyan v = safe(x)
+  nya(yarn || v > 0)
+}
+`)
+	test := writeProgram(t, dir, "safe_test.nyan", `meow test_safe() {
+  expect(safe(5), 5)
+  expect(both(hairball, yarn), hairball)
+  show(3)
+}
+`)
+	c := compiler.New(nil)
+	c.EnableCoverage(filepath.Join(dir, "cover.out"))
+	var result compiler.TestFileResult
+	c.RunTestsParallel(context.Background(), []string{test}, 1, func(r compiler.TestFileResult) bool {
+		result = r
+		return true
+	})
+	if result.Err != nil {
+		t.Fatalf("%v\n%s", result.Err, result.Output)
+	}
+	out := string(result.Output)
+	for _, want := range []string{
+		"branches: 50.0% of 8 taken, nya~",
+		"safe.nyan:2:3: sniff body never taken",
+		"safe.nyan:9:18: ~> fallback never taken",
+		"safe.nyan:13:11: && right side never taken",
+		"safe.nyan:18:12: || right side never taken",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCoverReportListsTheBranchesNoTestTook(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "pick.nyan", `meow pick(x int) int {
+  sniff (x < 0) {
+    bring 0
+  }
+  bring x
+}
+`)
+	test := writeProgram(t, dir, "pick_test.nyan", `meow test_pick() {
+  nyan v = pick(3)
+  sniff (v > 1) {
+    expect(v, 3)
+  }
+}
+`)
+	profile := filepath.Join(dir, "cover.out")
+	if err := os.WriteFile(profile, []byte("mode: set\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := compiler.New(nil)
+	c.EnableCoverage(profile)
+	if err := c.RunTest(test); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := compiler.ReadCoverReport([]string{profile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var funcs strings.Builder
+	if err := report.WriteFuncs(&funcs); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(branches)", "50.0%", "pick.nyan:2:3:", "sniff body", "never taken"} {
+		if !strings.Contains(funcs.String(), want) {
+			t.Errorf("-func output has no %q:\n%s", want, funcs.String())
+		}
+	}
+	// The sniff of the test itself is not a branch of the code it tests.
+	if strings.Contains(funcs.String(), "pick_test.nyan:3:3:") {
+		t.Errorf("-func output lists a branch of the test file:\n%s", funcs.String())
+	}
+
+	var page strings.Builder
+	if err := report.WriteHTML(&page); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<td class="untaken">sniff body never taken</td>`; !strings.Contains(page.String(), want) {
+		t.Errorf("page has no %s:\n%s", want, page.String())
+	}
+}
+
+func TestCoverReportWantsTheFilesItCovers(t *testing.T) {
+	profile := writeProgram(t, t.TempDir(), "cover.out", "mode: set\ngone.nyan:1.1,1.2 1 1\n")
+	if _, err := compiler.ReadCoverReport([]string{profile}); err == nil || !strings.Contains(err.Error(), "gone.nyan") {
+		t.Errorf("ReadCoverReport = %v, want it to say gone.nyan is not there", err)
+	}
+}
+
+func TestSetCoverModeChecksTheMode(t *testing.T) {
+	c := compiler.New(nil)
+	for _, mode := range compiler.CoverModes {
+		if err := c.SetCoverMode(mode); err != nil {
+			t.Errorf("SetCoverMode(%q) = %v", mode, err)
+		}
+	}
+	if err := c.SetCoverMode("sometimes"); err == nil {
+		t.Error("SetCoverMode took a mode there is none of")
+	}
+}
@@ -0,0 +1,170 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestEvent is one thing that happened while a test file ran, in the shape
+// `go test -json` gives its events, so that whatever reads those reads these.
+// Package is the .nyan file the tests are in; Test, when set, is the test_,
+// catwalk_ or fuzz_ function the event is about. Elapsed is in seconds, and
+// only pass and fail events have it.
+type TestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string   `json:",omitempty"`
+	Test    string   `json:",omitempty"`
+	Elapsed *float64 `json:",omitempty"`
+	Output  string   `json:",omitempty"`
+}
+
+// SetTestEvents has RunTest, RunTestContext and RunFuzz report what happens as
+// events to fn, rather than writing it to stdout: each file's start, each
+// test's run and result, what was printed and by which test, and how the file
+// went as a whole. A file that does not build is a fail event, with what was
+// wrong as its output. nil goes back to text.
+func (c *Compiler) SetTestEvents(fn func(TestEvent)) {
+	c.testEvents = fn
+}
+
+// eventMarker starts the lines of a test binary's output that are events; it
+// is what runtime/testing writes ahead of them.
+const eventMarker = "\x1emeow-event "
+
+// eventStream reads a test run's output as events. It is written to as the
+// run's stdout and stderr both, and passes on an event for each line: a line
+// that decode takes as an event is that event, and any other line is output,
+// credited to the test running at the time. An event decode gives back with
+// no Action is one it took, to be dropped.
+type eventStream struct {
+	pkg     string
+	emit    func(TestEvent)
+	decode  func(line []byte) (TestEvent, bool)
+	current string
+	pending []byte
+}
+
+func (c *Compiler) newEventStream(pkg string, decode func([]byte) (TestEvent, bool)) *eventStream {
+	return &eventStream{pkg: pkg, emit: c.testEvents, decode: decode}
+}
+
+func (s *eventStream) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+	for {
+		i := bytes.IndexByte(s.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		s.line(s.pending[:i+1])
+		s.pending = s.pending[i+1:]
+	}
+}
+
+// flush passes on the last line of the run, when it has no newline to end it.
+func (s *eventStream) flush() {
+	if len(s.pending) > 0 {
+		s.line(s.pending)
+		s.pending = nil
+	}
+}
+
+func (s *eventStream) line(line []byte) {
+	e, ok := s.decode(line)
+	if !ok {
+		e = TestEvent{Action: "output", Test: s.current, Output: string(line)}
+	}
+	if e.Action == "" {
+		return
+	}
+	switch e.Action {
+	case "run":
+		s.current = e.Test
+	case "pass", "fail", "skip":
+		// The output after a subtest is the enclosing test's again.
+		if e.Test == s.current {
+			s.current = parentTest(e.Test)
+		}
+	}
+	s.send(e)
+}
+
+func (s *eventStream) send(e TestEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Package = s.pkg
+	s.emit(e)
+}
+
+// start and done bracket the run of a file with the events for the file as a
+// whole. err is how the run went; one the tests did not report themselves —
+// a file that did not compile — is sent as output first, as nothing else
+// would say what it was.
+func (s *eventStream) start() {
+	s.send(TestEvent{Action: "start"})
+}
+
+func (s *eventStream) done(began time.Time, err error) {
+	s.flush()
+	action := "pass"
+	if err != nil {
+		action = "fail"
+		var ran *TestsFailed
+		if !errors.As(err, &ran) {
+			s.send(TestEvent{Action: "output", Output: strings.TrimRight(err.Error(), "\n") + "\n"})
+		}
+	}
+	elapsed := time.Since(began).Seconds()
+	s.send(TestEvent{Action: action, Elapsed: &elapsed})
+}
+
+// decodeMeowEvent reads a line a test binary wrote with eventMarker.
+func decodeMeowEvent(line []byte) (TestEvent, bool) {
+	rest, ok := bytes.CutPrefix(line, []byte(eventMarker))
+	if !ok {
+		return TestEvent{}, false
+	}
+	var e TestEvent
+	if err := json.Unmarshal(rest, &e); err != nil {
+		return TestEvent{}, false
+	}
+	return e, true
+}
+
+// decodeFuzzEvent reads a line of `go test -json` run over the Go a fuzz file
+// compiles to. The Go names of the fuzz targets are given back their Meow
+// ones; the events for the Go package as a whole are dropped, as each fuzz
+// target is a go test of its own and the file is one package all the same.
+func decodeFuzzEvent(line []byte) (TestEvent, bool) {
+	var e TestEvent
+	if err := json.Unmarshal(line, &e); err != nil || e.Action == "" {
+		return TestEvent{}, false
+	}
+	if e.Test == "" && (e.Action == "start" || e.Action == "pass" || e.Action == "fail" || e.Action == "skip") {
+		return TestEvent{}, true
+	}
+	name, sub, _ := strings.Cut(e.Test, "/")
+	if goName, ok := strings.CutPrefix(name, "Fuzz"); ok {
+		r, size := utf8.DecodeRuneInString(goName)
+		e.Test = "fuzz_" + string(unicode.ToLower(r)) + goName[size:]
+		if sub != "" {
+			e.Test += "/" + sub
+		}
+	}
+	return e, true
+}
+
+// parentTest is the test a subtest is part of, or "" for a test of the file's
+// own.
+func parentTest(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
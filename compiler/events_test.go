@@ -0,0 +1,158 @@
+package compiler_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+func TestATestRunCanBeHadAsEvents(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "cat_test.nyan", `meow test_loud() {
+  nya("nya!")
+  expect(1 + 1, 2)
+}
+
+meow test_wrong() {
+  expect(1 + 1, 3)
+}
+`)
+	writeProgram(t, dir, "broken_test.nyan", "meow test_x() {\n  expect(1, nope)\n}\n")
+
+	var events []compiler.TestEvent
+	c := compiler.New(nil)
+	c.SetTestEvents(func(e compiler.TestEvent) { events = append(events, e) })
+	cat := filepath.Join(dir, "cat_test.nyan")
+	if err := c.RunTest(cat); err == nil {
+		t.Fatal("RunTest passed, want test_wrong failed")
+	}
+
+	var got []string
+	for _, e := range events {
+		if e.Package != cat || e.Time.IsZero() {
+			t.Errorf("event %+v is not of %s, or has no time", e, cat)
+		}
+		if e.Action == "output" && e.Test == "" {
+			continue
+		}
+		got = append(got, strings.TrimSpace(e.Action+" "+e.Test+" "+strings.TrimSpace(e.Output)))
+		if (e.Action == "pass" || e.Action == "fail") && e.Elapsed == nil {
+			t.Errorf("%s event %+v has no elapsed time", e.Action, e)
+		}
+	}
+	want := []string{
+		"start",
+		"run test_loud", "output test_loud nya!", "output test_loud PASS: test_loud", "pass test_loud",
+		"run test_wrong", "output test_wrong FAIL: test_wrong - expected 3, got 2", "fail test_wrong",
+		"fail",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("events\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	events = nil
+	broken := filepath.Join(dir, "broken_test.nyan")
+	if err := c.RunTest(broken); err == nil {
+		t.Fatal("RunTest passed, want the file refused")
+	}
+	if n := len(events); n != 3 || events[1].Action != "output" || !strings.Contains(events[1].Output, "nope") || events[2].Action != "fail" {
+		t.Errorf("events = %+v, want start, the error as output, and fail", events)
+	}
+}
+
+func TestAJUnitReportHasACaseForEachTest(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	sec := func(s float64) *float64 { return &s }
+	r := compiler.NewJUnitReport()
+	for _, e := range []compiler.TestEvent{
+		{Time: at, Action: "start", Package: "cat_test.nyan"},
+		{Action: "run", Package: "cat_test.nyan", Test: "test_ok"},
+		{Action: "output", Package: "cat_test.nyan", Test: "test_ok", Output: "  PASS: test_ok\n"},
+		{Action: "pass", Package: "cat_test.nyan", Test: "test_ok", Elapsed: sec(0.25)},
+		{Action: "run", Package: "cat_test.nyan", Test: "test_wrong"},
+		{Action: "output", Package: "cat_test.nyan", Test: "test_wrong", Output: "  FAIL: test_wrong (0.00s) - expected 3, got 2\n"},
+		{Action: "fail", Package: "cat_test.nyan", Test: "test_wrong", Elapsed: sec(0)},
+		{Action: "fail", Package: "cat_test.nyan", Elapsed: sec(1.5)},
+		{Time: at, Action: "start", Package: "broken_test.nyan"},
+		{Action: "output", Package: "broken_test.nyan", Output: "Hiss! undefined variable nope, nya~\n"},
+		{Action: "fail", Package: "broken_test.nyan", Elapsed: sec(0)},
+	} {
+		r.Add(e)
+	}
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	xml := buf.String()
+	for _, want := range []string{
+		`<testsuites tests="3" failures="1" errors="1" time="1.500">`,
+		`<testsuite name="cat_test.nyan" tests="2" failures="1" errors="0" time="1.500" timestamp="2026-10-19T12:00:00Z">`,
+		`<testcase name="test_ok" classname="cat_test.nyan" time="0.250">`,
+		`<failure message="expected 3, got 2">`,
+		`<testcase name="broken_test.nyan" classname="broken_test.nyan" time="0.000">`,
+		`<error message="the tests did not run to the end">Hiss! undefined variable nope, nya~`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("report has no %s:\n%s", want, xml)
+		}
+	}
+}
+
+func TestOutputAfterASubtestIsTheEnclosingTests(t *testing.T) {
+	dir := t.TempDir()
+	path := writeProgram(t, dir, "sub_test.nyan", `nab "testing"
+
+meow test_outer() {
+  testing.run("inner", paw() {
+    nya("in")
+  })
+  nya("after")
+}
+`)
+	credited := make(map[string]string)
+	c := compiler.New(nil)
+	c.SetTestEvents(func(e compiler.TestEvent) {
+		if e.Action == "output" {
+			credited[strings.TrimSpace(e.Output)] = e.Test
+		}
+	})
+	if err := c.RunTest(path); err != nil {
+		t.Fatal(err)
+	}
+	if credited["in"] != "test_outer/inner" || credited["after"] != "test_outer" {
+		t.Errorf("output credited as %v", credited)
+	}
+}
+
+func TestARunAtTheTopLevelIsTestedAsEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := writeProgram(t, dir, "top_test.nyan", `nab "testing"
+
+testing.run("top_level_add", paw() {
+  expect(1 + 1, 2)
+})
+
+meow test_ok() {
+  judge(yarn)
+}
+`)
+	var actions []string
+	c := compiler.New(nil)
+	c.SetTestEvents(func(e compiler.TestEvent) {
+		if e.Test != "" && e.Action != "output" {
+			actions = append(actions, e.Action+" "+e.Test)
+		}
+	})
+	if err := c.RunTest(path); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"run top_level_add", "pass top_level_add", "run test_ok", "pass test_ok"}
+	if !slices.Equal(actions, want) {
+		t.Errorf("events = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,127 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+// fuzzSeedDir is where, beside a fuzz file, the inputs that failed its fuzz_
+// functions are kept: a directory for each function, and in it a .nyan file
+// for each input, holding the seed() call that hands the function it again.
+// The seeds there are added to the function's own on every run, so that an
+// input that once failed is tried first from then on.
+const fuzzSeedDir = "testdata/fuzz"
+
+// fuzzTarget is a fuzz_ function, and the name go test knows it by.
+type fuzzTarget struct {
+	goName   string
+	funcName string
+}
+
+// fuzzTargets pairs the fuzz_ functions of prog with goNames, the names
+// GenerateFuzz gave them, in the same order.
+func fuzzTargets(prog *ast.Program, goNames []string) []fuzzTarget {
+	var targets []fuzzTarget
+	for _, stmt := range prog.Stmts {
+		fn, ok := stmt.(*ast.FuncStmt)
+		if !ok || !strings.HasPrefix(fn.Name, "fuzz_") || len(targets) == len(goNames) {
+			continue
+		}
+		targets = append(targets, fuzzTarget{goName: goNames[len(targets)], funcName: fn.Name})
+	}
+	return targets
+}
+
+// addSavedSeeds adds the seed() calls saved under dir for each fuzz_
+// function of prog to the function's body, after its own.
+func addSavedSeeds(prog *ast.Program, dir string) error {
+	for _, stmt := range prog.Stmts {
+		fn, ok := stmt.(*ast.FuncStmt)
+		if !ok || !strings.HasPrefix(fn.Name, "fuzz_") {
+			continue
+		}
+		paths, _ := filepath.Glob(filepath.Join(dir, fn.Name, "*.nyan"))
+		for _, path := range paths {
+			seeds, err := readSeedFile(path)
+			if err != nil {
+				return err
+			}
+			fn.Body = append(fn.Body, seeds...)
+		}
+	}
+	return nil
+}
+
+// readSeedFile reads the seed() calls of a saved seed file.
+func readSeedFile(path string) ([]ast.Stmt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	prog, errs := parser.New(lexer.New(string(data), filepath.ToSlash(path)).Tokens()).Parse()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Hiss! %s is not a seed file, nya~: %v", path, errs[0])
+	}
+	for _, stmt := range prog.Stmts {
+		es, ok := stmt.(*ast.ExprStmt)
+		var call *ast.CallExpr
+		if ok {
+			call, ok = es.Expr.(*ast.CallExpr)
+		}
+		if ok {
+			ident, isIdent := call.Fn.(*ast.Ident)
+			ok = isIdent && ident.Name == "seed"
+		}
+		if !ok {
+			return nil, fmt.Errorf("Hiss! A seed file holds only seed() calls, not what is at %s, nya~", stmt.Pos())
+		}
+	}
+	return prog.Stmts, nil
+}
+
+// saveFailingInputs keeps the inputs go test wrote to the corpus of target in
+// buildDir, having failed it, as seed files under seedDir. Go writes them
+// in a form of its own, which for a function handed bytes says nothing of
+// the litter or kitty they stood for; so each is run once more by itself,
+// with MEOW_FUZZ_SEED telling the failing function where to write the
+// seed() call that hands it the same values. What is saved is said to w.
+func (c *Compiler) saveFailingInputs(buildDir string, target fuzzTarget, seedDir string, w io.Writer) {
+	entries, err := os.ReadDir(filepath.Join(buildDir, "testdata", "fuzz", target.goName))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(seedDir, target.funcName)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Fprintf(w, "Hiss! Cannot save the failing input, nya~: %v\n", err)
+			return
+		}
+		path := filepath.Join(dir, e.Name()+".nyan")
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			fmt.Fprintf(w, "Hiss! Cannot save the failing input, nya~: %v\n", err)
+			return
+		}
+		run := fmt.Sprintf("^%s$/^%s$", regexp.QuoteMeta(target.goName), regexp.QuoteMeta(e.Name()))
+		cmd := c.goCmd(buildDir, "test", "-run="+run, ".")
+		cmd.Env = append(cmd.Env, "MEOW_FUZZ_SEED="+abs)
+		cmd.Stdout, cmd.Stderr = io.Discard, io.Discard
+		cmd.Run() // It fails, as the input does.
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(w, "Hiss! The failing input of %s cannot be written as a seed, nya~\n", target.funcName)
+			continue
+		}
+		fmt.Fprintf(w, "  failing input saved as %s, nya~\n", path)
+	}
+}
@@ -0,0 +1,105 @@
+package compiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+const orderFuzz = `kitty Order {
+  id: string
+  qty: int
+  tags: litter[string]
+}
+
+meow fuzz_orders(o Order, sizes basket[int]) {
+  seed(Order("a", 1, ["x"]), {"s": 1})
+  judge(o.qty >= 0 || len(sizes) >= 0)
+}
+`
+
+func TestFuzzDecodesALitterBasketOrKittyOutOfBytes(t *testing.T) {
+	_, tests, names, err := compiler.New(nil).CompileFuzzToGo(orderFuzz, "orders.nyan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("fuzz targets = %v, want one", names)
+	}
+	for _, want := range []string{
+		`meow_testing.FuzzKitty("Order"`,
+		`meow_testing.FuzzList(meow_testing.FuzzString)`,
+		`meow_testing.FuzzBasket(meow_testing.FuzzInt)`,
+		`__data []byte`,
+		`meow_testing.DecodeFuzz(__data, __shapes...)`,
+	} {
+		if !strings.Contains(tests, want) {
+			t.Errorf("fuzz test does not contain %s:\n%s", want, tests)
+		}
+	}
+}
+
+func TestFuzzRefusesAParameterItCannotMakeFromBytes(t *testing.T) {
+	_, _, _, err := compiler.New(nil).CompileFuzzToGo(`meow fuzz_f(g furball) {
+  judge(yarn)
+}
+`, "f.nyan")
+	if err == nil || !strings.Contains(err.Error(), "cannot be fuzzed") {
+		t.Errorf("err = %v, want it says g cannot be fuzzed", err)
+	}
+}
+
+func TestFuzzSavesAFailingInputAsASeedAndReplaysIt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("fuzzes for a while")
+	}
+	dir := t.TempDir()
+	path := writeProgram(t, dir, "lists.nyan", `meow fuzz_lists(xs litter[int]) {
+  seed([1])
+  judge(len(xs) > 0)
+}
+`)
+	c := compiler.New(nil)
+	if err := c.RunFuzz(path, "30s"); err == nil {
+		t.Fatal("fuzz passed, want it found the empty litter")
+	}
+	seeds, _ := filepath.Glob(filepath.Join(dir, "testdata", "fuzz", "fuzz_lists", "*.nyan"))
+	if len(seeds) != 1 {
+		t.Fatalf("saved seeds = %v, want one", seeds)
+	}
+	data, err := os.ReadFile(seeds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "seed([])\n" {
+		t.Errorf("saved seed = %q, want seed([])", got)
+	}
+
+	// The saved seed fails again before any fuzzing, and is not saved twice.
+	if err := c.RunFuzz(path, "2s"); err == nil {
+		t.Error("fuzz passed, want the saved seed to fail it again")
+	}
+	if again, _ := filepath.Glob(filepath.Join(dir, "testdata", "fuzz", "fuzz_lists", "*.nyan")); len(again) != 1 {
+		t.Errorf("saved seeds = %v, want still one", again)
+	}
+}
+
+func TestASeedFileHoldsOnlySeedCalls(t *testing.T) {
+	dir := t.TempDir()
+	path := writeProgram(t, dir, "small.nyan", `meow fuzz_small(n int) {
+  judge(n >= 0 || n < 0)
+}
+`)
+	seedDir := filepath.Join(dir, "testdata", "fuzz", "fuzz_small")
+	if err := os.MkdirAll(seedDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeProgram(t, seedDir, "bad.nyan", "nyan n = 1\n")
+	err := compiler.New(nil).RunFuzz(path, "2s")
+	if err == nil || !strings.Contains(err.Error(), "holds only seed() calls") {
+		t.Errorf("err = %v, want it says a seed file holds only seed() calls", err)
+	}
+}
@@ -0,0 +1,16 @@
+package compiler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAGoFlagJoinsTheUsersOwn(t *testing.T) {
+	env := withGoFlag([]string{"HOME=/home/cat", "GOFLAGS=-mod=mod -tags=purr"}, "-mod=vendor")
+	if got := env[len(env)-1]; got != "GOFLAGS=-tags=purr -mod=vendor" {
+		t.Errorf("GOFLAGS = %q, want the user's tags kept and -mod replaced", got)
+	}
+	if !slices.Contains(env, "HOME=/home/cat") {
+		t.Errorf("env = %v, want the rest of it kept", env)
+	}
+}
@@ -0,0 +1,244 @@
+package compiler
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/pkg/codegen"
+)
+
+// What a `nab go` package exports is only known to the package itself. It is
+// read the way the Go toolchain reads it — see checker.LoadGoPackages — once
+// per compile, and used twice: by the checker, to type the calls made into
+// the package, and here, where a groom naming a Go interface needs the
+// interface's method set written out in the generated adapter.
+//
+// Reading it means resolving a module, which is not free, and a Compiler
+// compiles many programs in a run — every test file of `meow test ./...` —
+// that mostly fetch the same packages. So what was read is kept by what
+// decided it, and read again only when one of those changes.
+
+// goAPIRead is what readGoAPI came to for a set of packages.
+type goAPIRead struct {
+	pkgs map[string]*types.Package
+	err  error
+}
+
+// resolveGoInterfaces reads the Go interfaces the program's grooms name,
+// leaving them where the generator is handed them.
+func (c *Compiler) resolveGoInterfaces(prog *ast.Program) error {
+	c.goInterfaces = nil
+	var grooms []*ast.LearnStmt
+	goPaths := make(map[string]string) // name → import path
+	for _, stmt := range prog.Stmts {
+		switch s := stmt.(type) {
+		case *ast.LearnStmt:
+			if len(s.As) > 0 {
+				grooms = append(grooms, s)
+			}
+		case *ast.FetchStmt:
+			if s.Go {
+				goPaths[s.Name()] = s.Path
+			}
+		}
+	}
+	if len(grooms) == 0 {
+		return nil
+	}
+
+	// A groom cannot be written without the interface, so a package the
+	// checker went without is an error here.
+	if c.goAPIErr != nil {
+		return c.goAPIErr
+	}
+	pkgs := c.goPackages
+
+	for _, ls := range grooms {
+		for _, ref := range ls.As {
+			iface, err := goInterface(ls, ref, pkgs[goPaths[ref.Package]], goPaths)
+			if err != nil {
+				return fmt.Errorf("Hiss! %s at %s, nya~", err, ref.Token.Pos)
+			}
+			c.goInterfaces = append(c.goInterfaces, iface)
+		}
+	}
+	return nil
+}
+
+// readGoAPI reads the exported API of the program's `nab go` packages into
+// goPackages, for the checker to type calls into them with and for a groom to
+// read the Go interface it names out of, or says why it could not in
+// goAPIErr.
+//
+// cached keeps it to the modules the module cache already holds, with no
+// lookups over the network for what the latest version is.
+func (c *Compiler) readGoAPI(prog *ast.Program, cached bool) {
+	c.goPackages, c.goAPIErr = nil, nil
+	var paths []string
+	for _, stmt := range prog.Stmts {
+		if fs, ok := stmt.(*ast.FetchStmt); ok && fs.Go {
+			paths = append(paths, fs.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+	key := c.goAPIKey(paths, cached)
+	read, ok := c.goAPIs[key]
+	if !ok {
+		c.logger.Debug("reading Go packages", "packages", paths, "cached", cached)
+		c.fromModuleCache = cached
+		read.pkgs, read.err = checker.LoadGoPackages(paths, goVersionFor(c.findModuleRoot()), c.prepareModule, c.goEnv())
+		c.fromModuleCache = false
+		if c.goAPIs == nil {
+			c.goAPIs = make(map[string]goAPIRead)
+		}
+		c.goAPIs[key] = read
+	}
+	c.goPackages, c.goAPIErr = read.pkgs, read.err
+}
+
+// goAPIKey is what decides what readGoAPI reads for paths: the packages, the
+// go directive and module root they are resolved under, and what holds them
+// to a version.
+func (c *Compiler) goAPIKey(paths []string, cached bool) string {
+	var b strings.Builder
+	modRoot := c.findModuleRoot()
+	fmt.Fprintf(&b, "%s\x00%s\x00%t\x00%t\x00%s\x00", modRoot, goVersionFor(modRoot), cached, c.offline, c.vendorDir())
+	for _, p := range paths {
+		fmt.Fprintf(&b, "import %s\x00", p)
+	}
+	for _, p := range sortedKeys(c.goPins) {
+		fmt.Fprintf(&b, "pin %s@%s\x00", p, c.goPins[p])
+	}
+	if c.lock != nil {
+		b.WriteString(c.lock.goMod())
+		b.WriteString(c.lock.goSum())
+	}
+	return b.String()
+}
+
+// newChecker makes the checker for a program, told what its `nab go`
+// packages export.
+//
+// They are read from the module cache, and resolved as the build would
+// resolve them only when the cache does not hold them yet, which puts them
+// there for the next compile. A package that cannot be read either way — a
+// machine with no network and an empty module cache — leaves its calls to be
+// checked by the bridge when they are made, as they were before there was
+// anything else, and the user is told they are.
+func (c *Compiler) newChecker(prog *ast.Program) *checker.Checker {
+	ch := checker.New()
+	c.readGoAPI(prog, true)
+	if c.goAPIErr != nil {
+		c.readGoAPI(prog, false)
+	}
+	if c.goAPIErr != nil {
+		c.logger.Warn("Go packages could not be read, so calls into them are not checked, nya~", "err", c.goAPIErr)
+		return ch
+	}
+	ch.SetGoPackages(c.goPackages)
+	return ch
+}
+
+// goInterface reads the interface ref names out of pkg, and matches each of
+// its methods to the groom's.
+//
+// A Go method is answered by the groom method spelled the same once case and
+// underscores are set aside, so Write is write and ServeHTTP is serve_http —
+// the same names a Meow program calls a Go value's methods by.
+func goInterface(ls *ast.LearnStmt, ref ast.GoTypeRef, pkg *types.Package, goPaths map[string]string) (codegen.GoInterface, error) {
+	obj, ok := pkg.Scope().Lookup(ref.Name).(*types.TypeName)
+	if !ok || !obj.Exported() {
+		return codegen.GoInterface{}, fmt.Errorf("%s is not a type %s exports", ref, ref.Package)
+	}
+	it, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return codegen.GoInterface{}, fmt.Errorf("%s is not an interface", ref)
+	}
+	if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return codegen.GoInterface{}, fmt.Errorf("%s is generic, and a kitty cannot be groomed as a generic interface", ref)
+	}
+	if !it.IsMethodSet() {
+		return codegen.GoInterface{}, fmt.Errorf("%s is a constraint rather than an interface a value can satisfy", ref)
+	}
+
+	iface := codegen.GoInterface{
+		Kitty:   ls.TypeName,
+		Package: ref.Package,
+		Name:    ref.Name,
+		Imports: make(map[string]string),
+	}
+	aliases := make(map[string]string) // import path → alias
+	for name, path := range goPaths {
+		aliases[path] = "go_" + name
+	}
+	qualify := func(p *types.Package) string {
+		alias, ok := aliases[p.Path()]
+		if !ok {
+			alias = "goiface_" + p.Name()
+			for n := 2; takenAlias(aliases, alias); n++ {
+				alias = fmt.Sprintf("goiface_%s%d", p.Name(), n)
+			}
+			aliases[p.Path()] = alias
+		}
+		iface.Imports[alias] = p.Path()
+		return alias
+	}
+	iface.Type = types.TypeString(obj.Type(), qualify)
+
+	for i := range it.NumMethods() {
+		m := it.Method(i)
+		if !m.Exported() {
+			return codegen.GoInterface{}, fmt.Errorf("%s has the unexported method %s, which only its own package can write", ref, m.Name())
+		}
+		sig := m.Type().(*types.Signature)
+		method, ok := groomMethodFor(ls, m.Name())
+		if !ok {
+			return codegen.GoInterface{}, fmt.Errorf("%s is groomed as %s but has no method answering %s", ls.TypeName, ref, m.Name())
+		}
+		if len(method.Params) != sig.Params().Len() {
+			return codegen.GoInterface{}, fmt.Errorf("%s takes %d arguments, but %s.%s takes %d",
+				method.Name, len(method.Params), ref, m.Name(), sig.Params().Len())
+		}
+		gm := codegen.GoMethod{Name: m.Name(), Method: method.Name, Variadic: sig.Variadic()}
+		for j := range sig.Params().Len() {
+			t := sig.Params().At(j).Type()
+			if gm.Variadic && j == sig.Params().Len()-1 {
+				t = t.(*types.Slice).Elem()
+			}
+			gm.Params = append(gm.Params, types.TypeString(t, qualify))
+		}
+		for j := range sig.Results().Len() {
+			gm.Results = append(gm.Results, types.TypeString(sig.Results().At(j).Type(), qualify))
+		}
+		iface.Methods = append(iface.Methods, gm)
+	}
+	return iface, nil
+}
+
+// groomMethodFor finds the groom method that answers the Go method goName.
+func groomMethodFor(ls *ast.LearnStmt, goName string) (*ast.FuncStmt, bool) {
+	for i := range ls.Methods {
+		m := &ls.Methods[i]
+		if strings.EqualFold(strings.ReplaceAll(m.Name, "_", ""), goName) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func takenAlias(aliases map[string]string, alias string) bool {
+	for _, a := range aliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
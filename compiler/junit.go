@@ -0,0 +1,187 @@
+package compiler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JUnitReport gathers the events of a test run into the JUnit XML that CI
+// servers read: a testsuite for each file, and a testcase for each test in
+// it. Add is given the events as SetTestEvents hands them over.
+type JUnitReport struct {
+	suites []*junitSuite
+	byFile map[string]*junitSuite
+}
+
+// NewJUnitReport starts an empty report.
+func NewJUnitReport() *JUnitReport {
+	return &JUnitReport{byFile: make(map[string]*junitSuite)}
+}
+
+type junitSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Errors   int           `xml:"errors,attr"`
+	Time     string        `xml:"time,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string       `xml:"name,attr"`
+	Tests     int          `xml:"tests,attr"`
+	Failures  int          `xml:"failures,attr"`
+	Errors    int          `xml:"errors,attr"`
+	Time      string       `xml:"time,attr"`
+	Timestamp string       `xml:"timestamp,attr,omitempty"`
+	Cases     []*junitCase `xml:"testcase"`
+	SystemOut string       `xml:"system-out,omitempty"`
+
+	elapsed float64
+	failed  bool
+	byName  map[string]*junitCase
+	out     strings.Builder
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+
+	out strings.Builder
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Add takes one event into the report.
+func (r *JUnitReport) Add(e TestEvent) {
+	s := r.byFile[e.Package]
+	if s == nil {
+		s = &junitSuite{Name: e.Package, byName: make(map[string]*junitCase)}
+		if !e.Time.IsZero() {
+			s.Timestamp = e.Time.UTC().Format(time.RFC3339)
+		}
+		r.byFile[e.Package] = s
+		r.suites = append(r.suites, s)
+	}
+	if e.Test == "" {
+		switch e.Action {
+		case "output", "build-output":
+			s.out.WriteString(e.Output)
+		case "pass", "fail":
+			s.failed = e.Action == "fail"
+			if e.Elapsed != nil {
+				s.elapsed = *e.Elapsed
+			}
+		}
+		return
+	}
+	tc := s.byName[e.Test]
+	if tc == nil {
+		tc = &junitCase{Name: e.Test, Classname: e.Package, Time: seconds(0)}
+		s.byName[e.Test] = tc
+		s.Cases = append(s.Cases, tc)
+	}
+	switch e.Action {
+	case "output":
+		tc.out.WriteString(e.Output)
+	case "fail":
+		tc.Failure = &junitProblem{Message: failMessage(tc.out.String(), e.Test), Text: tc.out.String()}
+		fallthrough
+	case "pass":
+		if e.Elapsed != nil {
+			tc.Time = seconds(*e.Elapsed)
+		}
+	}
+}
+
+// WriteTo writes the report as XML.
+func (r *JUnitReport) WriteTo(w io.Writer) (int64, error) {
+	all := junitSuites{Suites: r.suites}
+	var elapsed float64
+	for _, s := range r.suites {
+		s.Tests, s.Failures, s.Errors = 0, 0, 0
+		for _, tc := range s.Cases {
+			s.Tests++
+			if tc.Failure != nil {
+				s.Failures++
+			} else if tc.Error == nil {
+				tc.SystemOut = tc.out.String()
+			}
+		}
+		// A file that failed with none of its tests failing never got as far
+		// as its tests — it did not compile, or its top level broke — and
+		// that is an error of a case of its own, or a CI server would show
+		// the file as green.
+		if s.failed && s.Failures == 0 {
+			s.Cases = append(s.Cases, &junitCase{
+				Name:      filepath.Base(s.Name),
+				Classname: s.Name,
+				Time:      seconds(0),
+				Error:     &junitProblem{Message: "the tests did not run to the end", Text: s.out.String()},
+			})
+			s.Tests++
+			s.Errors++
+		} else {
+			s.SystemOut = s.out.String()
+		}
+		s.Time = seconds(s.elapsed)
+		all.Tests += s.Tests
+		all.Failures += s.Failures
+		all.Errors += s.Errors
+		elapsed += s.elapsed
+	}
+	all.Time = seconds(elapsed)
+
+	out, err := xml.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, xml.Header+string(out)+"\n")
+	return int64(n), err
+}
+
+// WriteFile writes the report to path.
+func (r *JUnitReport) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	return f.Close()
+}
+
+// failMessage is why a test says it failed, from the FAIL line it wrote, or
+// just that it did when it wrote none.
+func failMessage(out, test string) string {
+	for line := range strings.Lines(out) {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "FAIL: "+test)
+		if !ok {
+			continue
+		}
+		// Under -v the duration comes between the name and the message.
+		if _, msg, found := strings.Cut(rest, " - "); found && msg != "" {
+			return msg
+		}
+		break
+	}
+	return "Failed"
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,156 @@
+package compiler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A program's Go imports used to be resolved afresh by every build: `go get`
+// for the pins, `go mod tidy` for the rest, in a module made for the purpose
+// and thrown away after. Whatever the proxy said that day was what the program
+// was built against, and a build without the network was no build at all.
+//
+// meow.lock keeps what was resolved. `meow mod tidy` writes it, beside the
+// programs it covers; a build below it starts from the versions and hashes it
+// holds rather than asking, and refuses to go on if what it would build
+// against is not what the lock says. The hashes are go.sum's own lines, so the
+// toolchain checks what it downloads against them as it always does.
+
+// LockFile is the name of the file `meow mod tidy` writes.
+const LockFile = "meow.lock"
+
+// Lock is what meow.lock records.
+type Lock struct {
+	// Imports are the Go packages the programs fetch with `nab go`.
+	Imports []string
+	// Pins are the versions the programs pinned their imports to, by
+	// import path.
+	Pins map[string]string
+	// Modules are the versions every module of the build resolved to, by
+	// module path. The meow runtime is left out: which one a program links
+	// against is the compiler's to say.
+	Modules map[string]string
+	// Sums are the go.sum lines of those modules.
+	Sums []string
+}
+
+// ReadLock reads the lock file at path.
+func ReadLock(path string) (*Lock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	defer f.Close()
+
+	l := &Lock{Pins: make(map[string]string), Modules: make(map[string]string)}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, rest, _ := strings.Cut(line, " ")
+		fields := strings.Fields(rest)
+		switch {
+		case kind == "import" && len(fields) == 1:
+			l.Imports = append(l.Imports, fields[0])
+		case kind == "pin" && len(fields) == 2:
+			l.Pins[fields[0]] = fields[1]
+		case kind == "module" && len(fields) == 2:
+			l.Modules[fields[0]] = fields[1]
+		case kind == "sum" && len(fields) == 3:
+			l.Sums = append(l.Sums, strings.Join(fields, " "))
+		default:
+			return nil, fmt.Errorf("Hiss! %s:%d is not a line meow.lock can have, nya~", path, n)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	return l, nil
+}
+
+// String writes the lock as meow.lock holds it, each kind of line sorted so
+// that a lock resolved twice the same way is the same file.
+func (l *Lock) String() string {
+	var b strings.Builder
+	b.WriteString("# Written by meow mod tidy. Do not edit.\n")
+	imports := append([]string(nil), l.Imports...)
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		b.WriteString("\n")
+	}
+	for _, p := range imports {
+		fmt.Fprintf(&b, "import %s\n", p)
+	}
+	for _, section := range []struct {
+		kind  string
+		items map[string]string
+	}{{"pin", l.Pins}, {"module", l.Modules}} {
+		if len(section.items) > 0 {
+			b.WriteString("\n")
+		}
+		for _, k := range sortedKeys(section.items) {
+			fmt.Fprintf(&b, "%s %s %s\n", section.kind, k, section.items[k])
+		}
+	}
+	sums := append([]string(nil), l.Sums...)
+	sort.Strings(sums)
+	if len(sums) > 0 {
+		b.WriteString("\n")
+	}
+	for _, s := range sums {
+		fmt.Fprintf(&b, "sum %s\n", s)
+	}
+	return b.String()
+}
+
+// goMod writes the require block a build's go.mod gets from the lock.
+func (l *Lock) goMod() string {
+	if len(l.Modules) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nrequire (\n")
+	for _, path := range sortedKeys(l.Modules) {
+		fmt.Fprintf(&b, "\t%s %s\n", path, l.Modules[path])
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// goSum writes the lock's hashes as go.sum holds them.
+func (l *Lock) goSum() string {
+	if len(l.Sums) == 0 {
+		return ""
+	}
+	return strings.Join(l.Sums, "\n") + "\n"
+}
+
+// findLock looks for meow.lock in dir and the directories above it, and gives
+// back the directory it is in, or "" when there is none.
+func findLock(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, LockFile)); err == nil && !info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
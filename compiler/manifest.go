@@ -0,0 +1,275 @@
+package compiler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A Meow project used to be whatever .nyan files happened to sit together.
+// What its entry point was, which versions of its Go imports it wanted, which
+// lint rules it had no use for and how its tests were to be run lived on the
+// command lines of whoever built it, typed again every time.
+//
+// meow.mod says it once, at the top of the project, in the same
+// one-line-per-thing shape as go.mod:
+//
+//	project shelter
+//	main main.nyan
+//	pin github.com/aws/aws-sdk-go-v2/config v1.27.0
+//	lint disable unused-var
+//	test paths ./...
+//	test cover
+//
+// `meow build` and `meow test` with nothing to go on read it, and every build
+// below it takes its pins.
+
+// ManifestFile is the name of a project's manifest.
+const ManifestFile = "meow.mod"
+
+// Manifest is what meow.mod declares.
+type Manifest struct {
+	// Dir is the directory the manifest is in, which its paths are
+	// relative to.
+	Dir string
+	// Project is the project's name, and what `meow build` calls what it
+	// builds.
+	Project string
+	// Main is the file `meow build` builds: a program, or with Lib a
+	// package for Go code to import.
+	Main string
+	Lib  bool
+	// Pins are the versions the project's Go imports are held to, by
+	// import path. They apply wherever `nab go` names the path without a
+	// version of its own.
+	Pins map[string]string
+	// LintDisable names the lint rules the project turns off.
+	LintDisable []string
+	// Test is how `meow test` runs the project's tests.
+	Test TestSettings
+}
+
+// TestSettings are the `test` lines of a manifest.
+type TestSettings struct {
+	// Paths are the files and patterns tested when none are given.
+	Paths []string
+	// Cover turns statement coverage on, and CoverProfile says where its
+	// profile goes.
+	Cover        bool
+	CoverProfile string
+	// FuzzTime is how long each fuzz test runs.
+	FuzzTime string
+}
+
+// projectName is what a project may be called: a name Meow could write,
+// so that a library's package and a program's binary can both be named after
+// it.
+var projectName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ReadManifest reads the manifest at path.
+func ReadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	defer f.Close()
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	m := &Manifest{Dir: dir, Pins: make(map[string]string)}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		bad := func(why string) error {
+			return fmt.Errorf("Hiss! %s:%d: %s, nya~", path, n, why)
+		}
+		switch kind, args := fields[0], fields[1:]; {
+		case kind == "project" && len(args) == 1:
+			if !projectName.MatchString(args[0]) {
+				return nil, bad(fmt.Sprintf("%q is not a name a project can have; use lowercase letters, digits and _", args[0]))
+			}
+			m.Project = args[0]
+		case (kind == "main" || kind == "lib") && len(args) == 1:
+			if m.Main != "" {
+				return nil, bad("a project has one main or lib")
+			}
+			m.Main, m.Lib = args[0], kind == "lib"
+		case kind == "pin" && len(args) == 2:
+			if _, dup := m.Pins[args[0]]; dup {
+				return nil, bad(args[0] + " is pinned twice")
+			}
+			m.Pins[args[0]] = args[1]
+		case kind == "lint" && len(args) >= 2 && args[0] == "disable":
+			m.LintDisable = append(m.LintDisable, args[1:]...)
+		case kind == "test" && len(args) >= 2 && args[0] == "paths":
+			m.Test.Paths = append(m.Test.Paths, args[1:]...)
+		case kind == "test" && len(args) == 1 && args[0] == "cover":
+			m.Test.Cover = true
+		case kind == "test" && len(args) == 2 && args[0] == "coverprofile":
+			m.Test.Cover, m.Test.CoverProfile = true, args[1]
+		case kind == "test" && len(args) == 2 && args[0] == "fuzztime":
+			m.Test.FuzzTime = args[1]
+		default:
+			return nil, bad(fmt.Sprintf("%q is not a line meow.mod can have", strings.TrimSpace(line)))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+	}
+	if m.Project == "" {
+		return nil, fmt.Errorf("Hiss! %s does not say what the project is called; add a project line, nya~", path)
+	}
+	return m, nil
+}
+
+// FindManifest reads the meow.mod in dir or the nearest directory above it.
+// It answers nil, and no error, when there is none.
+func FindManifest(dir string) (*Manifest, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot find %s, nya~: %w", dir, err)
+	}
+	for {
+		path := filepath.Join(abs, ManifestFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return ReadManifest(path)
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil, nil
+		}
+		abs = parent
+	}
+}
+
+// String writes the manifest as meow.mod holds it.
+func (m *Manifest) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "project %s\n", m.Project)
+	if m.Main != "" {
+		kind := "main"
+		if m.Lib {
+			kind = "lib"
+		}
+		fmt.Fprintf(&b, "%s %s\n", kind, m.Main)
+	}
+	if len(m.Pins) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range sortedKeys(m.Pins) {
+		fmt.Fprintf(&b, "pin %s %s\n", path, m.Pins[path])
+	}
+	if len(m.LintDisable) > 0 {
+		fmt.Fprintf(&b, "\nlint disable %s\n", strings.Join(m.LintDisable, " "))
+	}
+	t := m.Test
+	if len(t.Paths) > 0 || t.Cover || t.FuzzTime != "" {
+		b.WriteString("\n")
+	}
+	if len(t.Paths) > 0 {
+		fmt.Fprintf(&b, "test paths %s\n", strings.Join(t.Paths, " "))
+	}
+	switch {
+	case t.CoverProfile != "":
+		fmt.Fprintf(&b, "test coverprofile %s\n", t.CoverProfile)
+	case t.Cover:
+		b.WriteString("test cover\n")
+	}
+	if t.FuzzTime != "" {
+		fmt.Fprintf(&b, "test fuzztime %s\n", t.FuzzTime)
+	}
+	return b.String()
+}
+
+// Path resolves a path the manifest names against the directory it is in.
+func (m *Manifest) Path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(m.Dir, p)
+}
+
+// InitProject writes a meow.mod for the project in dir, called name. When
+// the directory already has a main.nyan, that is its entry point.
+func InitProject(dir, name string) (*Manifest, error) {
+	if !projectName.MatchString(name) {
+		return nil, fmt.Errorf("Hiss! %q is not a name a project can have; use lowercase letters, digits and _, nya~", name)
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("Hiss! %s already exists, nya~", path)
+	}
+	m := &Manifest{Project: name, Test: TestSettings{Paths: []string{"./..."}}}
+	if _, err := os.Stat(filepath.Join(dir, "main.nyan")); err == nil {
+		m.Main = "main.nyan"
+	}
+	if err := os.WriteFile(path, []byte(m.String()), 0644); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	return ReadManifest(path)
+}
+
+// NewProject makes a project called name in a new directory dir: a manifest,
+// an entry point and a test of it, ready for `meow build` and `meow test`.
+// With lib the entry point is a library for Go code to import rather than a
+// program.
+func NewProject(dir, name string, lib bool) (*Manifest, error) {
+	if !projectName.MatchString(name) {
+		return nil, fmt.Errorf("Hiss! %q is not a name a project can have; use lowercase letters, digits and _, nya~", name)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("Hiss! %s already exists, nya~", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot create %s, nya~: %w", dir, err)
+	}
+
+	m := &Manifest{Project: name, Main: "main.nyan", Lib: lib, Test: TestSettings{Paths: []string{"./..."}}}
+	files := map[string]string{
+		"main.nyan": `# The entry point of ` + name + `.
+
+meow greet(name string) string {
+  bring "Hello, " + name + "!"
+}
+
+nya(greet("Nyantyu"))
+`,
+		"main_test.nyan": `meow test_greet() {
+  expect(greet("Tyako"), "Hello, Tyako!")
+}
+`,
+	}
+	if lib {
+		m.Main = name + ".nyan"
+		files = map[string]string{
+			m.Main: `# ` + name + ` is a library. meow build makes it a Go package, whose
+# functions are these in Go's spelling: greet is Greet.
+
+meow greet(name string) string {
+  bring "Hello, " + name + "!"
+}
+`,
+			name + "_test.nyan": `meow test_greet() {
+  expect(greet("Tyako"), "Hello, Tyako!")
+}
+`,
+		}
+	}
+	files[ManifestFile] = m.String()
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+		}
+	}
+	return ReadManifest(filepath.Join(dir, ManifestFile))
+}
@@ -0,0 +1,167 @@
+package compiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+func TestAManifestReadsBackAsItWasWritten(t *testing.T) {
+	m := &compiler.Manifest{
+		Project:     "shelter",
+		Main:        "main.nyan",
+		Pins:        map[string]string{"example.com/whisker": "v1.0.0"},
+		LintDisable: []string{"unused-var", "empty-block"},
+		Test:        compiler.TestSettings{Paths: []string{"./...", "extra/"}, Cover: true, CoverProfile: "cover.out", FuzzTime: "30s"},
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, compiler.ManifestFile)
+	if err := os.WriteFile(path, []byte(m.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	back, err := compiler.ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.String() != m.String() {
+		t.Errorf("read back as\n%s\nwant\n%s", back, m)
+	}
+	if back.Path("main.nyan") != filepath.Join(dir, "main.nyan") {
+		t.Errorf("Path = %s, want it under %s", back.Path("main.nyan"), dir)
+	}
+}
+
+func TestAManifestSaysWhatIsWrongWithIt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no project", "main main.nyan\n", "does not say what the project is called"},
+		{"bad name", "project Shelter\n", `"Shelter" is not a name a project can have`},
+		{"two mains", "project shelter\nmain a.nyan\nlib b.nyan\n", "meow.mod:3: a project has one main or lib"},
+		{"pinned twice", "project shelter\npin a.com/b v1.0.0\npin a.com/b v1.1.0\n", "a.com/b is pinned twice"},
+		{"unknown line", "project shelter\nbreed cat\n", `"breed cat" is not a line meow.mod can have`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), compiler.ManifestFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := compiler.ReadManifest(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadManifest = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindManifestWalksUp(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := compiler.InitProject(dir, "shelter"); err != nil {
+		t.Fatal(err)
+	}
+	below := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(below, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m, err := compiler.FindManifest(below)
+	if err != nil || m == nil || m.Project != "shelter" {
+		t.Fatalf("FindManifest = %v, %v; want the shelter project", m, err)
+	}
+	if _, err := compiler.InitProject(dir, "shelter"); err == nil {
+		t.Error("a second meow init should not overwrite the first")
+	}
+	if m, err := compiler.FindManifest(t.TempDir()); m != nil || err != nil {
+		t.Errorf("FindManifest outside a project = %v, %v; want nothing", m, err)
+	}
+}
+
+func TestANewAppBuildsAndPassesItsTest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shelter")
+	m, err := compiler.NewProject(dir, "shelter", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Main != "main.nyan" || m.Lib {
+		t.Fatalf("main = %q, lib = %v; want an app built from main.nyan", m.Main, m.Lib)
+	}
+	c := compiler.New(nil)
+	if err := c.RunTest(filepath.Join(dir, "main_test.nyan")); err != nil {
+		t.Errorf("the new app's test failed: %v", err)
+	}
+	if err := c.Build(m.Path(m.Main), m.Path(m.Project)); err != nil {
+		t.Errorf("the new app did not build: %v", err)
+	}
+}
+
+func TestABuildSaysWhatItRead(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shelter")
+	if _, err := compiler.NewProject(dir, "shelter", false); err != nil {
+		t.Fatal(err)
+	}
+	c := compiler.New(nil)
+	if err := c.RunTest(filepath.Join(dir, "main_test.nyan")); err != nil {
+		t.Fatal(err)
+	}
+	read := c.FilesRead()
+	for _, name := range []string{"main_test.nyan", "main.nyan", compiler.ManifestFile} {
+		if !slices.Contains(read, filepath.Join(dir, name)) {
+			t.Errorf("FilesRead = %v, want %s in it", read, name)
+		}
+	}
+}
+
+func TestANewLibBuildsAndPassesItsTest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pricing")
+	m, err := compiler.NewProject(dir, "pricing", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := compiler.New(nil)
+	if err := c.RunTest(filepath.Join(dir, "pricing_test.nyan")); err != nil {
+		t.Errorf("the new lib's test failed: %v", err)
+	}
+	if err := c.BuildLib(m.Path(m.Main), m.Path(m.Project)); err != nil {
+		t.Errorf("the new lib did not build: %v", err)
+	}
+	if _, err := compiler.NewProject(dir, "pricing", true); err == nil {
+		t.Error("meow new should not write over a directory that exists")
+	}
+}
+
+func TestTheProjectPinsImportsThatNameNoVersion(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	manifest := "project shelter\npin example.com/whisker v1.0.0\n"
+	writeProgram(t, dir, compiler.ManifestFile, manifest)
+	writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker\"\nnya(whisker.loud(\"nya\"))\n")
+
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := compiler.ReadLock(filepath.Join(dir, compiler.LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Left to itself the proxy's latest, v1.1.0, is what would be chosen.
+	if lock.Modules["example.com/whisker"] != "v1.0.0" {
+		t.Errorf("modules = %v, want example.com/whisker at the project's v1.0.0", lock.Modules)
+	}
+
+	writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker@v1.1.0\"\nnya(whisker.loud(\"nya\"))\n")
+	err = c.TidyModules(dir)
+	if err == nil || !strings.Contains(err.Error(), "but meow.mod pins it to v1.0.0") {
+		t.Errorf("TidyModules = %v, want the disagreement with meow.mod refused", err)
+	}
+	err = c.Build(filepath.Join(dir, "cat.nyan"), filepath.Join(dir, "cat"))
+	if err == nil || !strings.Contains(err.Error(), "but meow.mod pins it to v1.0.0") {
+		t.Errorf("Build = %v, want the disagreement with meow.mod refused", err)
+	}
+}
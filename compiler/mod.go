@@ -0,0 +1,609 @@
+package compiler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+// runtimeImport is the package every generated program imports.
+const runtimeImport = meowModulePath + "/runtime/meowrt"
+
+// SetOffline has builds use nothing but meow.lock and what the module cache
+// already holds. A module the cache is missing is an error rather than a
+// download.
+func (c *Compiler) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// useProjectFor finds the meow.mod and the meow.lock covering the program at
+// nyanPath, if there are any, for the build that follows to take its pins
+// from the one and be held to the other.
+func (c *Compiler) useProjectFor(nyanPath string) error {
+	c.lock, c.lockDir, c.projectPins = nil, "", nil
+	abs, err := filepath.Abs(nyanPath)
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot find %s, nya~: %w", nyanPath, err)
+	}
+	c.readFrom(abs)
+	m, err := FindManifest(filepath.Dir(abs))
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		c.projectPins = m.Pins
+		c.readFrom(m.Path(ManifestFile))
+	}
+	dir := findLock(filepath.Dir(abs))
+	if dir == "" {
+		return nil
+	}
+	c.readFrom(filepath.Join(dir, LockFile))
+	lock, err := ReadLock(filepath.Join(dir, LockFile))
+	if err != nil {
+		return err
+	}
+	c.logger.Debug("using lock", "file", filepath.Join(dir, LockFile))
+	c.lock, c.lockDir = lock, dir
+	return nil
+}
+
+// FilesRead are the files the builds of c have read so far: the .nyan files
+// compiled, and the meow.mod and meow.lock they were built by. A change to
+// any of them may change what a build makes, which is what a watch for
+// changes wants to know.
+func (c *Compiler) FilesRead() []string {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	return slices.Sorted(maps.Keys(c.read))
+}
+
+// readFrom records that a build read the file at path.
+func (c *Compiler) readFrom(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.read == nil {
+		c.read = make(map[string]bool)
+	}
+	c.read[path] = true
+}
+
+// vendorDir is the vendor directory `meow mod vendor` wrote beside the lock in
+// use, or "" when there is none. A vendor directory it did not write — the Go
+// module the project sits in may keep its own — is not the build's to read.
+func (c *Compiler) vendorDir() string {
+	if c.lock == nil || c.vendoring {
+		return ""
+	}
+	dir := filepath.Join(c.lockDir, "vendor")
+	if !isMeowVendor(dir) {
+		return ""
+	}
+	return dir
+}
+
+// vendorMark is the file `meow mod vendor` leaves in the vendor directory it
+// writes, which is what tells that directory from any other called vendor.
+const vendorMark = ".meow"
+
+// isMeowVendor reports whether dir is a vendor directory meow wrote.
+func isMeowVendor(dir string) bool {
+	for _, name := range []string{"modules.txt", vendorMark} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// goEnv is the environment every go command of a build runs in.
+func (c *Compiler) goEnv() []string {
+	env := os.Environ()
+	switch {
+	case c.offline:
+		env = append(env, "GOPROXY=off")
+	case c.fromModuleCache:
+		// The module cache keeps what it downloaded the way a proxy serves
+		// it, so it can be one, for "latest" as much as for a version. What
+		// is in it was checked against go.sum when it was put there.
+		if cache := goEnvOf("GOMODCACHE"); cache != "" {
+			env = append(env, "GOPROXY=file://"+filepath.ToSlash(filepath.Join(cache, "cache", "download")), "GOSUMDB=off")
+		}
+	}
+	if c.vendorDir() != "" {
+		env = withGoFlag(env, "-mod=vendor")
+	}
+	return env
+}
+
+// withGoFlag is env with flag added to the GOFLAGS the user already has, in
+// place of any flag of theirs of the same name. Setting GOFLAGS outright would
+// drop the rest of them, and so would leaving out those `go env -w` keeps,
+// which the variable overrides rather than adds to.
+func withGoFlag(env []string, flag string) []string {
+	name, _, _ := strings.Cut(flag, "=")
+	var flags []string
+	for _, f := range strings.Fields(goFlagsIn(env)) {
+		if n, _, _ := strings.Cut(f, "="); n != name {
+			flags = append(flags, f)
+		}
+	}
+	return append(env, "GOFLAGS="+strings.Join(append(flags, flag), " "))
+}
+
+// goFlagsIn is the GOFLAGS a go command run in env would have.
+func goFlagsIn(env []string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], "GOFLAGS="); ok {
+			return v
+		}
+	}
+	return goEnvOf("GOFLAGS")
+}
+
+// goEnvOf is what `go env` says name is, or "" when it will not say. It
+// asks once for each name.
+func goEnvOf(name string) string {
+	goEnvMu.Lock()
+	defer goEnvMu.Unlock()
+	if v, ok := goEnvValues[name]; ok {
+		return v
+	}
+	out, err := exec.Command("go", "env", name).Output()
+	v := ""
+	if err == nil {
+		v = strings.TrimSpace(string(out))
+	}
+	goEnvValues[name] = v
+	return v
+}
+
+var (
+	goEnvMu     sync.Mutex
+	goEnvValues = make(map[string]string)
+)
+
+// goCmd makes a go command run in dir, in the build's environment, killed if
+// the context the build runs under is done first.
+func (c *Compiler) goCmd(dir string, args ...string) *exec.Cmd {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = c.goEnv()
+	cmd.Stderr = os.Stderr
+	if c.fromModuleCache {
+		// A module the cache does not hold is not a failure of the build,
+		// and readGoAPI says what it means.
+		cmd.Stderr = nil
+	}
+	return cmd
+}
+
+// prepareModule writes the go.mod of the build in dir and settles what it
+// requires.
+//
+// Without a lock that is the toolchain's to decide: the pins are fetched and
+// `go mod tidy` finds the rest. With one, the build starts from the lock's
+// versions and hashes, and whatever tidy would change about them is refused —
+// a lock the program has outgrown says so, rather than the build quietly
+// resolving something the lock never saw. A vendor directory beside the lock
+// is used as it is, with nothing resolved at all.
+func (c *Compiler) prepareModule(dir string) error {
+	modRoot := c.findModuleRoot()
+	modContent, err := buildModContent(goVersionFor(modRoot), modRoot)
+	if err != nil {
+		return err
+	}
+	if c.lock == nil {
+		if c.offline && len(c.goPaths) > 0 {
+			return fmt.Errorf("Hiss! An offline build needs a meow.lock for %s; run meow mod tidy first, nya~", strings.Join(c.goPaths, " "))
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(modContent), 0644); err != nil {
+			return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
+		}
+		if err := c.fetchGoPins(dir); err != nil {
+			return err
+		}
+		return c.tidy(dir)
+	}
+
+	if err := c.checkAgainstLock(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(modContent+c.lock.goMod()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte(c.lock.goSum()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.sum, nya~: %w", err)
+	}
+	if vendor := c.vendorDir(); vendor != "" {
+		c.logger.Debug("using vendored modules", "dir", vendor)
+		return os.CopyFS(filepath.Join(dir, "vendor"), os.DirFS(vendor))
+	}
+	if err := c.tidy(dir); err != nil {
+		return err
+	}
+	return c.checkResolved(dir)
+}
+
+// tidy runs `go mod tidy` in dir.
+func (c *Compiler) tidy(dir string) error {
+	if err := c.goCmd(dir, "mod", "tidy").Run(); err != nil {
+		if c.offline {
+			return fmt.Errorf("Hiss! go mod tidy failed offline, nya~: %w; run meow mod download while online", err)
+		}
+		return fmt.Errorf("Hiss! go mod tidy failed, nya~: %w", err)
+	}
+	return nil
+}
+
+// checkAgainstLock holds the program's imports and pins to the lock in use.
+func (c *Compiler) checkAgainstLock() error {
+	locked := make(map[string]bool, len(c.lock.Imports))
+	for _, p := range c.lock.Imports {
+		locked[p] = true
+	}
+	for _, p := range c.goPaths {
+		if !locked[p] {
+			return fmt.Errorf("Hiss! %s is not in %s; run meow mod tidy, nya~", p, c.lockPath())
+		}
+	}
+	for _, path := range sortedKeys(c.goPins) {
+		had, ok := c.lock.Pins[path]
+		switch {
+		case !ok:
+			return fmt.Errorf("Hiss! %s is pinned to %s, but %s has no pin for it; run meow mod tidy, nya~", path, c.goPins[path], c.lockPath())
+		case had != c.goPins[path]:
+			return fmt.Errorf("Hiss! %s is pinned to %s, but %s has %s; run meow mod tidy, nya~", path, c.goPins[path], c.lockPath(), had)
+		}
+	}
+	return nil
+}
+
+// checkResolved holds what `go mod tidy` settled on in dir to the lock.
+func (c *Compiler) checkResolved(dir string) error {
+	required, err := c.requirements(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range sortedKeys(required) {
+		had, ok := c.lock.Modules[path]
+		switch {
+		case !ok:
+			return fmt.Errorf("Hiss! The build needs %s %s, which %s does not have; run meow mod tidy, nya~", path, required[path], c.lockPath())
+		case had != required[path]:
+			return fmt.Errorf("Hiss! The build needs %s %s, but %s has %s; run meow mod tidy, nya~", path, required[path], c.lockPath(), had)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) lockPath() string {
+	return filepath.Join(c.lockDir, LockFile)
+}
+
+// requirements reads the modules the go.mod in dir requires, by path, the
+// meow runtime aside.
+func (c *Compiler) requirements(dir string) (map[string]string, error) {
+	cmd := c.goCmd(dir, "mod", "edit", "-json")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read go.mod, nya~: %w", err)
+	}
+	var mod struct {
+		Require []struct{ Path, Version string }
+	}
+	if err := json.Unmarshal(out, &mod); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read go.mod, nya~: %w", err)
+	}
+	required := make(map[string]string, len(mod.Require))
+	for _, r := range mod.Require {
+		if r.Path != meowModulePath {
+			required[r.Path] = r.Version
+		}
+	}
+	return required, nil
+}
+
+// preparingFor has the module c prepares next be held to lock, read from
+// lockDir, and fetch paths at pins, until the func it answers is called, which
+// puts back what the build before had. A module tidied or vendored is one of
+// its own, and a build the same Compiler goes on to make is not about it.
+func (c *Compiler) preparingFor(lock *Lock, lockDir string, pins map[string]string, paths []string) (restore func()) {
+	prevLock, prevDir, prevPins, prevPaths := c.lock, c.lockDir, c.goPins, c.goPaths
+	c.lock, c.lockDir, c.goPins, c.goPaths = lock, lockDir, pins, paths
+	return func() {
+		c.lock, c.lockDir, c.goPins, c.goPaths = prevLock, prevDir, prevPins, prevPaths
+	}
+}
+
+// TidyModules resolves the Go imports of every .nyan file under dir and
+// writes what they resolved to into dir's meow.lock.
+//
+// The files are resolved together, in one module, because they are locked
+// together: two programs beside one another building against two versions of
+// a module is the surprise a lock is there to prevent.
+func (c *Compiler) TidyModules(dir string) error {
+	m, err := FindManifest(dir)
+	if err != nil {
+		return err
+	}
+	var projectPins map[string]string
+	if m != nil {
+		projectPins = m.Pins
+	}
+	imports, pins, err := goImportsUnder(dir, projectPins)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "meow-mod-*")
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := writeStub(tmpDir, imports); err != nil {
+		return err
+	}
+	defer c.preparingFor(nil, "", pins, nil)()
+	if err := c.prepareModule(tmpDir); err != nil {
+		return err
+	}
+
+	modules, err := c.requirements(tmpDir)
+	if err != nil {
+		return err
+	}
+	lock := &Lock{Imports: imports, Pins: pins, Modules: modules}
+	sums, err := os.ReadFile(filepath.Join(tmpDir, "go.sum"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Hiss! Cannot read go.sum, nya~: %w", err)
+	}
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] != meowModulePath {
+			lock.Sums = append(lock.Sums, line)
+		}
+	}
+	path := filepath.Join(dir, LockFile)
+	if err := os.WriteFile(path, []byte(lock.String()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	return nil
+}
+
+// DownloadModules fetches every module dir's meow.lock holds into the module
+// cache, checked against the lock's hashes, so that an offline build has
+// them.
+func (c *Compiler) DownloadModules(dir string) error {
+	lock, err := readLockIn(dir)
+	if err != nil {
+		return err
+	}
+	if len(lock.Modules) == 0 {
+		return nil
+	}
+	tmpDir, err := os.MkdirTemp("", "meow-mod-*")
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mod := fmt.Sprintf("module meow_download\n\ngo %s\n", goVersionFor(c.findModuleRoot()))
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(mod+lock.goMod()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.sum"), []byte(lock.goSum()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.sum, nya~: %w", err)
+	}
+	specs := make([]string, 0, len(lock.Modules))
+	for _, path := range sortedKeys(lock.Modules) {
+		specs = append(specs, path+"@"+lock.Modules[path])
+	}
+	c.logger.Debug("downloading modules", "modules", specs)
+	if err := c.goCmd(tmpDir, append([]string{"mod", "download"}, specs...)...).Run(); err != nil {
+		return fmt.Errorf("Hiss! Cannot download %s, nya~: %w", strings.Join(specs, " "), err)
+	}
+	return nil
+}
+
+// VendorModules copies the source of every package dir's meow.lock covers
+// into dir/vendor. A build that finds it there reads nothing else — no
+// network, no module cache.
+//
+// The new vendor directory is made and built against away from dir, and takes
+// the place of the old one only once it builds, so a failure leaves the old
+// one as it was. A vendor directory meow did not write is never replaced.
+func (c *Compiler) VendorModules(dir string) error {
+	lock, err := readLockIn(dir)
+	if err != nil {
+		return err
+	}
+	vendor := filepath.Join(dir, "vendor")
+	if _, err := os.Stat(vendor); err == nil && !isMeowVendor(vendor) {
+		return fmt.Errorf("Hiss! %s was not written by meow mod vendor, so it is left alone; move it aside first, nya~", vendor)
+	}
+	tmpDir, err := os.MkdirTemp("", "meow-mod-*")
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := writeStub(tmpDir, lock.Imports); err != nil {
+		return err
+	}
+	// The module is written exactly as a locked build writes its own, which
+	// is what has the toolchain accept the vendor directory there.
+	defer c.preparingFor(lock, dir, lock.Pins, lock.Imports)()
+	c.vendoring = true
+	defer func() { c.vendoring = false }()
+	if err := c.prepareModule(tmpDir); err != nil {
+		return err
+	}
+	// Tidying has the module's go.mod say more than the build's will, so the
+	// vendor directory is made from the build's.
+	modRoot := c.findModuleRoot()
+	modContent, err := buildModContent(goVersionFor(modRoot), modRoot)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent+lock.goMod()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
+	}
+	if err := c.goCmd(tmpDir, "mod", "vendor").Run(); err != nil {
+		return fmt.Errorf("Hiss! go mod vendor failed, nya~: %w", err)
+	}
+	if err := c.goCmd(tmpDir, "build", "-mod=vendor", "-o", filepath.Join(tmpDir, "stub"), ".").Run(); err != nil {
+		return fmt.Errorf("Hiss! The vendored modules do not build, nya~: %w", err)
+	}
+	mark := "Written by meow mod vendor from " + LockFile + "; run it again rather than editing this directory.\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "vendor", vendorMark), []byte(mark), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", vendorMark, err)
+	}
+	return replaceDir(vendor, os.DirFS(filepath.Join(tmpDir, "vendor")))
+}
+
+// replaceDir has dir hold what fsys does, in place of whatever it held. The
+// copy is made beside dir and renamed into place, so dir is never left half
+// written.
+func replaceDir(dir string, fsys fs.FS) error {
+	staged, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-new-*")
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(staged)
+	// MkdirTemp makes a directory only its owner can read.
+	if err := os.Chmod(staged, 0755); err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", dir, err)
+	}
+	if err := os.CopyFS(staged, fsys); err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", dir, err)
+	}
+	old := staged + ".old"
+	if err := os.Rename(dir, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Hiss! Cannot replace %s, nya~: %w", dir, err)
+	}
+	if err := os.Rename(staged, dir); err != nil {
+		os.Rename(old, dir)
+		return fmt.Errorf("Hiss! Cannot replace %s, nya~: %w", dir, err)
+	}
+	return os.RemoveAll(old)
+}
+
+func readLockIn(dir string) (*Lock, error) {
+	path := filepath.Join(dir, LockFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Hiss! There is no %s; run meow mod tidy first, nya~", path)
+	}
+	return ReadLock(path)
+}
+
+// writeStub writes a Go file importing the packages a module is resolved for,
+// the runtime among them, since the build that follows imports it too.
+func writeStub(dir string, imports []string) error {
+	var b strings.Builder
+	b.WriteString("package main\n\n")
+	for _, p := range append([]string{runtimeImport}, imports...) {
+		fmt.Fprintf(&b, "import _ %q\n", p)
+	}
+	b.WriteString("\nfunc main() {}\n")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
+	}
+	return nil
+}
+
+// goImportsUnder reads the `nab go` imports and pins of every .nyan file under
+// dir, with the project's pins for those that name no version. A vendor
+// directory and anything hidden are passed over.
+func goImportsUnder(dir string, projectPins map[string]string) (imports []string, pins map[string]string, err error) {
+	pins = make(map[string]string)
+	pinnedIn := make(map[string]string)
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".nyan") {
+			return nil
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Hiss! Cannot read %s, nya~: %w", path, err)
+		}
+		prog, errs := parser.New(lexer.New(string(source), path).Tokens()).Parse()
+		if len(errs) > 0 {
+			return errs[0]
+		}
+		for _, stmt := range prog.Stmts {
+			fs, ok := stmt.(*ast.FetchStmt)
+			if !ok || !fs.Go {
+				continue
+			}
+			if !seen[fs.Path] {
+				seen[fs.Path] = true
+				imports = append(imports, fs.Path)
+			}
+			if fs.Version == "" {
+				continue
+			}
+			if had, pinned := pins[fs.Path]; pinned && had != fs.Version {
+				return fmt.Errorf("Hiss! %s is pinned to %s in %s and %s in %s, nya~", fs.Path, had, pinnedIn[fs.Path], fs.Version, path)
+			}
+			pins[fs.Path], pinnedIn[fs.Path] = fs.Version, path
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := applyProjectPins(imports, pins, projectPins); err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(imports)
+	return imports, pins, nil
+}
+
+// applyProjectPins pins each of paths that pins has no version for to the
+// project's version of it. A program pinning a path the project pins too has
+// to agree with it: one project building against two versions of a module
+// is the same surprise as two programs doing so.
+func applyProjectPins(paths []string, pins, projectPins map[string]string) error {
+	for _, path := range paths {
+		want, ok := projectPins[path]
+		if !ok {
+			continue
+		}
+		if had, pinned := pins[path]; pinned && had != want {
+			return fmt.Errorf("Hiss! %s is pinned to %s, but %s pins it to %s, nya~", path, had, ManifestFile, want)
+		}
+		pins[path] = want
+	}
+	return nil
+}
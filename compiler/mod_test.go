@@ -0,0 +1,245 @@
+package compiler_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+// fakeProxy serves example.com/whisker at v1.0.0 and v1.1.0 the way a module
+// proxy does, from a directory, so that resolving it needs no network, along
+// with example.com/broken, whose one package does not compile. The module
+// cache is one of the test's own, so what is in it is what the test put
+// there.
+func fakeProxy(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	serve := func(path string, files func(v string) map[string]string, versions ...string) {
+		dir := filepath.Join(root, filepath.FromSlash(path), "@v")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		write := func(name, content string) {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		write("list", strings.Join(versions, "\n")+"\n")
+		for _, v := range versions {
+			mod := "module " + path + "\n\ngo 1.21\n"
+			write(v+".info", `{"Version":"`+v+`","Time":"2024-01-01T00:00:00Z"}`)
+			write(v+".mod", mod)
+			f, err := os.Create(filepath.Join(dir, v+".zip"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			z := zip.NewWriter(f)
+			for name, content := range files(v) {
+				w, err := z.Create(path + "@" + v + "/" + name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write([]byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w, err := z.Create(path + "@" + v + "/go.mod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(mod)); err != nil {
+				t.Fatal(err)
+			}
+			if err := z.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		}
+	}
+	serve("example.com/whisker", func(v string) map[string]string {
+		return map[string]string{
+			"whisker.go": "package whisker\n\n// Version is the version the package was fetched at.\nconst Version = \"" + v + "\"\n\nfunc Loud(s string) string { return s + \"!\" }\n",
+		}
+	}, "v1.0.0", "v1.1.0")
+	serve("example.com/broken", func(string) map[string]string {
+		return map[string]string{
+			"broken.go": "package broken\n\nfunc Purr() string { return 1 }\n",
+		}
+	}, "v1.0.0")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(root))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+}
+
+func writeProgram(t *testing.T, dir, name, source string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestModTidyLocksWhatTheProgramsResolveTo(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	writeProgram(t, dir, "a.nyan", "nab go \"example.com/whisker@v1.0.0\"\nnya(whisker.loud(\"nya\"))\n")
+	writeProgram(t, dir, "b.nyan", "nab go \"example.com/whisker\"\nnab go \"strings\"\nnya(strings.to_upper(whisker.loud(\"mew\")))\n")
+
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := compiler.ReadLock(filepath.Join(dir, compiler.LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lock.Imports, " ") != "example.com/whisker strings" {
+		t.Errorf("imports = %v", lock.Imports)
+	}
+	if lock.Pins["example.com/whisker"] != "v1.0.0" || lock.Modules["example.com/whisker"] != "v1.0.0" {
+		t.Errorf("pins = %v, modules = %v; want example.com/whisker at v1.0.0", lock.Pins, lock.Modules)
+	}
+	if len(lock.Sums) != 2 {
+		t.Errorf("sums = %v, want the module's and its go.mod's", lock.Sums)
+	}
+}
+
+func TestModTidyRefusesTwoPinsOfOnePath(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "a.nyan", "nab go \"example.com/whisker@v1.0.0\"\n")
+	writeProgram(t, dir, "b.nyan", "nab go \"example.com/whisker@v1.1.0\"\n")
+
+	err := compiler.New(nil).TidyModules(dir)
+	if err == nil || !strings.Contains(err.Error(), "example.com/whisker is pinned to v1.0.0 in") {
+		t.Errorf("TidyModules = %v, want the two pins named", err)
+	}
+}
+
+func TestALockedBuildIsHeldToTheLock(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	prog := writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker@v1.0.0\"\nnya(whisker.loud(\"nya\"))\n")
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Build(prog, filepath.Join(dir, "cat")); err != nil {
+		t.Fatalf("a build the lock covers failed: %v", err)
+	}
+
+	writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker@v1.1.0\"\nnya(whisker.loud(\"nya\"))\n")
+	err := c.Build(prog, filepath.Join(dir, "cat"))
+	if err == nil || !strings.Contains(err.Error(), "example.com/whisker is pinned to v1.1.0, but") {
+		t.Errorf("Build = %v, want the pin the lock does not have refused", err)
+	}
+
+	writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker@v1.0.0\"\nnab go \"strings\"\nnya(strings.to_upper(\"nya\"))\n")
+	err = c.Build(prog, filepath.Join(dir, "cat"))
+	if err == nil || !strings.Contains(err.Error(), "strings is not in") {
+		t.Errorf("Build = %v, want the import the lock does not have refused", err)
+	}
+}
+
+func TestAnOfflineBuildUsesTheLockAndTheCache(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	prog := writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker\"\nnya(whisker.loud(\"nya\"))\n")
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	// A cache of its own, which only download fills.
+	t.Setenv("GOMODCACHE", t.TempDir())
+	if err := c.DownloadModules(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetOffline(true)
+	if err := c.Build(prog, filepath.Join(dir, "cat")); err != nil {
+		t.Fatalf("offline build failed: %v", err)
+	}
+
+	elsewhere := t.TempDir()
+	unlocked := writeProgram(t, elsewhere, "cat.nyan", "nab go \"example.com/whisker\"\nnya(whisker.loud(\"nya\"))\n")
+	err := c.Build(unlocked, filepath.Join(elsewhere, "cat"))
+	if err == nil || !strings.Contains(err.Error(), "An offline build needs a meow.lock") {
+		t.Errorf("Build = %v, want an offline build without a lock refused", err)
+	}
+}
+
+func TestAVendoredBuildNeedsNoModuleCache(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	prog := writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker\"\nnya(whisker.loud(\"nya\"))\n")
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VendorModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "vendor", "example.com", "whisker", "whisker.go")); err != nil {
+		t.Fatalf("nothing vendored: %v", err)
+	}
+	// Vendoring again replaces what it wrote the first time.
+	if err := c.VendorModules(dir); err != nil {
+		t.Fatalf("vendoring again: %v", err)
+	}
+
+	t.Setenv("GOMODCACHE", t.TempDir())
+	c.SetOffline(true)
+	if err := c.Build(prog, filepath.Join(dir, "cat")); err != nil {
+		t.Fatalf("vendored build failed: %v", err)
+	}
+}
+
+func TestVendoringLeavesAVendorDirectoryItDidNotWrite(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	writeProgram(t, dir, "cat.nyan", "nab go \"example.com/whisker\"\nnya(whisker.loud(\"nya\"))\n")
+	c := compiler.New(nil)
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	// The vendor directory of the Go module the project sits in.
+	theirs := filepath.Join(dir, "vendor", "modules.txt")
+	if err := os.MkdirAll(filepath.Dir(theirs), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(theirs, []byte("# example.com/purr v1.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := c.VendorModules(dir)
+	if err == nil || !strings.Contains(err.Error(), "not written by meow mod vendor") {
+		t.Errorf("VendorModules = %v, want it refuses to replace vendor/", err)
+	}
+	if data, _ := os.ReadFile(theirs); string(data) != "# example.com/purr v1.0.0\n" {
+		t.Errorf("modules.txt = %q, want it left as it was", data)
+	}
+}
+
+func TestALockReadsBackAsItWasWritten(t *testing.T) {
+	lock := &compiler.Lock{
+		Imports: []string{"strings", "example.com/whisker"},
+		Pins:    map[string]string{"example.com/whisker": "v1.0.0"},
+		Modules: map[string]string{"example.com/whisker": "v1.0.0"},
+		Sums:    []string{"example.com/whisker v1.0.0 h1:abc=", "example.com/whisker v1.0.0/go.mod h1:def="},
+	}
+	path := filepath.Join(t.TempDir(), compiler.LockFile)
+	if err := os.WriteFile(path, []byte(lock.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	back, err := compiler.ReadLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.String() != lock.String() {
+		t.Errorf("read back as\n%s\nwant\n%s", back, lock)
+	}
+}
@@ -220,3 +220,40 @@ func TestGoVersionFor(t *testing.T) {
 		}
 	})
 }
+
+func TestLibPackageName(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"pricing", "pricing"},
+		{"Pricing-Rules", "pricingrules"},
+		{"tax_v2", "tax_v2"},
+		{"2fast", ""},
+		{"type", ""},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := libPackageName(tt.dir); got != tt.want {
+			t.Errorf("libPackageName(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestLibModSnippet(t *testing.T) {
+	got, err := libModSnippet("pricing", "/src/meow", map[string]string{
+		"github.com/google/uuid": "v1.6.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"require " + meowModulePath + " v0.0.0",
+		`replace ` + meowModulePath + ` => "/src/meow"`,
+		"go get github.com/google/uuid@v1.6.0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
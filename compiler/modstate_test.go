@@ -0,0 +1,37 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTidyingAndVendoringLeaveTheBuildsStateBe(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cat.nyan"), []byte("nya(\"nya\")\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := New(nil)
+	built := &Lock{}
+	c.lock, c.lockDir = built, "/elsewhere"
+	c.goPins, c.goPaths = map[string]string{"example.com/whisker": "v1.0.0"}, []string{"example.com/whisker"}
+	unchanged := func(after string) {
+		t.Helper()
+		if c.lock != built || c.lockDir != "/elsewhere" {
+			t.Errorf("after %s, lock = %p from %q; want the build's own back", after, c.lock, c.lockDir)
+		}
+		if c.goPins["example.com/whisker"] != "v1.0.0" || !slices.Equal(c.goPaths, []string{"example.com/whisker"}) {
+			t.Errorf("after %s, pins = %v and paths = %v; want the build's own back", after, c.goPins, c.goPaths)
+		}
+	}
+
+	if err := c.TidyModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	unchanged("TidyModules")
+	if err := c.VendorModules(dir); err != nil {
+		t.Fatal(err)
+	}
+	unchanged("VendorModules")
+}
@@ -0,0 +1,235 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/mutation"
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+// MutationOptions are how RunMutationTest tests mutants, as `meow test -mutate`
+// was asked to.
+type MutationOptions struct {
+	// Workers is how many mutants are tested at once; 0 is one for each CPU.
+	Workers int
+	// NoCache tests every mutant again, rather than give back the results of
+	// a run over the same source and tests from the cache.
+	NoCache bool
+	// Reports are the reports FinishMutationTests writes besides the text
+	// one: "html" for mutation.html, "json" for mutation.json.
+	Reports []string
+	// Threshold is the mutation score, in percent, below which
+	// FinishMutationTests fails; 0 is no threshold.
+	Threshold float64
+}
+
+// mutationReports are the reports there are, and the files they are
+// written to.
+var mutationReports = map[string]struct {
+	path  string
+	write func(io.Writer, []mutation.FileResult) error
+}{
+	"html": {"mutation.html", mutation.WriteHTML},
+	"json": {"mutation.json", mutation.WriteJSON},
+}
+
+// SetMutationOptions sets how RunMutationTest tests mutants from now on. A
+// report there is none of is an error.
+func (c *Compiler) SetMutationOptions(opts MutationOptions) error {
+	for _, r := range opts.Reports {
+		if _, ok := mutationReports[r]; !ok {
+			return fmt.Errorf("Hiss! -report wants html or json, not %q, nya~", r)
+		}
+	}
+	c.mutationOptions = opts
+	return nil
+}
+
+func (c *Compiler) recordMutationFile(path string, source []byte, mutants []mutation.Mutant, results []mutation.RunResult) {
+	c.mutationFiles = append(c.mutationFiles, mutation.FileResult{
+		Path:    path,
+		Source:  source,
+		Mutants: mutants,
+		Results: results,
+	})
+}
+
+// FinishMutationTests writes the reports asked for of every source file
+// RunMutationTest has tested the mutants of, and holds their score up to the
+// threshold: a score below it is an error, for CI to fail on.
+func (c *Compiler) FinishMutationTests() error {
+	files := c.mutationFiles
+	for _, r := range c.mutationOptions.Reports {
+		report := mutationReports[r]
+		if err := writeMutationReport(report.path, files, report.write); err != nil {
+			return err
+		}
+		fmt.Printf("Mutation report written to %s, nya~\n", report.path)
+	}
+	if t := c.mutationOptions.Threshold; t > 0 {
+		if score := mutation.Score(files); score < t {
+			return fmt.Errorf("Hiss! Mutation score %.1f%% is below the threshold of %g%%, nya~", score, t)
+		}
+	}
+	return nil
+}
+
+func writeMutationReport(path string, files []mutation.FileResult, write func(io.Writer, []mutation.FileResult) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	if err := write(f, files); err != nil {
+		f.Close()
+		return fmt.Errorf("Hiss! Cannot write %s, nya~: %w", path, err)
+	}
+	return f.Close()
+}
+
+// mutantTimeout is how long the tests of one mutant may run before it counts
+// as killed: a mutant that has a loop run forever is caught that way.
+const mutantTimeout = 10 * time.Second
+
+// testNames are the test_ and catwalk_ functions of progs, which the test
+// binary runs by those names.
+func testNames(progs []*ast.Program) []string {
+	var names []string
+	for _, prog := range progs {
+		for _, stmt := range prog.Stmts {
+			fn, ok := stmt.(*ast.FuncStmt)
+			if ok && (strings.HasPrefix(fn.Name, "test_") || strings.HasPrefix(fn.Name, "catwalk_")) {
+				names = append(names, fn.Name)
+			}
+		}
+	}
+	return names
+}
+
+// mutationCoverage runs each test of the mutation binary bin on its own, with
+// no mutant active, and puts together which of them reach what. A test that
+// fails that way is an error: a mutant could not be told to have killed it.
+func mutationCoverage(bin, dir string, tests []string, workers int) (*mutation.Coverage, error) {
+	cov := &mutation.Coverage{}
+	var mu sync.Mutex
+	failed := make([]error, len(tests))
+	mutation.Each(len(tests), workers, func(i int) {
+		profile := filepath.Join(dir, fmt.Sprintf("cover-%d.out", i))
+		cmd := exec.Command(bin)
+		cmd.Env = append(os.Environ(),
+			"MEOW_COVERPROFILE="+profile,
+			"MEOW_TEST_RUN="+mutation.OnlyTests(tests[i:i+1]),
+		)
+		var out strings.Builder
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		timer := time.AfterFunc(mutantTimeout, func() { cmd.Process.Kill() })
+		err := cmd.Run()
+		timer.Stop()
+		if err != nil {
+			failed[i] = fmt.Errorf("Hiss! %s fails before any mutant is made, nya~\n%s", tests[i], strings.TrimRight(out.String(), "\n"))
+			return
+		}
+		f, err := os.Open(profile)
+		if err != nil {
+			failed[i] = fmt.Errorf("Hiss! Cannot read the coverage of %s, nya~: %w", tests[i], err)
+			return
+		}
+		defer f.Close()
+		blocks, err := coverage.ParseProfile(f)
+		if err != nil {
+			failed[i] = fmt.Errorf("Hiss! Cannot read the coverage of %s, nya~: %w", tests[i], err)
+			return
+		}
+		mu.Lock()
+		cov.Add(tests[i], blocks)
+		mu.Unlock()
+	})
+	for _, err := range failed {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cov, nil
+}
+
+// runMutants tests mutants with runner, other than those cache has the result
+// of already under base, by the code around them and the tests that reach them; see
+// mutation.MutantKeys. What it tests, it adds to the cache.
+func (c *Compiler) runMutants(runner *mutation.Runner, cache *mutation.Cache, base string, source mutation.File, tests []mutation.File, mutants []mutation.Mutant) []mutation.RunResult {
+	if cache == nil {
+		return runner.RunAll(mutants)
+	}
+	keys := mutation.MutantKeys(base, source, tests, runner.Coverage, mutants)
+	results := make([]mutation.RunResult, len(mutants))
+	var run []mutation.Mutant
+	var at []int
+	for i, m := range mutants {
+		if status, ok := cache.LoadMutant(keys[i]); ok {
+			results[i] = mutation.RunResult{ID: m.ID, Status: status}
+			continue
+		}
+		run = append(run, m)
+		at = append(at, i)
+	}
+	if cached := len(mutants) - len(run); cached > 0 {
+		fmt.Printf("%d of %d mutants unchanged since the last run; their results are from the cache, nya~\n", cached, len(mutants))
+	}
+	for j, r := range runner.RunAll(run) {
+		i := at[j]
+		results[i] = r
+		if err := cache.StoreMutant(keys[i], r.Status); err != nil {
+			c.logger.Debug("cannot cache mutation result", "err", err)
+		}
+	}
+	return results
+}
+
+// buildInputs are what a program is built from besides its own source: the
+// meow.lock and the project pins that settle the versions of the Go packages
+// it fetches.
+func (c *Compiler) buildInputs() [][]byte {
+	var inputs [][]byte
+	if c.lock != nil {
+		inputs = append(inputs, []byte(c.lock.String()))
+	}
+	pins := make([]string, 0, len(c.projectPins))
+	for path, version := range c.projectPins {
+		pins = append(pins, path+"@"+version)
+	}
+	sort.Strings(pins)
+	return append(inputs, []byte(strings.Join(pins, "\n")))
+}
+
+// toolID tells this meow apart from others, for results that depend on the
+// compiler they were had with: its version, or, for a build with none, a
+// hash of its binary.
+var toolID = sync.OnceValue(func() string {
+	if v, ok := runtimeRequirement(); ok {
+		return v
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return Version
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return Version
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Version
+	}
+	return hex.EncodeToString(h.Sum(nil))
+})
@@ -0,0 +1,246 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestFileResult is how the tests of one file went, as RunTestsParallel hands
+// it over.
+type TestFileResult struct {
+	Path string
+	// Output is everything the file's tests wrote, stdout and stderr
+	// together. Under SetTestEvents it is empty: it went as events.
+	Output []byte
+	// Err is what RunTest would have answered for the file.
+	Err error
+
+	events []TestEvent
+}
+
+// RunTestsParallel builds the test binaries of files together and runs them,
+// p at a time. Each file's result is handed to each once it and every file
+// before it are done, so what is printed reads in the order the files were
+// given, however they finished; each answering false starts no more files.
+// Under SetTestEvents, a file's events are sent just after each is handed
+// its result, and never two files' at once.
+//
+// Building the files one after another spent most of a large suite's time in
+// go build starting over, on the same runtime, for every file. Here every file
+// that can share a Go module with the others is written into one, as a main
+// package of its own, and one go build builds them all; see BuildTests.
+func (c *Compiler) RunTestsParallel(ctx context.Context, files []string, p int, each func(TestFileResult) bool) {
+	if p < 1 {
+		p = 1
+	}
+	outDir, err := os.MkdirTemp("", "meow-test-bins-*")
+	if err != nil {
+		for _, f := range files {
+			if !each(TestFileResult{Path: f, Err: fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)}) {
+				return
+			}
+		}
+		return
+	}
+	defer os.RemoveAll(outDir)
+
+	restore := c.runningUnder(ctx)
+	bins, buildErrs := c.BuildTests(files, outDir, p)
+	restore()
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	done := make([]chan TestFileResult, len(files))
+	slots := make(chan struct{}, p)
+	var wg sync.WaitGroup
+	for i, f := range files {
+		done[i] = make(chan TestFileResult, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				done[i] <- TestFileResult{Path: f, Err: ctx.Err()}
+				return
+			}
+			defer func() { <-slots }()
+			done[i] <- c.runBuiltTest(ctx, f, bins[f], buildErrs[f])
+		}()
+	}
+	for i := range files {
+		r := <-done[i]
+		if ctx.Err() != nil {
+			break
+		}
+		more := each(r)
+		for _, e := range r.events {
+			c.testEvents(e)
+		}
+		if !more {
+			break
+		}
+	}
+	stop()
+	wg.Wait()
+}
+
+// runBuiltTest runs the binary BuildTests made of a file, or says why there
+// is none, with what it writes kept to be handed over whole.
+func (c *Compiler) runBuiltTest(ctx context.Context, nyanPath, bin string, buildErr error) TestFileResult {
+	r := TestFileResult{Path: nyanPath}
+	if c.testEvents == nil {
+		var out bytes.Buffer
+		r.Err = buildErr
+		if r.Err == nil {
+			r.Err = c.execTest(ctx, nyanPath, bin, &out, &out)
+		}
+		r.Output = out.Bytes()
+		return r
+	}
+
+	// The events wait with the rest of the file's result, so that each
+	// file's events arrive together, and in the order of the files.
+	events := &eventStream{pkg: nyanPath, emit: func(e TestEvent) { r.events = append(r.events, e) }, decode: decodeMeowEvent}
+	began := time.Now()
+	events.start()
+	r.Err = buildErr
+	if r.Err == nil {
+		r.Err = c.execTest(ctx, nyanPath, bin, events, events)
+	}
+	events.done(began, r.Err)
+	return r
+}
+
+// BuildTests builds the test binaries of files into outDir, with go build
+// running p jobs at a time, and answers where each file's binary is, or why
+// it has none.
+//
+// Files share a Go module when what they need of one is the same: those under
+// one meow.lock are built from that lock, and those that fetch no Go packages
+// need nothing but the runtime. A file that fetches Go packages without a
+// lock has its versions settled for it alone, as they would be for meow build,
+// and so gets a module to itself.
+func (c *Compiler) BuildTests(files []string, outDir string, p int) (bins map[string]string, errs map[string]error) {
+	bins = make(map[string]string)
+	errs = make(map[string]error)
+
+	type module struct {
+		dir         string
+		lock        *Lock
+		lockDir     string
+		goPins      map[string]string
+		goPaths     []string
+		files, pkgs []string
+	}
+	var modules []*module
+	byKey := make(map[string]*module)
+	defer func() {
+		for _, m := range modules {
+			os.RemoveAll(m.dir)
+		}
+	}()
+
+	for i, f := range files {
+		goCode, err := c.compileTestFile(f)
+		if err != nil {
+			errs[f] = err
+			continue
+		}
+		key := ""
+		switch {
+		case c.lock != nil:
+			if err := c.checkAgainstLock(); err != nil {
+				errs[f] = err
+				continue
+			}
+			key = "lock " + c.lockDir
+		case len(c.goPaths) > 0:
+			key = "own " + f
+		}
+		m := byKey[key]
+		if m == nil {
+			dir, err := os.MkdirTemp("", "meow-test-build-*")
+			if err != nil {
+				errs[f] = fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+				continue
+			}
+			m = &module{dir: dir, lock: c.lock, lockDir: c.lockDir}
+			if c.lock == nil {
+				m.goPins, m.goPaths = c.goPins, c.goPaths
+			}
+			byKey[key] = m
+			modules = append(modules, m)
+		}
+		pkg := "t" + strconv.Itoa(i)
+		if err := os.Mkdir(filepath.Join(m.dir, pkg), 0o755); err != nil {
+			errs[f] = fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(m.dir, pkg, "main.go"), []byte(goCode), 0o644); err != nil {
+			errs[f] = fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
+			continue
+		}
+		m.files = append(m.files, f)
+		m.pkgs = append(m.pkgs, pkg)
+	}
+
+	for n, m := range modules {
+		// The module is set up as the compiler would have set it up for its
+		// files one at a time; they have been checked against it already.
+		c.lock, c.lockDir, c.goPins, c.goPaths = m.lock, m.lockDir, m.goPins, m.goPaths
+		if err := c.prepareModule(m.dir); err != nil {
+			for _, f := range m.files {
+				errs[f] = err
+			}
+			continue
+		}
+		binDir := filepath.Join(outDir, "m"+strconv.Itoa(n))
+		c.logger.Debug("building tests", "files", len(m.files), "dir", m.dir)
+		build := c.goCmd(m.dir, "build", "-p", strconv.Itoa(p), "-o", binDir+string(filepath.Separator), "./...")
+		var stderr bytes.Buffer
+		build.Stderr = &stderr
+		if err := build.Run(); err == nil {
+			for i, f := range m.files {
+				bins[f] = filepath.Join(binDir, m.pkgs[i])
+			}
+			continue
+		}
+		// go build says which packages failed, but in the names of the
+		// module's packages rather than the files; building each alone puts
+		// each failure with its own file, and what go build said of it in
+		// the file's error, where -json and -junit see it too.
+		for i, f := range m.files {
+			bin := filepath.Join(binDir, m.pkgs[i])
+			one := c.goCmd(m.dir, "build", "-o", bin, "./"+m.pkgs[i])
+			var stderr bytes.Buffer
+			one.Stderr = &stderr
+			if err := one.Run(); err != nil {
+				errs[f] = fmt.Errorf("Hiss! go build failed, nya~: %w\n%s", err, strings.TrimRight(stderr.String(), "\n"))
+				continue
+			}
+			bins[f] = bin
+		}
+	}
+	return bins, errs
+}
+
+// compileTestFile compiles a test file, with its companion source, to the Go
+// of its test binary, leaving the compiler set up for the module it needs.
+func (c *Compiler) compileTestFile(nyanPath string) (string, error) {
+	if err := c.useProjectFor(nyanPath); err != nil {
+		return "", err
+	}
+	files, err := c.readTestSources(nyanPath)
+	if err != nil {
+		return "", err
+	}
+	return c.compileTestSources(files)
+}
@@ -0,0 +1,122 @@
+package compiler_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+func TestRunTestsParallelGivesEachFileInOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeProgram(t, dir, "a_test.nyan", "meow test_a() {\n  nya(\"from a\")\n  expect(1, 1)\n}\n"),
+		writeProgram(t, dir, "b_test.nyan", "meow test_b() {\n  expect(1, 2)\n}\n"),
+		writeProgram(t, dir, "c_test.nyan", "meow test_c() {\n  expect(missing, 1)\n}\n"),
+		writeProgram(t, dir, "d_test.nyan", "meow test_d() {\n  nya(\"from d\")\n  expect(2, 2)\n}\n"),
+	}
+
+	var got []string
+	var errs []error
+	compiler.New(nil).RunTestsParallel(context.Background(), files, 3, func(r compiler.TestFileResult) bool {
+		got = append(got, filepath.Base(r.Path))
+		errs = append(errs, r.Err)
+		if (r.Err == nil) != strings.Contains(string(r.Output), "passed, nya~!") {
+			t.Errorf("%s: err %v with output %q", r.Path, r.Err, r.Output)
+		}
+		return true
+	})
+	if !slices.Equal(got, []string{"a_test.nyan", "b_test.nyan", "c_test.nyan", "d_test.nyan"}) {
+		t.Fatalf("files came back as %v", got)
+	}
+	var failed *compiler.TestsFailed
+	if errs[0] != nil || !errors.As(errs[1], &failed) || errs[2] == nil || errors.As(errs[2], &failed) || errs[3] != nil {
+		t.Errorf("errors = %v; want b's tests failed and c not compiled", errs)
+	}
+}
+
+func TestAFileThatDoesNotBuildSaysWhy(t *testing.T) {
+	fakeProxy(t)
+	dir := t.TempDir()
+	files := []string{
+		writeProgram(t, dir, "a_test.nyan", "meow test_a() {\n  expect(1, 1)\n}\n"),
+		writeProgram(t, dir, "b_test.nyan", "nab go \"example.com/broken\"\n\nmeow test_b() {\n  expect(broken.purr(), \"purr\")\n}\n"),
+	}
+	errs := make(map[string]error)
+	compiler.New(nil).RunTestsParallel(context.Background(), files, 2, func(r compiler.TestFileResult) bool {
+		errs[filepath.Base(r.Path)] = r.Err
+		return true
+	})
+	if errs["a_test.nyan"] != nil {
+		t.Errorf("a_test.nyan: %v, want it to pass", errs["a_test.nyan"])
+	}
+	// What go build said is the file's error, not only that it failed.
+	if err := errs["b_test.nyan"]; err == nil || !strings.Contains(err.Error(), "broken.go") {
+		t.Errorf("b_test.nyan: %v, want the compiler's error in it", err)
+	}
+}
+
+func TestRunTestsParallelStopsWhenAsked(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a_test.nyan", "b_test.nyan", "c_test.nyan"} {
+		files = append(files, writeProgram(t, dir, name, "meow test_x() {\n  expect(1, 2)\n}\n"))
+	}
+	n := 0
+	compiler.New(nil).RunTestsParallel(context.Background(), files, 2, func(compiler.TestFileResult) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("each was called %d times after asking to stop", n)
+	}
+}
+
+func TestParallelTestsKeepTheirOutputTogether(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "p_test.nyan", `nya("top level")
+
+meow test_first() {
+  parallel()
+  nya("first 1")
+  nya("first 2")
+  expect(1, 1)
+}
+
+meow test_second() {
+  parallel()
+  nya("second 1")
+  nya("second 2")
+  expect(1, 2)
+}
+`)
+	var out strings.Builder
+	c := compiler.New(nil)
+	c.SetTestEvents(func(e compiler.TestEvent) {
+		if e.Action == "output" {
+			out.WriteString(e.Output)
+		}
+	})
+	err := c.RunTest(filepath.Join(dir, "p_test.nyan"))
+	var failed *compiler.TestsFailed
+	if !errors.As(err, &failed) {
+		t.Fatalf("RunTest = %v, want test_second failed", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"first 1\nfirst 2\n  PASS: test_first\n",
+		"second 1\nsecond 2\n  FAIL: test_second",
+		"1 passed, 1 failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+	if strings.Count(got, "top level") != 1 {
+		t.Errorf("output = %q, want the top level's output once", got)
+	}
+}
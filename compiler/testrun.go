@@ -0,0 +1,97 @@
+package compiler
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestOptions are how RunTest runs the tests of a file, as `meow test` was
+// asked to.
+type TestOptions struct {
+	// Run is a regular expression a test_ or catwalk_ function's name has to
+	// match to run. Empty runs them all.
+	Run string
+	// Verbose announces each test as it starts and gives how long it took.
+	Verbose bool
+	// Timeout is how long a single test may run before the file's tests are
+	// stopped and it is reported as hung. 0 is no limit.
+	Timeout time.Duration
+	// FailFast stops at the first test that fails.
+	FailFast bool
+	// Shuffle runs the tests in an order made from Seed. Given the same
+	// seed, the order is the same again, which is what makes a failure that
+	// depends on order possible to look into.
+	Shuffle bool
+	Seed    int64
+	// Update has snapshot tests write what they are given as their
+	// snapshots, rather than compare it with them.
+	Update bool
+	// Color colors the diffs of expect's failures, for output that goes to
+	// a terminal.
+	Color bool
+}
+
+// SnapshotDir is where, beside a test file, its snapshot tests keep their
+// snapshots, in a directory named for the file.
+const SnapshotDir = "testdata/__snapshots__"
+
+// SetTestOptions sets how RunTest runs tests from now on.
+func (c *Compiler) SetTestOptions(opts TestOptions) error {
+	if opts.Run != "" {
+		if _, err := regexp.Compile(opts.Run); err != nil {
+			return fmt.Errorf("Hiss! -run %q is not a regular expression, nya~: %w", opts.Run, err)
+		}
+	}
+	if opts.Timeout < 0 {
+		return fmt.Errorf("Hiss! -timeout %s is less than nothing, nya~", opts.Timeout)
+	}
+	c.testOptions = opts
+	return nil
+}
+
+// testEnv is the environment that tells the test binary of nyanPath how to
+// run its tests; runtime/testing reads it back.
+func (c *Compiler) testEnv(nyanPath string) []string {
+	opts := c.testOptions
+	var env []string
+	// The binary runs wherever meow test was run, and a file's snapshots are
+	// beside the file.
+	if abs, err := filepath.Abs(nyanPath); err == nil {
+		env = append(env, "MEOW_TEST_SNAPSHOTS="+filepath.Join(filepath.Dir(abs), SnapshotDir))
+	}
+	env = append(env, "MEOW_TEST_FILE="+strings.TrimSuffix(filepath.Base(nyanPath), ".nyan"))
+	if opts.Update {
+		env = append(env, "MEOW_TEST_UPDATE=1")
+	}
+	if c.coverProfile != "" {
+		env = append(env, "MEOW_COVERPROFILE="+c.coverProfile)
+	}
+	if c.coverEnabled && c.coverMode != "" {
+		env = append(env, "MEOW_COVERMODE="+c.coverMode)
+	}
+	if opts.Run != "" {
+		env = append(env, "MEOW_TEST_RUN="+opts.Run)
+	}
+	if opts.Verbose {
+		env = append(env, "MEOW_TEST_VERBOSE=1")
+	}
+	if opts.Timeout > 0 {
+		env = append(env, "MEOW_TEST_TIMEOUT="+opts.Timeout.String())
+	}
+	if opts.FailFast {
+		env = append(env, "MEOW_TEST_FAILFAST=1")
+	}
+	if opts.Shuffle {
+		env = append(env, "MEOW_TEST_SHUFFLE="+strconv.FormatInt(opts.Seed, 10))
+	}
+	if c.testEvents != nil {
+		env = append(env, "MEOW_TEST_JSON=1")
+	} else if opts.Color {
+		env = append(env, "MEOW_TEST_COLOR=1")
+	}
+	return env
+}
@@ -0,0 +1,60 @@
+package compiler_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/compiler"
+)
+
+const hungTests = `meow test_quick() {
+  expect(1 + 1, 2)
+}
+
+meow test_broken() {
+  expect(1 + 1, 3)
+}
+
+meow test_hang() {
+  purr (yarn) {
+    nyan spin = 1 + 1
+  }
+}
+`
+
+func TestRunPicksWhichTestsRun(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "hang_test.nyan", hungTests)
+	c := compiler.New(nil)
+	if err := c.SetTestOptions(compiler.TestOptions{Run: "quick$"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RunTest(filepath.Join(dir, "hang_test.nyan")); err != nil {
+		t.Errorf("RunTest = %v, want only the passing test run", err)
+	}
+}
+
+func TestAHungTestIsStoppedByTheTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeProgram(t, dir, "hang_test.nyan", hungTests)
+	c := compiler.New(nil)
+	if err := c.SetTestOptions(compiler.TestOptions{Run: "hang", Timeout: 200 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.RunTest(filepath.Join(dir, "hang_test.nyan"))
+	var failed *compiler.TestsFailed
+	if !errors.As(err, &failed) {
+		t.Errorf("RunTest = %v, want the hung test failed", err)
+	}
+}
+
+func TestTestOptionsAreChecked(t *testing.T) {
+	c := compiler.New(nil)
+	err := c.SetTestOptions(compiler.TestOptions{Run: "test_(add"})
+	if err == nil || !strings.Contains(err.Error(), "is not a regular expression") {
+		t.Errorf("SetTestOptions = %v, want the bad -run refused", err)
+	}
+}
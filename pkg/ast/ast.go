@@ -368,6 +368,36 @@ func (n *FetchStmt) Name() string {
 	return GoPackageName(n.Path)
 }
 
+// GoName spells a name the way Go writes it: new_from_config becomes
+// NewFromConfig, which is how a Meow program says a Go name in its own words.
+//
+// A name already written in Go's own spelling is left alone, which is the way
+// out for the ones this cannot reach — ParseURL is not what parse_url spells,
+// so it is written as ParseURL.
+func GoName(member string) string {
+	if member == "" {
+		return member
+	}
+	if r := rune(member[0]); r >= 'A' && r <= 'Z' {
+		return member
+	}
+	var b strings.Builder
+	up := true
+	for _, r := range member {
+		if r == '_' {
+			up = true
+			continue
+		}
+		if up {
+			b.WriteString(strings.ToUpper(string(r)))
+			up = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // GoPackageName reads the name a Go import path is known by, the way Go itself
 // does: the last element, except that a major-version element belongs to the
 // module rather than the package.
@@ -650,8 +680,25 @@ type LearnStmt struct {
 	TypeName string
 	// Methods is the list of method definitions.
 	Methods []FuncStmt
+	// As lists the Go interfaces the type is groomed as, written
+	// `groom Logger as io.Writer { ... }`. A kitty handed to a Go function
+	// that asks for one of them goes as an adapter calling these methods.
+	As []GoTypeRef
+}
+
+// GoTypeRef names a type exported by a `nab go` package, as pkg.Name.
+type GoTypeRef struct {
+	// Token is the package name token.
+	Token token.Token
+	// Package is what the program calls the package by.
+	Package string
+	// Name is the type's name in the package.
+	Name string
 }
 
+// String renders the reference the way it is written.
+func (r GoTypeRef) String() string { return r.Package + "." + r.Name }
+
 func (n *LearnStmt) Pos() token.Position { return n.Token.Pos }
 func (n *LearnStmt) nodeTag()            {}
 func (n *LearnStmt) stmtTag()            {}
@@ -12,6 +12,9 @@ type TypeExpr interface {
 type BasicType struct {
 	Token token.Token
 	Name  string // "int", "float", "string", "bool", "furball", "litter"
+	// Elem is what a litter or basket holds, when the annotation says
+	// (litter[int]); nil for one of anything.
+	Elem TypeExpr
 }
 
 func (n *BasicType) Pos() token.Position { return n.Token.Pos }
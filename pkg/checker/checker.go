@@ -2,6 +2,7 @@ package checker
 
 import (
 	"fmt"
+	gotypes "go/types"
 
 	"github.com/135yshr/meow/pkg/ast"
 	"github.com/135yshr/meow/pkg/token"
@@ -71,6 +72,12 @@ type Checker struct {
 	// loop outside is not one the body can bolt from — Go would reject the
 	// generated break, and the interpreter would unwind past the loop.
 	loopDepth int
+	// goImports names the imports written `nab go`, the only packages a
+	// groom's `as` can name an interface from.
+	goImports map[string]bool
+	// goPackages holds the exported API of the `nab go` packages, by import
+	// path, for the ones that could be read; see SetGoPackages.
+	goPackages map[string]*gotypes.Package
 }
 
 // enterLoop counts a loop for bolt and slink, returning a function that
@@ -99,6 +106,14 @@ func New() *Checker {
 	return c
 }
 
+// Declare makes a name known before the program is checked, for a program that
+// runs alongside names it does not write itself — the functions and values a
+// Go program embedding the interpreter hands it. What they are is up to the
+// host, so they are checked as any.
+func (c *Checker) Declare(name string) {
+	c.scopes[0][name] = types.AnyType{}
+}
+
 func (c *Checker) pushScope() {
 	c.scopes = append(c.scopes, make(map[string]types.Type))
 }
@@ -206,9 +221,13 @@ var builtinNames = map[string]bool{
 	"upper": true, "lower": true, "trim": true, "replace": true, "pad": true,
 	"sort": true, "reverse": true, "round": true,
 	"scram": true,
-	"judge": true, "expect": true, "refuse": true, "seed": true,
+	"judge": true, "expect": true, "refuse": true, "seed": true, "parallel": true,
 }
 
+// BenchType is the type of the handle a bench_ function is given:
+// meow bench_sort(b Bench) { ... }.
+const BenchType = "Bench"
+
 func (c *Checker) addError(pos token.Position, format string, args ...any) {
 	c.errors = append(c.errors, &TypeError{
 		Pos:     pos,
@@ -248,6 +267,12 @@ func (c *Checker) Check(prog *ast.Program) (*TypeInfo, []*TypeError) {
 			} else {
 				c.info.ImportNames[effectiveName] = fs.Path
 			}
+			if fs.Go {
+				if c.goImports == nil {
+					c.goImports = make(map[string]bool)
+				}
+				c.goImports[effectiveName] = true
+			}
 		}
 	}
 
@@ -437,9 +462,9 @@ func (c *Checker) resolveTypeExpr(te ast.TypeExpr) types.Type {
 		case "furball":
 			return types.FurballType{}
 		case "litter":
-			return types.ListType{Elem: types.AnyType{}}
+			return types.ListType{Elem: c.resolveTypeExpr(t.Elem)}
 		case "basket":
-			return types.MapType{Val: types.AnyType{}}
+			return types.MapType{Val: c.resolveTypeExpr(t.Elem)}
 		default:
 			return types.AnyType{}
 		}
@@ -453,6 +478,11 @@ func (c *Checker) resolveTypeExpr(te ast.TypeExpr) types.Type {
 		if kt, ok := c.info.KittyTypes[t.Name]; ok {
 			return kt
 		}
+		if t.Name == BenchType {
+			// The handle is made by the test runtime rather than declared
+			// by the program, and its members are looked up as it runs.
+			return types.AnyType{}
+		}
 		c.addError(t.Token.Pos, "Unknown type %s", t.Name)
 		return types.AnyType{}
 	default:
@@ -495,6 +525,30 @@ func (c *Checker) checkStmt(stmt ast.Stmt) {
 	}
 }
 
+// checkGroomedAs checks the Go interfaces a groom names. Whether the methods
+// written answer the interface's is a question about the Go package, which
+// is asked where the package can be read: when the program is compiled.
+func (c *Checker) checkGroomedAs(s *ast.LearnStmt, isKitty bool) {
+	if len(s.As) == 0 {
+		return
+	}
+	if !isKitty {
+		c.addError(s.Token.Pos, "only a kitty can be groomed as a Go interface, and %s is a collar", s.TypeName)
+		return
+	}
+	seen := make(map[string]bool)
+	for _, ref := range s.As {
+		if !c.goImports[ref.Package] {
+			c.addError(ref.Token.Pos, "%s is not a package fetched with nab go", ref.Package)
+			continue
+		}
+		if seen[ref.String()] {
+			c.addError(ref.Token.Pos, "%s is groomed as %s twice", s.TypeName, ref)
+		}
+		seen[ref.String()] = true
+	}
+}
+
 func (c *Checker) checkLearnStmt(s *ast.LearnStmt) {
 	// Verify the target type exists
 	_, isKitty := c.info.KittyTypes[s.TypeName]
@@ -504,6 +558,8 @@ func (c *Checker) checkLearnStmt(s *ast.LearnStmt) {
 		return
 	}
 
+	c.checkGroomedAs(s, isKitty)
+
 	if c.info.LearnImpls[s.TypeName] == nil {
 		c.info.LearnImpls[s.TypeName] = make(map[string]types.FuncType)
 	}
@@ -511,9 +567,11 @@ func (c *Checker) checkLearnStmt(s *ast.LearnStmt) {
 	for i := range s.Methods {
 		m := &s.Methods[i]
 
-		// Mirror function-level signature checks
+		// Mirror function-level signature checks. A groom answering a Go
+		// interface is told what its methods take by Go's signatures, and
+		// what Go hands it is often a handle Meow has no type to write for.
 		for _, p := range m.Params {
-			if p.TypeAnn == nil {
+			if p.TypeAnn == nil && len(s.As) == 0 {
 				c.addError(m.Token.Pos, "Parameter %q of method %s must have a type annotation", p.Name, m.Name)
 			}
 		}
@@ -1018,7 +1076,11 @@ func (c *Checker) checkRangeStmt(s *ast.RangeStmt) {
 	// is rejected even where the subject's type is unknown — accepting it there
 	// would leave a program that binds two variables when it turns out to be a
 	// litter and one when it turns out to be a number.
-	if s.IndexVar != "" && !isListRange && !isMapRange {
+	//
+	// A Go sequence has its pair, as a litter does, though nothing else about
+	// it is known.
+	_, isGoSequence := endType.(types.GoSequenceType)
+	if s.IndexVar != "" && !isListRange && !isMapRange && !isGoSequence {
 		c.addError(s.Token.Pos, "Two-variable form is only allowed for litter or basket iteration")
 	}
 	c.pushScope()
@@ -1419,6 +1481,12 @@ func (c *Checker) inferCall(e *ast.CallExpr) types.Type {
 
 	// Handle member call (e.g. c.show())
 	if member, ok := e.Fn.(*ast.MemberExpr); ok {
+		if obj, ok := member.Object.(*ast.Ident); ok {
+			if pkg, ok := c.goPackage(obj.Name); ok {
+				c.inferExpr(member.Object)
+				return c.checkGoCall(e, obj.Name, member, pkg)
+			}
+		}
 		objType := types.Unwrap(c.inferExpr(member.Object))
 		typeName := ""
 		switch tt := objType.(type) {
@@ -174,6 +174,19 @@ nyan result = add(1, "two")
 	}
 }
 
+func TestLitterElemTypeMismatch(t *testing.T) {
+	_, errs := check(t, `
+meow total(xs litter[int]) int {
+  bring len(xs)
+}
+nyan a = total([1, 2])
+nyan b = total(["one"])
+`)
+	if len(errs) != 1 {
+		t.Fatalf("expected one type error, for the litter of strings, got %v", errs)
+	}
+}
+
 func TestInferFuncReturnType(t *testing.T) {
 	info, errs := check(t, `
 meow double(x int) int {
@@ -673,6 +686,46 @@ groom Cat {
 	}
 }
 
+func TestLearnAsGoInterface(t *testing.T) {
+	kitty := `
+nab go "io"
+kitty Logger {
+    prefix: string
+}
+collar Id = int
+`
+	tests := []struct {
+		name  string
+		groom string
+		want  string
+	}{
+		{"a nab go package", "groom Logger as io.Writer {\n}\n", ""},
+		{"a Meow package", "groom Logger as file.Writer {\n}\n", "file is not a package fetched with nab go"},
+		{"the same interface twice", "groom Logger as io.Writer, io.Writer {\n}\n", "Logger is groomed as io.Writer twice"},
+		{"a collar", "groom Id as io.Writer {\n}\n", "only a kitty can be groomed as a Go interface"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := check(t, kitty+tt.groom)
+			if tt.want == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if contains(e.Message, tt.want) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("errors = %v, want one saying %q", errs, tt.want)
+			}
+		})
+	}
+}
+
 func TestTrickSatisfaction(t *testing.T) {
 	// Cat has show() string, so it structurally satisfies Showable
 	info, errs := check(t, `
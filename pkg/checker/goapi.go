@@ -0,0 +1,355 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/importer"
+	gotoken "go/token"
+	gotypes "go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/types"
+)
+
+// A call into a `nab go` package goes through the bridge, which reads each
+// argument into what the Go function asks for and says so when it cannot. Left
+// at that, `url.parse(1)` or `strings.repeat("nya")` builds and then fails
+// as a furball the first time it runs. The package's exported API is known
+// before then — it is in the module cache, where the build will read it from —
+// so the checker reads it too and holds the call to it here, at the .nyan line
+// that made it.
+//
+// What the checker cannot read it does not guess at: a call on a package that
+// could not be loaded, on a generic function, or on something held from Go is
+// left to the bridge as before.
+
+// LoadGoPackages reads the exported API of the Go packages at paths with
+// go/types, from the export data `go list -export` leaves in the build cache.
+//
+// The packages are resolved in a module of their own, written for the purpose,
+// whose go directive is goVersion. resolve, when given, settles what that
+// module requires in place of `go mod tidy`, which is where a program's pins
+// and lock come in; the go commands run in env, or in the process's own
+// environment when env is nil.
+func LoadGoPackages(paths []string, goVersion string, resolve func(dir string) error, env []string) (map[string]*gotypes.Package, error) {
+	paths = slices.Clone(paths)
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	dir, err := os.MkdirTemp("", "meow-goapi-*")
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot create temp dir, nya~: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mod := fmt.Sprintf("module meow_goapi\n\ngo %s\n", goVersion)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot write go.mod, nya~: %w", err)
+	}
+	// The imports are what the module is resolved from.
+	var stub strings.Builder
+	stub.WriteString("package meow_goapi\n\n")
+	for _, p := range paths {
+		fmt.Fprintf(&stub, "import _ %q\n", p)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stub.go"), []byte(stub.String()), 0644); err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot write Go source, nya~: %w", err)
+	}
+	if resolve == nil {
+		resolve = func(dir string) error { return runGo(dir, env, "mod", "tidy") }
+	}
+	if err := resolve(dir); err != nil {
+		return nil, err
+	}
+
+	list := exec.Command("go", append([]string{"list", "-export", "-deps", "-f", "{{.ImportPath}}\t{{.Export}}"}, paths...)...)
+	list.Dir = dir
+	list.Env = env
+	var stderr bytes.Buffer
+	list.Stderr = &stderr
+	out, err := list.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w\n%s", strings.Join(paths, " "), err, stderr.String())
+	}
+	exports := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		path, file, _ := strings.Cut(sc.Text(), "\t")
+		exports[path] = file
+	}
+
+	imp := importer.ForCompiler(gotoken.NewFileSet(), "gc", func(path string) (io.ReadCloser, error) {
+		file := exports[path]
+		if file == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(file)
+	})
+	pkgs := make(map[string]*gotypes.Package, len(paths))
+	for _, p := range paths {
+		pkg, err := imp.Import(p)
+		if err != nil {
+			return nil, fmt.Errorf("Hiss! Cannot read %s, nya~: %w", p, err)
+		}
+		pkgs[p] = pkg
+	}
+	return pkgs, nil
+}
+
+func runGo(dir string, env []string, args ...string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Hiss! go %s failed, nya~: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return nil
+}
+
+// SetGoPackages gives the checker the exported API of the program's `nab go`
+// packages, by import path, so that calls into them are typed.
+func (c *Checker) SetGoPackages(pkgs map[string]*gotypes.Package) {
+	c.goPackages = pkgs
+}
+
+// goPackage finds the loaded Go package a name in the program refers to.
+func (c *Checker) goPackage(name string) (*gotypes.Package, bool) {
+	if !c.goImports[name] || c.bound(name) {
+		return nil, false
+	}
+	pkg, ok := c.goPackages[c.info.ImportNames[name]]
+	return pkg, ok && pkg != nil
+}
+
+// checkGoCall types a call into a Go package: the arguments against the
+// function's parameters, and the call as what the bridge reads its result as.
+func (c *Checker) checkGoCall(e *ast.CallExpr, pkgName string, member *ast.MemberExpr, pkg *gotypes.Package) types.Type {
+	name := ast.GoName(member.Member)
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil || !obj.Exported() {
+		if other := spelledAlike(pkg, name); other != "" {
+			c.addError(member.Token.Pos, "%s has no %s; Go calls it %s, so write %s.%s", pkgName, name, other, pkgName, other)
+		} else {
+			c.addError(member.Token.Pos, "%s has no %s", pkgName, name)
+		}
+		return types.AnyType{}
+	}
+	var sig *gotypes.Signature
+	switch o := obj.(type) {
+	case *gotypes.Func:
+		sig = o.Signature()
+	case *gotypes.Var:
+		sig, _ = o.Type().Underlying().(*gotypes.Signature)
+	}
+	// A type called as a conversion, or a generic function, is the bridge's
+	// to make sense of, as it was before there was any checking here.
+	if sig == nil || sig.TypeParams().Len() > 0 {
+		return types.AnyType{}
+	}
+
+	what := pkgName + "." + member.Member
+	params := sig.Params()
+	first := 0
+	// The bridge hands a function its context itself.
+	if params.Len() > 0 && isContext(params.At(0).Type()) {
+		first = 1
+	}
+	wanted := params.Len() - first
+	switch {
+	case sig.Variadic() && len(e.Args) < wanted-1:
+		c.addError(e.Token.Pos, "%s expects at least %d arguments but got %d", what, wanted-1, len(e.Args))
+		return goResultType(sig)
+	case !sig.Variadic() && len(e.Args) != wanted:
+		c.addError(e.Token.Pos, "%s expects %d arguments but got %d", what, wanted, len(e.Args))
+		return goResultType(sig)
+	}
+	for i, arg := range e.Args {
+		pos := first + i
+		var pt gotypes.Type
+		if sig.Variadic() && pos >= params.Len()-1 {
+			pt = params.At(params.Len() - 1).Type().(*gotypes.Slice).Elem()
+		} else {
+			pt = params.At(pos).Type()
+		}
+		argType := c.info.ExprTypes[arg]
+		if argType != nil && !goAccepts(pt, argType) {
+			c.addError(e.Token.Pos, "Argument %d for %s: expected %s but got %s",
+				i+1, what, gotypes.TypeString(pt, gotypes.RelativeTo(pkg)), argType)
+		}
+	}
+	return goResultType(sig)
+}
+
+// spelledAlike finds the name a package exports that name was meant to be,
+// when only case and underscores part them — the initialisms GoName cannot
+// spell, such as ParseURL for parse_url.
+func spelledAlike(pkg *gotypes.Package, name string) string {
+	for _, n := range pkg.Scope().Names() {
+		if gotoken.IsExported(n) && strings.EqualFold(n, name) {
+			return n
+		}
+	}
+	return ""
+}
+
+func isContext(t gotypes.Type) bool {
+	named, ok := t.(*gotypes.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// goAccepts reports whether a Meow value of type mt can be read as the Go type
+// gt, the way the bridge reads an argument. A type the checker knows nothing
+// of is accepted, since only the running program can say.
+func goAccepts(gt gotypes.Type, mt types.Type) bool {
+	mt = types.Unwrap(mt)
+	switch mt.(type) {
+	case types.AnyType, types.GoSequenceType, types.CollarType, types.TrickType:
+		return true
+	case types.NilType:
+		switch gt.Underlying().(type) {
+		case *gotypes.Pointer, *gotypes.Interface, *gotypes.Slice, *gotypes.Map, *gotypes.Signature, *gotypes.Chan:
+			return true
+		}
+		return false
+	}
+	switch u := gt.Underlying().(type) {
+	case *gotypes.Basic:
+		info := u.Info()
+		switch {
+		case info&gotypes.IsBoolean != 0:
+			return types.BoolType{}.Equals(mt)
+		case info&gotypes.IsInteger != 0:
+			return types.IntType{}.Equals(mt) || types.ByteType{}.Equals(mt)
+		case info&gotypes.IsFloat != 0:
+			return types.FloatType{}.Equals(mt) || types.IntType{}.Equals(mt)
+		case info&gotypes.IsString != 0:
+			return types.StringType{}.Equals(mt)
+		}
+		return true
+	case *gotypes.Pointer:
+		return goAccepts(u.Elem(), mt)
+	case *gotypes.Slice:
+		lt, ok := mt.(types.ListType)
+		return ok && goAccepts(u.Elem(), lt.Elem)
+	case *gotypes.Map:
+		mm, ok := mt.(types.MapType)
+		return ok && goAccepts(u.Elem(), mm.Val)
+	case *gotypes.Signature:
+		ft, ok := mt.(types.FuncType)
+		return ok && len(ft.Params) == u.Params().Len()
+	case *gotypes.Interface:
+		// Anything goes where nothing is asked of it. Where methods are, only
+		// something held from Go or a kitty groomed as the interface will do,
+		// and the checker has no type for the first.
+		if u.Empty() {
+			return true
+		}
+		_, isKitty := mt.(types.KittyType)
+		return isKitty
+	case *gotypes.Struct:
+		// A record is built from a basket, and a time read from its text.
+		if isTime(gt) {
+			return types.StringType{}.Equals(mt)
+		}
+		_, isBasket := mt.(types.MapType)
+		return isBasket
+	}
+	return true
+}
+
+func isTime(t gotypes.Type) bool {
+	named, ok := t.(*gotypes.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+// goResultType is the Meow type the bridge reads a call's result as. A
+// trailing error is the failure rather than an answer, and what is left is
+// typed only when the bridge is sure to read it as that: a Go slice can come
+// back as nothing, and a pointer as a basket or a handle, so those stay open.
+func goResultType(sig *gotypes.Signature) types.Type {
+	results := sig.Results()
+	n := results.Len()
+	if n > 0 && isError(results.At(n-1).Type()) {
+		n--
+	}
+	switch n {
+	case 0:
+		return types.AnyType{}
+	case 1:
+		return goValueType(results.At(0).Type())
+	}
+	return types.ListType{Elem: types.AnyType{}}
+}
+
+// goValueType is the Meow type a Go value of type t is read as, when there is
+// only one it can be.
+func goValueType(t gotypes.Type) types.Type {
+	if isTime(t) {
+		return types.StringType{}
+	}
+	if isSequence(t) {
+		return types.GoSequenceType{}
+	}
+	u, ok := t.Underlying().(*gotypes.Basic)
+	if !ok {
+		return types.AnyType{}
+	}
+	info := u.Info()
+	switch {
+	case info&gotypes.IsBoolean != 0:
+		return types.BoolType{}
+	case info&gotypes.IsInteger != 0:
+		return types.IntType{}
+	case info&gotypes.IsFloat != 0:
+		return types.FloatType{}
+	case info&gotypes.IsString != 0:
+		return types.StringType{}
+	}
+	return types.AnyType{}
+}
+
+// isSequence reports whether a Go value of type t is one the bridge walks with
+// purr: a channel that can be received from, a function shaped like iter.Seq
+// or iter.Seq2, or a paginator answering HasMorePages and NextPage.
+func isSequence(t gotypes.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *gotypes.Chan:
+		return u.Dir() != gotypes.SendOnly
+	case *gotypes.Signature:
+		if u.Params().Len() != 1 || u.Results().Len() != 0 {
+			return false
+		}
+		yield, ok := u.Params().At(0).Type().Underlying().(*gotypes.Signature)
+		if !ok || yield.Results().Len() != 1 || !isBool(yield.Results().At(0).Type()) {
+			return false
+		}
+		n := yield.Params().Len()
+		return n == 1 || n == 2
+	}
+	more, _, _ := gotypes.LookupFieldOrMethod(t, true, nil, "HasMorePages")
+	next, _, _ := gotypes.LookupFieldOrMethod(t, true, nil, "NextPage")
+	hasMore, ok := more.(*gotypes.Func)
+	if !ok || next == nil {
+		return false
+	}
+	sig := hasMore.Signature()
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && isBool(sig.Results().At(0).Type())
+}
+
+func isBool(t gotypes.Type) bool {
+	b, ok := t.Underlying().(*gotypes.Basic)
+	return ok && b.Info()&gotypes.IsBoolean != 0
+}
+
+func isError(t gotypes.Type) bool {
+	return gotypes.Identical(t, gotypes.Universe.Lookup("error").Type())
+}
@@ -0,0 +1,111 @@
+package checker_test
+
+import (
+	gotypes "go/types"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+// fakeStrings is the part of the strings package the tests below call, with
+// a context-taking function the bridge fills the first argument of and an
+// iterator purr walks.
+func fakeStrings() *gotypes.Package {
+	pkg := gotypes.NewPackage("strings", "strings")
+	str := gotypes.Typ[gotypes.String]
+	integer := gotypes.Typ[gotypes.Int]
+	param := func(name string, t gotypes.Type) *gotypes.Var {
+		return gotypes.NewParam(0, pkg, name, t)
+	}
+	fn := func(name string, variadic bool, results []*gotypes.Var, params ...*gotypes.Var) {
+		sig := gotypes.NewSignatureType(nil, nil, nil, gotypes.NewTuple(params...), gotypes.NewTuple(results...), variadic)
+		pkg.Scope().Insert(gotypes.NewFunc(0, pkg, name, sig))
+	}
+	ctxPkg := gotypes.NewPackage("context", "context")
+	ctx := gotypes.NewNamed(gotypes.NewTypeName(0, ctxPkg, "Context", nil), gotypes.NewInterfaceType(nil, nil), nil)
+	errType := gotypes.Universe.Lookup("error").Type()
+
+	fn("Repeat", false, []*gotypes.Var{param("", str)}, param("s", str), param("count", integer))
+	fn("Count", false, []*gotypes.Var{param("", integer)}, param("s", str), param("substr", str))
+	fn("Join", false, []*gotypes.Var{param("", str)}, param("elems", gotypes.NewSlice(str)), param("sep", str))
+	fn("ToUpperURL", false, []*gotypes.Var{param("", str)}, param("s", str))
+	fn("Fetch", false, []*gotypes.Var{param("", str), param("", errType)}, param("ctx", ctx), param("s", str))
+	yield := gotypes.NewSignatureType(nil, nil, nil, gotypes.NewTuple(param("", str)), gotypes.NewTuple(param("", gotypes.Typ[gotypes.Bool])), false)
+	seq := gotypes.NewSignatureType(nil, nil, nil, gotypes.NewTuple(param("yield", yield)), nil, false)
+	fn("FieldsSeq", false, []*gotypes.Var{param("", seq)}, param("s", str))
+	fn("Concat", true, []*gotypes.Var{param("", str)}, param("sep", str), param("parts", gotypes.NewSlice(str)))
+	return pkg
+}
+
+func checkGo(t *testing.T, input string) []*checker.TypeError {
+	t.Helper()
+	prog, errs := parser.New(lexer.New(input, "test.nyan").Tokens()).Parse()
+	if len(errs) > 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	c := checker.New()
+	c.SetGoPackages(map[string]*gotypes.Package{"strings": fakeStrings()})
+	_, typeErrs := c.Check(prog)
+	return typeErrs
+}
+
+func TestGoCallsAreCheckedAgainstThePackage(t *testing.T) {
+	tests := []struct {
+		name string
+		call string
+		want string
+	}{
+		{"a good call", `nyan x = strings.repeat("nya", 3)`, ""},
+		{"too few arguments", `nyan x = strings.repeat("nya")`, "strings.repeat expects 2 arguments but got 1"},
+		{"the wrong argument", `nyan x = strings.repeat(3, "nya")`, "Argument 1 for strings.repeat: expected string but got int"},
+		{"a list for a slice", `nyan x = strings.join(["a", "b"], ",")`, ""},
+		{"a list of the wrong things", `nyan x = strings.join([1, 2], ",")`, "Argument 1 for strings.join: expected []string but got list[int]"},
+		{"the context is the bridge's", `nyan x = strings.fetch("nya")`, ""},
+		{"variadic", `nyan x = strings.concat(",", "a", "b")`, ""},
+		{"variadic with too few", `nyan x = strings.concat()`, "strings.concat expects at least 1 arguments but got 0"},
+		{"a name not exported", `nyan x = strings.shout("nya")`, "strings has no Shout"},
+		{"an initialism", `nyan x = strings.to_upper_url("nya")`, "strings has no ToUpperUrl; Go calls it ToUpperURL, so write strings.ToUpperURL"},
+		{"the result is typed", `nyan x = strings.count("nya", "a") + "s"`, "Cannot add int and string"},
+		{"a sequence is walked in pairs", "purr i, f (strings.fields_seq(\"a b\")) {\n  nya(i, f)\n}", ""},
+		{"an error result is the failure", `nyan x string = strings.fetch("nya")`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := checkGo(t, "nab go \"strings\"\n"+tt.call+"\n")
+			if tt.want == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if contains(e.Message, tt.want) {
+					found = true
+					if e.Pos.Line != 2 {
+						t.Errorf("error at %s, want line 2", e.Pos)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("errors = %v, want one saying %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnUnreadGoPackageIsLeftToTheBridge(t *testing.T) {
+	_, errs := check(t, "nab go \"strings\"\nnyan x = strings.repeat(3)\n")
+	if len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestAShadowedGoPackageIsNotChecked(t *testing.T) {
+	errs := checkGo(t, "nab go \"strings\"\nkitty Box {\n  n: int\n}\ngroom Box {\n  meow repeat() int {\n    bring self.n\n  }\n}\nmeow f(strings Box) {\n  nya(strings.repeat())\n}\n")
+	if len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
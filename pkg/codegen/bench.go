@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+)
+
+// GenerateBench produces the Go source of a benchmark run from a test
+// Program AST. It returns the program (main.go), built as a test file is but
+// with a main that only sets the program up, the Go benchmarks (main_test.go),
+// one for each bench_ function, and the names of those functions.
+//
+// A bench_ function is an ordinary function compiled with the rest; its
+// benchmark hands it a handle on the *testing.B and leaves the counting to
+// Go, so what `go test -bench` prints — ns/op, allocs/op — is Go's own, in
+// Go's own format, and benchstat can compare one run with another.
+func (g *Generator) GenerateBench(prog *ast.Program) (program string, benchTests string, benchNames []string, err error) {
+	g.benchMode = true
+	program, err = g.GenerateTest(prog)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by meow compiler. DO NOT EDIT.\n")
+	b.WriteString("package main\n\n")
+	b.WriteString("import (\n")
+	b.WriteString("\t\"os\"\n")
+	b.WriteString("\t\"testing\"\n\n")
+	b.WriteString("\tmeow \"github.com/135yshr/meow/runtime/meowrt\"\n")
+	b.WriteString("\tmeow_testing \"github.com/135yshr/meow/runtime/testing\"\n")
+	b.WriteString(")\n\n")
+	// The program's top level — its bindings, its kitties' tricks — is set
+	// up once, before any benchmark runs, as it would be before any test.
+	b.WriteString("func TestMain(m *testing.M) {\n")
+	b.WriteString("\tmain()\n")
+	b.WriteString("\tos.Exit(m.Run())\n")
+	b.WriteString("}\n")
+
+	// Go names the benchmark by capitalizing the function's name, so two
+	// functions apart only in the case of the first letter after bench_ would
+	// be one benchmark declared twice, which go build reports in the
+	// generated code rather than the program.
+	goNames := make(map[string]string, len(g.benchFuncs))
+	for _, name := range g.benchFuncs {
+		if other, ok := goNames[BenchName(name)]; ok {
+			return "", "", nil, fmt.Errorf("bench functions %s and %s are both the Go benchmark %s; rename one", other, name, BenchName(name))
+		}
+		goNames[BenchName(name)] = name
+		benchNames = append(benchNames, name)
+		fmt.Fprintf(&b, "\nfunc %s(b *testing.B) {\n", BenchName(name))
+		fmt.Fprintf(&b, "\tmeow_testing.Bench(b, func(handle meow.Value) meow.Value {\n")
+		fmt.Fprintf(&b, "\t\treturn %s(handle)\n", name)
+		b.WriteString("\t})\n")
+		b.WriteString("}\n")
+	}
+	return program, b.String(), benchNames, nil
+}
+
+// BenchName is the name of the Go benchmark a bench_ function becomes:
+// bench_sort is BenchmarkSort.
+func BenchName(name string) string {
+	return "Benchmark" + capitalizeFirst(strings.TrimPrefix(name, "bench_"))
+}
@@ -0,0 +1,92 @@
+package codegen_test
+
+import (
+	"go/format"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+func generateBench(t *testing.T, input string) (program, benchTests string, names []string, err error) {
+	t.Helper()
+	p := parser.New(lexer.New(input, "sort_test.nyan").Tokens())
+	prog, parseErrs := p.Parse()
+	if len(parseErrs) > 0 {
+		t.Fatalf("parse errors: %v", parseErrs)
+	}
+	ti, errs := checker.New().Check(prog)
+	if len(errs) > 0 {
+		t.Fatalf("checker errors: %v", errs)
+	}
+	g := codegen.NewTest()
+	g.SetTypeInfo(ti)
+	return g.GenerateBench(prog)
+}
+
+func TestABenchFunctionIsAGoBenchmark(t *testing.T) {
+	program, benchTests, names, err := generateBench(t, `nyan items = [3, 1, 2]
+
+meow test_sort() {
+  expect(sort(items), [1, 2, 3])
+}
+
+meow bench_sort(b Bench) {
+  purr (b.loop()) {
+    sort(items)
+  }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"bench_sort"}) {
+		t.Errorf("names = %v, want [bench_sort]", names)
+	}
+	for _, want := range []string{
+		"func TestMain(m *testing.M) {\n\tmain()\n",
+		"func BenchmarkSort(b *testing.B) {\n\tmeow_testing.Bench(b, func(handle meow.Value) meow.Value {\n\t\treturn bench_sort(handle)\n",
+	} {
+		if !strings.Contains(benchTests, want) {
+			t.Errorf("missing %q in:\n%s", want, benchTests)
+		}
+	}
+	// The program is set up by main, and its tests are not run by it: a
+	// benchmark run is not a test run.
+	if strings.Contains(program, "meow_testing.Suite(") || strings.Contains(program, "meow_testing.Report(") {
+		t.Errorf("the benchmark program runs its tests:\n%s", program)
+	}
+	for _, code := range []string{program, benchTests} {
+		if _, err := format.Source([]byte(code)); err != nil {
+			t.Errorf("generated code does not parse: %v\n%s", err, code)
+		}
+	}
+}
+
+func TestABenchFunctionTakesTheHandle(t *testing.T) {
+	_, _, _, err := generateBench(t, `meow bench_nothing() {
+  nya(1)
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "bench function bench_nothing must take one parameter") {
+		t.Errorf("err = %v, want the missing handle reported", err)
+	}
+}
+
+func TestTwoBenchFunctionsCannotBeOneBenchmark(t *testing.T) {
+	_, _, _, err := generateBench(t, `meow bench_sort(b Bench) {
+  nya(1)
+}
+
+meow bench_Sort(b Bench) {
+  nya(2)
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "bench functions bench_sort and bench_Sort are both the Go benchmark BenchmarkSort") {
+		t.Errorf("err = %v, want the clash reported", err)
+	}
+}
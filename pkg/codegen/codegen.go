@@ -19,13 +19,19 @@ type Generator struct {
 	topLevel          []string
 	imports           map[string]string // meow pkg name → Go import path
 	testMode          bool
-	testFuncs         []string // names of test_ prefixed functions
-	catwalkFuncs      []string // names of catwalk_ prefixed functions
+	testFuncs         []string        // names of test_ prefixed functions
+	catwalkFuncs      []string        // names of catwalk_ prefixed functions
+	benchFuncs        []string        // names of bench_ prefixed functions
+	parallelFuncs     map[string]bool // tests that begin with parallel()
+	hookFuncs         map[string]bool // before_all, after_each and the like
+	benchMode         bool            // main only sets the program up; see GenerateBench
 	catwalkOutput     CatwalkOutput
-	mutations         map[ast.Expr][]mutation.MutationEntry
+	mutations         map[ast.Node][]mutation.MutationEntry
 	coverEnabled      bool
 	coverFilename     string
+	coverPaths        map[string]string // file name → name in the profile; see SetCoverPath
 	coverBlocks       []coverBlock
+	coverBranches     []coverBranch
 	typeInfo          *checker.TypeInfo
 	currentReturnType types.Type // return type of the function currently being generated
 	kittyDefs         map[string]*ast.KittyStmt
@@ -65,6 +71,15 @@ type Generator struct {
 	// through meow.Call, and a name here shadows a top-level function of the
 	// same name, as it does for the checker.
 	nestedFuncs map[string]bool
+	// libPackage is the Go package a library is generated as, and libSource
+	// the file it was generated from; see GenerateLib. Both are empty for a
+	// program.
+	libPackage  string
+	libSource   string
+	docComments DocComments
+	// goInterfaces are the Go interfaces kitties are groomed as, each of
+	// which is given an adapter; see SetGoInterfaces.
+	goInterfaces []GoInterface
 }
 
 // enterNestedScope starts tracking nested function names, returning a function
@@ -185,9 +200,21 @@ func (g *Generator) enterBoxedScope(names ...string) func() {
 }
 
 type coverBlock struct {
+	// file is the file the statement is from: a test file and the source it
+	// tests are generated together, and their statements are told apart by
+	// it.
+	file                                          string
 	startLine, startCol, endLine, endCol, numStmt int
 }
 
+// coverBranch is a way a decision can go, counted as coverBlock counts a
+// statement; see branch.
+type coverBranch struct {
+	file      string
+	line, col int
+	label     string
+}
+
 var stdPackages = map[string]string{
 	"clock":   "github.com/135yshr/meow/runtime/clock",
 	"env":     "github.com/135yshr/meow/runtime/env",
@@ -234,36 +261,6 @@ func capitalizeFirst(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
 }
 
-// goName spells a name the way Go writes it: new_from_config becomes
-// NewFromConfig, which is how a Meow program says a Go name in its own words.
-//
-// A name already written in Go's own spelling is left alone, which is the way
-// out for the ones this cannot reach — ParseURL is not what parse_url spells,
-// so it is written as ParseURL.
-func goName(member string) string {
-	if member == "" {
-		return member
-	}
-	if r := rune(member[0]); r >= 'A' && r <= 'Z' {
-		return member
-	}
-	var b strings.Builder
-	up := true
-	for _, r := range member {
-		if r == '_' {
-			up = true
-			continue
-		}
-		if up {
-			b.WriteString(strings.ToUpper(string(r)))
-			up = false
-			continue
-		}
-		b.WriteRune(r)
-	}
-	return b.String()
-}
-
 // New creates a new code generator.
 func New() *Generator {
 	return &Generator{}
@@ -285,7 +282,7 @@ func (g *Generator) SetCatwalkOutput(co CatwalkOutput) {
 }
 
 // SetMutations sets the mutation schema for schemata-based mutation testing.
-func (g *Generator) SetMutations(m map[ast.Expr][]mutation.MutationEntry) {
+func (g *Generator) SetMutations(m map[ast.Node][]mutation.MutationEntry) {
 	g.mutations = m
 }
 
@@ -295,8 +292,28 @@ func (g *Generator) EnableCoverage(filename string) {
 	g.coverFilename = filename
 }
 
+// SetCoverPath has the profile give path for the statements of file, the name
+// positions have: the path the file was named by on the command line, which a
+// coverage report can find it at, and which tells it from a file of the same
+// name in another directory.
+func (g *Generator) SetCoverPath(file, path string) {
+	if g.coverPaths == nil {
+		g.coverPaths = make(map[string]string)
+	}
+	g.coverPaths[file] = path
+}
+
 // Generate produces Go source code from a Program AST.
 func (g *Generator) Generate(prog *ast.Program) (string, error) {
+	if err := g.collect(prog); err != nil {
+		return "", err
+	}
+	return g.emit(), nil
+}
+
+// collect generates every declaration and top-level statement of a program,
+// ready for one of the emitters to lay out.
+func (g *Generator) collect(prog *ast.Program) error {
 	g.collectKittyDefs(prog)
 	for _, stmt := range prog.Stmts {
 		switch stmt.(type) {
@@ -311,18 +328,25 @@ func (g *Generator) Generate(prog *ast.Program) (string, error) {
 			continue
 		}
 		if fn, ok := stmt.(*ast.FuncStmt); ok {
-			g.funcs = append(g.funcs, g.genFuncDecl(fn))
+			decl := g.genFuncDecl(fn)
+			// A library function already spelled the way Go exports it is
+			// exported as itself, so its doc goes on it directly.
+			if g.libPackage != "" && isLibExport(fn.Name) && ast.GoName(fn.Name) == fn.Name {
+				decl = g.libDoc(fn, fn.Name) + decl
+			}
+			g.funcs = append(g.funcs, decl)
 		} else {
 			code, err := g.genTopLevelStmt(stmt)
 			if err != nil {
-				return "", err
+				return err
 			}
 			if code != "" {
 				g.topLevel = append(g.topLevel, code)
 			}
 		}
 	}
-	return g.emit(), nil
+	g.funcs = append(g.funcs, g.genAdapters()...)
+	return nil
 }
 
 // genTopLevelStmt generates one statement written at the top level of the
@@ -376,6 +400,12 @@ func (g *Generator) GenerateTest(prog *ast.Program) (string, error) {
 		}
 		if fn, ok := stmt.(*ast.FuncStmt); ok {
 			g.funcs = append(g.funcs, g.genFuncDecl(fn))
+			if err := g.markParallel(fn); err != nil {
+				return "", err
+			}
+			if err := g.markHook(fn); err != nil {
+				return "", err
+			}
 			if strings.HasPrefix(fn.Name, "test_") {
 				if len(fn.Params) != 0 {
 					return "", fmt.Errorf("test function %s must not take parameters", fn.Name)
@@ -391,6 +421,11 @@ func (g *Generator) GenerateTest(prog *ast.Program) (string, error) {
 					}
 				}
 				g.catwalkFuncs = append(g.catwalkFuncs, fn.Name)
+			} else if strings.HasPrefix(fn.Name, "bench_") {
+				if len(fn.Params) != 1 {
+					return "", fmt.Errorf("bench function %s must take one parameter, the benchmark handle", fn.Name)
+				}
+				g.benchFuncs = append(g.benchFuncs, fn.Name)
 			}
 		} else {
 			code, err := g.genTopLevelStmt(stmt)
@@ -402,6 +437,7 @@ func (g *Generator) GenerateTest(prog *ast.Program) (string, error) {
 			}
 		}
 	}
+	g.funcs = append(g.funcs, g.genAdapters()...)
 	g.ensureImport("testing")
 	return g.emitTest(), nil
 }
@@ -424,9 +460,7 @@ func (g *Generator) emitTest() string {
 	for _, name := range g.usedImports(g.testWrapperImports()...) {
 		fmt.Fprintf(&b, "import meow_%s \"%s\"\n", name, g.imports[name])
 	}
-	for _, name := range g.usedGoImports() {
-		fmt.Fprintf(&b, "import go_%s \"%s\"\n", name, g.goImports[name])
-	}
+	b.WriteString(g.goImportLines())
 	b.WriteString("\n")
 
 	if len(g.mutations) > 0 {
@@ -444,9 +478,14 @@ func (g *Generator) emitTest() string {
 			b.WriteString("\t}\n")
 		}
 		if g.coverEnabled {
+			b.WriteString("\tmeow_coverage.SetMode(os.Getenv(\"MEOW_COVERMODE\"))\n")
 			for i, cb := range g.coverBlocks {
 				fmt.Fprintf(&b, "\tmeow_coverage.Register(%q, %d, %d, %d, %d, %d) // block %d\n",
-					g.coverFilename, cb.startLine, cb.startCol, cb.endLine, cb.endCol, cb.numStmt, i)
+					cb.file, cb.startLine, cb.startCol, cb.endLine, cb.endCol, cb.numStmt, i)
+			}
+			for i, br := range g.coverBranches {
+				fmt.Fprintf(&b, "\tmeow_coverage.RegisterBranch(%q, %d, %d, %q) // branch %d\n",
+					br.file, br.line, br.col, br.label, i)
 			}
 		}
 		b.WriteString("}\n\n")
@@ -468,6 +507,11 @@ func (g *Generator) emitTest() string {
 	// running test_/catwalk_ functions. RunMain handles both Furball returns
 	// and internal panics so failures land as clean stderr messages.
 	b.WriteString("func main() {\n")
+	if !g.benchMode {
+		// The top level can run tests of its own, which are held to the
+		// same options, and told as events, as the suite's.
+		b.WriteString("\tmeow_testing.Begin()\n")
+	}
 	if len(g.topLevel) > 0 {
 		b.WriteString("\tmeow.RunMain(func() meow.Value {\n")
 		for _, line := range g.topLevel {
@@ -478,19 +522,33 @@ func (g *Generator) emitTest() string {
 		b.WriteString("\t\treturn meow.NewNil()\n")
 		b.WriteString("\t})\n")
 	}
-	for _, name := range g.testFuncs {
-		fmt.Fprintf(&b, "\tmeow_testing.Run(meow.NewString(%q), meow.NewFunc(%q, func(args ...meow.Value) meow.Value {\n", name, name)
-		fmt.Fprintf(&b, "\t\treturn %s()\n", name)
-		fmt.Fprintf(&b, "\t}))\n")
-	}
-	for _, name := range g.catwalkFuncs {
-		expected := ""
-		if g.catwalkOutput != nil {
-			expected = g.catwalkOutput[name]
+	if g.benchMode {
+		// The benchmarks call the test runtime from a file of their own.
+		b.WriteString("}\n\n")
+		b.WriteString("var _ = meow_testing.Judge\n")
+		return b.String()
+	}
+	// The tests are handed over together, so that which of them run, and in
+	// what order, is the runtime's to decide: -run, -shuffle and -failfast
+	// are read where the tests run, not baked into the binary.
+	if len(g.testFuncs)+len(g.catwalkFuncs) > 0 {
+		g.emitHooks(&b)
+		b.WriteString("\tmeow_testing.Suite(\n")
+		for _, name := range g.testFuncs {
+			fmt.Fprintf(&b, "\t\tmeow_testing.Case{Name: %q, Fn: func(args ...meow.Value) meow.Value {\n", name)
+			fmt.Fprintf(&b, "\t\t\treturn %s()\n", name)
+			fmt.Fprintf(&b, "\t\t}%s},\n", g.parallelField(name))
+		}
+		for _, name := range g.catwalkFuncs {
+			expected := ""
+			if g.catwalkOutput != nil {
+				expected = g.catwalkOutput[name]
+			}
+			fmt.Fprintf(&b, "\t\tmeow_testing.Case{Name: %q, Fn: func(args ...meow.Value) meow.Value {\n", name)
+			fmt.Fprintf(&b, "\t\t\treturn %s()\n", name)
+			fmt.Fprintf(&b, "\t\t}, Catwalk: true, Output: %q%s},\n", expected, g.parallelField(name))
 		}
-		fmt.Fprintf(&b, "\tmeow_testing.Catwalk(meow.NewString(%q), meow.NewFunc(%q, func(args ...meow.Value) meow.Value {\n", name, name)
-		fmt.Fprintf(&b, "\t\treturn %s()\n", name)
-		fmt.Fprintf(&b, "\t}), meow.NewString(%q))\n", expected)
+		b.WriteString("\t)\n")
 	}
 	if g.coverEnabled {
 		b.WriteString("\tmeow_coverage.Report(os.Stdout)\n")
@@ -605,9 +663,7 @@ func (g *Generator) emit() string {
 	for _, name := range g.usedImports() {
 		fmt.Fprintf(&b, "import meow_%s \"%s\"\n", name, g.imports[name])
 	}
-	for _, name := range g.usedGoImports() {
-		fmt.Fprintf(&b, "import go_%s \"%s\"\n", name, g.goImports[name])
-	}
+	b.WriteString(g.goImportLines())
 	b.WriteString("\n")
 
 	if len(g.mutations) > 0 {
@@ -747,7 +803,7 @@ func goTypeString(t types.Type) string {
 }
 
 func (g *Generator) genTypedStmt(stmt ast.Stmt) string {
-	return g.located(stmt, g.genTypedStmtInner(stmt))
+	return g.covered(stmt, g.located(stmt, g.genTypedStmtInner(stmt)))
 }
 
 func (g *Generator) genTypedStmtInner(stmt ast.Stmt) string {
@@ -765,7 +821,7 @@ func (g *Generator) genTypedStmtInner(stmt ast.Stmt) string {
 	case *ast.WhileStmt:
 		return g.genTypedWhile(s)
 	default:
-		return g.genStmt(stmt)
+		return g.genStmtInner(stmt)
 	}
 }
 
@@ -827,12 +883,9 @@ func (g *Generator) genTypedIf(s *ast.IfStmt) string {
 	} else {
 		fmt.Fprintf(&b, "if (%s).IsTruthy() {\n", g.genExpr(s.Condition))
 	}
+	b.WriteString(g.take(s.Pos(), "sniff body"))
 	b.WriteString(g.genBlockStmts(s.Body, g.genTypedStmt))
-	if len(s.ElseBody) > 0 {
-		b.WriteString("} else {\n")
-		b.WriteString(g.genBlockStmts(s.ElseBody, g.genTypedStmt))
-	}
-	b.WriteString("}")
+	g.genElse(&b, s, g.genTypedStmt)
 	return b.String()
 }
 
@@ -925,6 +978,12 @@ func (g *Generator) genTypedExpr(expr ast.Expr) string {
 		return g.genTypedBinary(e)
 	case *ast.CallExpr:
 		return g.genTypedCall(e)
+	case *ast.CatchExpr:
+		// The value or the fallback comes back boxed from GagOr either way.
+		if isNativeType(t) {
+			return unboxToNative(g.genCatch(e), t)
+		}
+		return g.genCatch(e)
 	default:
 		return g.genExprBoxed(expr)
 	}
@@ -966,11 +1025,14 @@ func (g *Generator) genTypedMatch(e *ast.MatchExpr) string {
 	hasCondArm := false
 	for i, arm := range e.Arms {
 		if _, ok := arm.Pattern.(*ast.WildcardPattern); ok {
+			take := g.take(armPos(arm), "peek arm")
 			if hasCondArm {
 				b.WriteString("\t} else {\n")
+				b.WriteString(take)
 				b.WriteString(fmt.Sprintf("\t\treturn %s\n", g.boxValue(arm.Body)))
 				b.WriteString("\t}\n")
 			} else {
+				b.WriteString(take)
 				b.WriteString(fmt.Sprintf("\treturn %s\n", g.boxValue(arm.Body)))
 			}
 			b.WriteString("\treturn meow.NewNil()\n}()")
@@ -982,6 +1044,7 @@ func (g *Generator) genTypedMatch(e *ast.MatchExpr) string {
 		}
 		hasCondArm = true
 		b.WriteString(fmt.Sprintf("\t%s %s {\n", keyword, g.genPatternCond("__subject", arm.Pattern)))
+		b.WriteString(g.take(armPos(arm), "peek arm"))
 		b.WriteString(fmt.Sprintf("\t\treturn %s\n", g.boxValue(arm.Body)))
 	}
 	if hasCondArm {
@@ -1111,9 +1174,9 @@ func (g *Generator) genTypedBinary(e *ast.BinaryExpr) string {
 	case token.GTE:
 		return fmt.Sprintf("(%s >= %s)", left, right)
 	case token.AND:
-		return fmt.Sprintf("(%s && %s)", left, right)
+		return fmt.Sprintf("(%s && %s)", g.decided(left, e.Pos(), "&& right side", "&& left side alone"), right)
 	case token.OR:
-		return fmt.Sprintf("(%s || %s)", left, right)
+		return fmt.Sprintf("(%s || %s)", g.decided(left, e.Pos(), "|| left side alone", "|| right side"), right)
 	}
 	return g.genBinary(e)
 }
@@ -1388,19 +1451,73 @@ func (g *Generator) blockAlwaysReturns(stmts []ast.Stmt) bool {
 }
 
 func (g *Generator) genStmt(stmt ast.Stmt) string {
-	code := g.located(stmt, g.genStmtInner(stmt))
+	return g.covered(stmt, g.located(stmt, g.genStmtInner(stmt)))
+}
+
+// covered is the code of stmt, counted as a run of it first when coverage is
+// on.
+func (g *Generator) covered(stmt ast.Stmt, code string) string {
 	if !g.coverEnabled {
 		return code
 	}
 	pos := stmt.Pos()
 	endLine, endCol := g.estimateEndPos(stmt)
 	id := len(g.coverBlocks)
-	g.coverBlocks = append(g.coverBlocks, coverBlock{pos.Line, pos.Column, endLine, endCol, 1})
+	g.coverBlocks = append(g.coverBlocks, coverBlock{g.coverFile(pos), pos.Line, pos.Column, endLine, endCol, 1})
 	g.markPackageUsed("coverage")
 	return fmt.Sprintf("meow_coverage.Hit(%d)\n%s", id, code)
 }
 
+// coverFile is the name the profile gives the file pos is in.
+func (g *Generator) coverFile(pos token.Position) string {
+	file := pos.File
+	if path, ok := g.coverPaths[file]; ok {
+		file = path
+	}
+	if file == "" {
+		file = g.coverFilename
+	}
+	return file
+}
+
+// branch registers a way the decision at pos can go, and gives back its ID,
+// or -1 when coverage is off or pos is in a _test.nyan file: the ways a test
+// goes are not the code it tests, and would only make the share of the
+// branches taken say less of it.
+func (g *Generator) branch(pos token.Position, label string) int {
+	file := g.coverFile(pos)
+	if !g.coverEnabled || strings.HasSuffix(file, "_test.nyan") {
+		return -1
+	}
+	g.coverBranches = append(g.coverBranches, coverBranch{file, pos.Line, pos.Column, label})
+	g.markPackageUsed("coverage")
+	return len(g.coverBranches) - 1
+}
+
+// take is the statement that counts the branch at pos as taken, "" when
+// coverage is off.
+func (g *Generator) take(pos token.Position, label string) string {
+	id := g.branch(pos, label)
+	if id < 0 {
+		return ""
+	}
+	return fmt.Sprintf("meow_coverage.Take(%d)\n", id)
+}
+
+// decided is cond, a Go bool, with the decision counted as going to the
+// branch yes or the branch no when coverage is on.
+func (g *Generator) decided(cond string, pos token.Position, yes, no string) string {
+	y := g.branch(pos, yes)
+	if y < 0 {
+		return cond
+	}
+	return fmt.Sprintf("meow_coverage.Decide(%s, %d, %d)", cond, y, g.branch(pos, no))
+}
+
 func (g *Generator) genStmtInner(stmt ast.Stmt) string {
+	if entries, ok := g.mutations[stmt]; ok && len(entries) > 0 {
+		return g.genMutatedStmt(stmt, entries)
+	}
 	switch s := stmt.(type) {
 	case *ast.VarStmt:
 		// Bind, then short-circuit if the value is a Furball — the untyped
@@ -1575,13 +1692,32 @@ func (g *Generator) usedGoImports() []string {
 func (g *Generator) genIf(s *ast.IfStmt) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "if (%s).IsTruthy() {\n", g.genExpr(s.Condition))
+	b.WriteString(g.take(s.Pos(), "sniff body"))
 	b.WriteString(g.genBlockStmts(s.Body, g.genStmt))
-	if len(s.ElseBody) > 0 {
+	g.genElse(&b, s, g.genStmt)
+	return b.String()
+}
+
+// armPos is where arm's pattern starts: a range's is its low end, not the ..
+// the pattern is known by.
+func armPos(arm ast.MatchArm) token.Position {
+	if r, ok := arm.Pattern.(*ast.RangePattern); ok && r.Low != nil {
+		return r.Low.Pos()
+	}
+	return arm.Pattern.Pos()
+}
+
+// genElse closes the if genIf or genTypedIf opened, with the scratch of s if
+// it has one. Under coverage a sniff with none has one written for it, for
+// its not being taken to be counted too.
+func (g *Generator) genElse(b *strings.Builder, s *ast.IfStmt, gen func(ast.Stmt) string) {
+	take := g.take(s.Pos(), "scratch")
+	if len(s.ElseBody) > 0 || take != "" {
 		b.WriteString("} else {\n")
-		b.WriteString(g.genBlockStmts(s.ElseBody, g.genStmt))
+		b.WriteString(take)
+		b.WriteString(g.genBlockStmts(s.ElseBody, gen))
 	}
 	b.WriteString("}")
-	return b.String()
 }
 
 func (g *Generator) genRange(s *ast.RangeStmt) string {
@@ -1739,7 +1875,7 @@ func (g *Generator) genExpr(expr ast.Expr) string {
 				g.markPackageUsed(goPkg)
 				// Something a Go package holds rather than does: read it if
 				// Meow has a shape for it, hold it if not.
-				return fmt.Sprintf("meow.FromGo(go_%s.%s)", goPkg, goName(e.Member))
+				return fmt.Sprintf("meow.FromGo(go_%s.%s)", goPkg, ast.GoName(e.Member))
 			}
 			if realPkg, ok := g.resolveImportName(obj.Name); ok {
 				g.markPackageUsed(realPkg)
@@ -1825,14 +1961,36 @@ func (g *Generator) genBinary(e *ast.BinaryExpr) string {
 	case token.GTE:
 		return fmt.Sprintf("meow.GreaterEqual(%s, %s)", l, r)
 	case token.AND:
-		return fmt.Sprintf("meow.And(%s, %s)", l, r)
+		return fmt.Sprintf("meow.And(%s, %s)", g.decidedLeft(l, e), r)
 	case token.OR:
-		return fmt.Sprintf("meow.Or(%s, %s)", l, r)
+		return fmt.Sprintf("meow.Or(%s, %s)", g.decidedLeft(l, e), r)
 	default:
 		return fmt.Sprintf("/* unsupported op: %v */", e.Op)
 	}
 }
 
+// decidedLeft is the left side of e, an untyped && or ||, counted under
+// coverage as deciding whether the right side is what e gives. Both sides are
+// evaluated either way, as And and Or are handed them; the right is taken
+// when it is the one they answer.
+func (g *Generator) decidedLeft(left string, e *ast.BinaryExpr) string {
+	op := e.Token.Literal
+	cond := "!__hissed && __left.IsTruthy()"
+	if e.Op == token.OR {
+		cond = "!__hissed && !__left.IsTruthy()"
+	}
+	decided := g.decided(cond, e.Pos(), op+" right side", op+" left side alone")
+	if decided == cond {
+		return left
+	}
+	return fmt.Sprintf("func() meow.Value {\n"+
+		"\tvar __left meow.Value = %s\n"+
+		"\t_, __hissed := __left.(*meow.Furball)\n"+
+		"\t%s\n"+
+		"\treturn __left\n"+
+		"}()", left, decided)
+}
+
 func (g *Generator) genCall(e *ast.CallExpr) string {
 	if member, ok := e.Fn.(*ast.MemberExpr); ok {
 		return g.genMemberCall(member, e.Args)
@@ -1915,7 +2073,7 @@ func (g *Generator) genCall(e *ast.CallExpr) string {
 		case "refuse":
 			g.ensureImport("testing")
 			return fmt.Sprintf("meow_testing.Refuse(%s)", argStr)
-		case "seed":
+		case "seed", "parallel":
 			return "meow.NewNil()"
 		default:
 			if ks, ok := g.kittyDefs[ident.Name]; ok {
@@ -2009,7 +2167,7 @@ func (g *Generator) genMemberCall(member *ast.MemberExpr, rawArgs []ast.Expr) st
 // named as the program wrote it so that anything going wrong says which call
 // it was.
 func (g *Generator) genGoCall(pkg, wrote, member, argStr string) string {
-	call := fmt.Sprintf("meow.CallGo(%q, go_%s.%s", wrote+"."+member, pkg, goName(member))
+	call := fmt.Sprintf("meow.CallGo(%q, go_%s.%s", wrote+"."+member, pkg, ast.GoName(member))
 	if argStr != "" {
 		call += ", " + argStr
 	}
@@ -2204,10 +2362,25 @@ func (g *Generator) genCatch(e *ast.CatchExpr) string {
 	// inline would bypass ~>'s recovery.
 	left := g.genExpr(e.Left)
 	right := g.genExpr(e.Right)
-	return fmt.Sprintf(
-		"meow.GagOr(meow.NewFunc(\"~>\", func(args ...meow.Value) meow.Value {\n"+
-			"\treturn %s\n"+
-			"}), %s)", left, right)
+	hissed := g.decided("__hissed", e.Pos(), "~> fallback", "~> without hiss")
+	if hissed == "__hissed" {
+		return fmt.Sprintf(
+			"meow.GagOr(meow.NewFunc(\"~>\", func(args ...meow.Value) meow.Value {\n"+
+				"\treturn %s\n"+
+				"}), %s)", left, right)
+	}
+	// Under coverage GagOr is taken apart, for whether the fallback is used
+	// to be counted; the fallback is still evaluated first, as it is when
+	// handed to GagOr.
+	return fmt.Sprintf("func() meow.Value {\n"+
+		"\t__fallback := %s\n"+
+		"\t__caught := meow.Gag(meow.NewFunc(\"~>\", func(args ...meow.Value) meow.Value {\n"+
+		"\t\treturn %s\n"+
+		"\t}))\n"+
+		"\t_, __hissed := __caught.(*meow.Furball)\n"+
+		"\t%s\n"+
+		"\treturn meow.Recover(__caught, __fallback)\n"+
+		"}()", right, left, hissed)
 }
 
 func (g *Generator) genMatch(e *ast.MatchExpr) string {
@@ -2217,11 +2390,14 @@ func (g *Generator) genMatch(e *ast.MatchExpr) string {
 	hasCondArm := false
 	for i, arm := range e.Arms {
 		if _, ok := arm.Pattern.(*ast.WildcardPattern); ok {
+			take := g.take(armPos(arm), "peek arm")
 			if hasCondArm {
 				b.WriteString("\t} else {\n")
+				b.WriteString(take)
 				b.WriteString(fmt.Sprintf("\t\treturn %s\n", g.genExpr(arm.Body)))
 				b.WriteString("\t}\n")
 			} else {
+				b.WriteString(take)
 				b.WriteString(fmt.Sprintf("\treturn %s\n", g.genExpr(arm.Body)))
 			}
 			b.WriteString("\treturn meow.NewNil()\n}()")
@@ -2233,6 +2409,7 @@ func (g *Generator) genMatch(e *ast.MatchExpr) string {
 		}
 		hasCondArm = true
 		b.WriteString(fmt.Sprintf("\t%s %s {\n", keyword, g.genPatternCond("__subject", arm.Pattern)))
+		b.WriteString(g.take(armPos(arm), "peek arm"))
 		b.WriteString(fmt.Sprintf("\t\treturn %s\n", g.genExpr(arm.Body)))
 	}
 	if hasCondArm {
@@ -2267,6 +2444,26 @@ func (g *Generator) genMutatedExpr(original ast.Expr, entries []mutation.Mutatio
 	return b.String()
 }
 
+// genMutatedStmt is genMutatedExpr for a statement: the mutants are the
+// branches of an if, and the original statement is its else. A statement
+// mutated this way declares nothing the statements after it could use.
+func (g *Generator) genMutatedStmt(original ast.Stmt, entries []mutation.MutationEntry) string {
+	delete(g.mutations, original)
+
+	var b strings.Builder
+	saved := g.mutations
+	g.mutations = nil
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "if __mutant == %d {\n%s\n} else ", entry.ID, g.genStmtInner(entry.Stmt))
+	}
+	g.mutations = saved
+
+	fmt.Fprintf(&b, "{\n%s\n}", g.genStmtInner(original))
+
+	g.mutations[original] = entries
+	return b.String()
+}
+
 func (g *Generator) genLearnMethod(typeName string, fn *ast.FuncStmt) string {
 	names := make([]string, 0, len(fn.Params)+1)
 	names = append(names, "self")
@@ -2322,6 +2519,7 @@ func (g *Generator) genLearnInit() string {
 				ls.TypeName, m.Name, ls.TypeName, m.Name)
 		}
 	}
+	b.WriteString(g.genAdapterRegistrations())
 	b.WriteString("}\n")
 	return b.String()
 }
@@ -174,13 +174,13 @@ meow helper() {
 	if !strings.Contains(code, `import meow_testing "github.com/135yshr/meow/runtime/testing"`) {
 		t.Error("expected meow_testing import")
 	}
-	if !strings.Contains(code, `meow_testing.Run(meow.NewString("test_add")`) {
-		t.Error("expected Run call for test_add")
+	if !strings.Contains(code, `meow_testing.Case{Name: "test_add"`) {
+		t.Error("expected a Case for test_add")
 	}
-	if !strings.Contains(code, `meow_testing.Run(meow.NewString("test_bool")`) {
-		t.Error("expected Run call for test_bool")
+	if !strings.Contains(code, `meow_testing.Case{Name: "test_bool"`) {
+		t.Error("expected a Case for test_bool")
 	}
-	if strings.Contains(code, `meow_testing.Run(meow.NewString("helper")`) {
+	if strings.Contains(code, `meow_testing.Case{Name: "helper"`) {
 		t.Error("helper should not be auto-run as test")
 	}
 	if !strings.Contains(code, `meow_testing.Report()`) {
@@ -333,6 +333,56 @@ func TestCoverageReportInMain(t *testing.T) {
 	}
 }
 
+func TestCoverageRegistersEachBranch(t *testing.T) {
+	code := generateTestWithCoverage(t, `meow test_branches() {
+  sniff (yarn) {
+    nya("yes")
+  }
+  nyan size = peek(3) {
+    0 => "none",
+    1..5 => "few",
+    _ => "many"
+  }
+  nyan safe = hiss("no") ~> 0
+  nyan both = yarn && hairball
+}`, "branch.nyan")
+
+	for _, want := range []string{
+		`meow_coverage.RegisterBranch("branch.nyan", 2, 3, "sniff body")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 2, 3, "scratch")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 6, 5, "peek arm")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 7, 5, "peek arm")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 8, 5, "peek arm")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 10, 26, "~> fallback")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 10, 26, "~> without hiss")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 11, 20, "&& right side")`,
+		`meow_coverage.RegisterBranch("branch.nyan", 11, 20, "&& left side alone")`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("expected %s, got:\n%s", want, code)
+		}
+	}
+	// A sniff with no scratch is given one, for not being taken to count.
+	if !strings.Contains(code, "} else {\nmeow_coverage.Take(1)\n}") {
+		t.Errorf("expected a scratch taking branch 1, got:\n%s", code)
+	}
+}
+
+func TestCoverageCountsNoBranchesOfATestFile(t *testing.T) {
+	code := generateTestWithCoverage(t, `meow test_branches() {
+  sniff (yarn) {
+    nya("yes")
+  }
+}`, "branch_test.nyan")
+
+	if strings.Contains(code, "RegisterBranch") || strings.Contains(code, "meow_coverage.Take") {
+		t.Errorf("expected no branches of a _test.nyan file, got:\n%s", code)
+	}
+	if !strings.Contains(code, `meow_coverage.Register("branch_test.nyan", 2, 3`) {
+		t.Errorf("expected its statements still counted, got:\n%s", code)
+	}
+}
+
 func TestCoverageDisabledByDefault(t *testing.T) {
 	code := generateTest(t, `meow test_add() {
   nyan result = 1 + 2
@@ -12,6 +12,12 @@
 //
 // All Meow values are represented as meow.Value at runtime.
 //
+// # Libraries
+//
+// GenerateLib lays the same code out as an importable package instead, with
+// no main. Each top-level function is exported under Go's spelling through a
+// thin function in front of it, and top-level statements run in init.
+//
 // # Runtime Dependency
 //
 // Generated code calls functions from the meowrt package for arithmetic,
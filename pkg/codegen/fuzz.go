@@ -2,11 +2,13 @@ package codegen
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/135yshr/meow/pkg/ast"
 	"github.com/135yshr/meow/pkg/token"
+	"github.com/135yshr/meow/pkg/types"
 )
 
 // GenerateFuzz produces Go fuzz test source from a Program AST.
@@ -24,6 +26,8 @@ func (g *Generator) GenerateFuzz(prog *ast.Program) (helpers string, fuzzTests s
 	var funcs []fuzzFunc
 	var helperFuncs []string
 
+	// A seed can make a kitty, and a helper take one.
+	g.collectKittyDefs(prog)
 	for _, stmt := range prog.Stmts {
 		fn, ok := stmt.(*ast.FuncStmt)
 		if !ok {
@@ -105,42 +109,77 @@ func (g *Generator) GenerateFuzz(prog *ast.Program) (helpers string, fuzzTests s
 		goName := "Fuzz" + capitalizeFirst(strings.TrimPrefix(ff.name, "fuzz_"))
 		names = append(names, goName)
 
-		fmt.Fprintf(&fb, "func %s(f *testing.F) {\n", goName)
-
-		// Add seeds
-		for _, seed := range ff.seeds {
-			seedArgs := make([]string, len(seed))
-			for i, s := range seed {
-				seedArgs[i] = g.fuzzSeedLiteral(s)
-			}
-			fmt.Fprintf(&fb, "\tf.Add(%s)\n", strings.Join(seedArgs, ", "))
+		paramTypes := g.fuzzParamTypes(ff.fn)
+		goTypes := make([]string, len(ff.params))
+		structured := false
+		for i, t := range paramTypes {
+			goTypes[i] = fuzzGoType(t)
+			structured = structured || goTypes[i] == ""
 		}
 
-		// Generate Fuzz function
-		fuzzParams := make([]string, len(ff.params))
-		fuzzParamTypes := make([]string, len(ff.params))
+		fmt.Fprintf(&fb, "func %s(f *testing.F) {\n", goName)
+		params := make([]string, len(ff.params))
 		for i, p := range ff.params {
-			typ := g.fuzzGoType(p)
-			fuzzParams[i] = fmt.Sprintf("%s_raw %s", p.Name, typ)
-			fuzzParamTypes[i] = typ
+			params[i] = p.Name
 		}
 
-		fmt.Fprintf(&fb, "\tf.Fuzz(func(t *testing.T, %s) {\n", strings.Join(fuzzParams, ", "))
-
-		// Convert raw params to meow values
-		for i, p := range ff.params {
-			converter := g.fuzzConverter(fuzzParamTypes[i], p.Name+"_raw")
-			fmt.Fprintf(&fb, "\t\t%s := %s\n", p.Name, converter)
+		if structured {
+			// Go fuzzes none of a litter, a basket or a kitty, so the
+			// function is handed bytes, and the runtime makes values of
+			// them as the shapes of its parameters say.
+			shapes := make([]string, len(paramTypes))
+			for i, t := range paramTypes {
+				shape, err := g.fuzzShape(t, nil)
+				if err != nil {
+					return "", "", nil, fmt.Errorf("fuzz function %s: parameter %s %w", ff.name, ff.params[i].Name, err)
+				}
+				shapes[i] = shape
+			}
+			fmt.Fprintf(&fb, "\t__shapes := []meow_testing.FuzzShape{%s}\n", strings.Join(shapes, ", "))
+			for _, seed := range ff.seeds {
+				args := make([]string, len(seed))
+				for i, s := range seed {
+					args[i] = g.genExpr(s)
+				}
+				fmt.Fprintf(&fb, "\tf.Add(meow_testing.EncodeFuzz(__shapes, %s))\n", strings.Join(args, ", "))
+			}
+			fb.WriteString("\tf.Fuzz(func(t *testing.T, __data []byte) {\n")
+			fb.WriteString("\t\t__args := meow_testing.DecodeFuzz(__data, __shapes...)\n")
+			for i, p := range ff.params {
+				fmt.Fprintf(&fb, "\t\t%s := __args[%d]\n", p.Name, i)
+			}
+		} else {
+			for _, seed := range ff.seeds {
+				args := make([]string, len(seed))
+				for i, s := range seed {
+					args[i] = g.fuzzSeedLiteral(s, goTypes[i])
+				}
+				fmt.Fprintf(&fb, "\tf.Add(%s)\n", strings.Join(args, ", "))
+			}
+			raw := make([]string, len(ff.params))
+			for i, p := range ff.params {
+				raw[i] = fmt.Sprintf("%s_raw %s", p.Name, goTypes[i])
+			}
+			fmt.Fprintf(&fb, "\tf.Fuzz(func(t *testing.T, %s) {\n", strings.Join(raw, ", "))
+			for i, p := range ff.params {
+				fmt.Fprintf(&fb, "\t\t%s := %s\n", p.Name, fuzzConverter(goTypes[i], p.Name+"_raw"))
+			}
 		}
 
-		// Generate body (excluding seed calls)
+		// The body is a function of its own, as a test's is, for a failed
+		// expect to come back from as a furball; the furball fails the
+		// input.
+		fb.WriteString("\t\tif __f, __ok := meow.AsFurball(func() meow.Value {\n")
 		for _, stmt := range ff.fn.Body {
 			if isSeedCall(stmt) {
 				continue
 			}
-			fmt.Fprintf(&fb, "\t\t%s\n", g.genStmt(stmt))
+			fmt.Fprintf(&fb, "\t\t\t%s\n", g.genStmt(stmt))
 		}
-
+		fb.WriteString("\t\t\treturn meow.NewNil()\n")
+		fb.WriteString("\t\t}()); __ok {\n")
+		fmt.Fprintf(&fb, "\t\t\tmeow_testing.FuzzFailed(t, __f%s)\n", prefixEach(", ", params))
+		fb.WriteString("\t\t}\n")
 		fb.WriteString("\t})\n")
 		fb.WriteString("}\n\n")
 	}
@@ -175,9 +214,15 @@ func isSeedCall(stmt ast.Stmt) bool {
 	return ok && ident.Name == "seed"
 }
 
-func (g *Generator) fuzzSeedLiteral(expr ast.Expr) string {
+// fuzzSeedLiteral is a seed for a parameter go fuzzes as typ, as the Go
+// value f.Add takes: a literal as is, and anything else evaluated and
+// unboxed.
+func (g *Generator) fuzzSeedLiteral(expr ast.Expr, typ string) string {
 	switch e := expr.(type) {
 	case *ast.IntLit:
+		if typ == "float64" {
+			return fmt.Sprintf("float64(%d)", e.Value)
+		}
 		return fmt.Sprintf("int64(%d)", e.Value)
 	case *ast.FloatLit:
 		return fmt.Sprintf("float64(%g)", e.Value)
@@ -189,41 +234,131 @@ func (g *Generator) fuzzSeedLiteral(expr ast.Expr) string {
 		if e.Op == token.MINUS {
 			switch inner := e.Right.(type) {
 			case *ast.IntLit:
+				if typ == "float64" {
+					return fmt.Sprintf("float64(%d)", -inner.Value)
+				}
 				return fmt.Sprintf("int64(%d)", -inner.Value)
 			case *ast.FloatLit:
 				return fmt.Sprintf("float64(%g)", -inner.Value)
 			}
 		}
-		return "int64(0)"
+	}
+	switch typ {
+	case "float64":
+		return fmt.Sprintf("meow.AsFloat(%s)", g.genExpr(expr))
+	case "string":
+		return fmt.Sprintf("meow.AsString(%s)", g.genExpr(expr))
+	case "bool":
+		return fmt.Sprintf("meow.AsBool(%s)", g.genExpr(expr))
 	default:
-		return "int64(0)"
+		return fmt.Sprintf("meow.AsInt(%s)", g.genExpr(expr))
 	}
 }
 
-// fuzzGoType returns the Go type string for a fuzz parameter based on its type annotation.
-func (g *Generator) fuzzGoType(p ast.Param) string {
-	if p.TypeAnn == nil {
-		return "int64" // fallback
+// fuzzParamTypes is the types of fn's parameters, as the checker has them,
+// or as their annotations say where nothing has checked fn.
+func (g *Generator) fuzzParamTypes(fn *ast.FuncStmt) []types.Type {
+	if g.typeInfo != nil {
+		if ft, ok := g.typeInfo.FuncTypes[fn.Name]; ok && len(ft.Params) == len(fn.Params) {
+			return ft.Params
+		}
 	}
-	if bt, ok := p.TypeAnn.(*ast.BasicType); ok {
-		switch bt.Name {
-		case "int":
-			return "int64"
-		case "float":
-			return "float64"
-		case "string":
-			return "string"
-		case "bool":
-			return "bool"
+	ts := make([]types.Type, len(fn.Params))
+	for i, p := range fn.Params {
+		ts[i] = types.AnyType{}
+		if bt, ok := p.TypeAnn.(*ast.BasicType); ok {
+			switch bt.Name {
+			case "int":
+				ts[i] = types.IntType{}
+			case "float":
+				ts[i] = types.FloatType{}
+			case "string":
+				ts[i] = types.StringType{}
+			case "bool":
+				ts[i] = types.BoolType{}
+			}
 		}
 	}
-	return "int64"
+	return ts
+}
+
+// fuzzGoType returns the Go type go fuzzes a parameter of type t as, or ""
+// for one it cannot, which is decoded from bytes instead.
+func fuzzGoType(t types.Type) string {
+	switch types.Unwrap(t).(type) {
+	case types.IntType:
+		return "int64"
+	case types.FloatType:
+		return "float64"
+	case types.StringType:
+		return "string"
+	case types.BoolType:
+		return "bool"
+	}
+	return ""
 }
 
-func (g *Generator) fuzzConverter(typ, rawVar string) string {
+// fuzzShape is the Go code of the meow_testing.FuzzShape a parameter of type
+// t is decoded as. kitties are those it is inside of already: a kitty that
+// holds itself would be a shape without end.
+func (g *Generator) fuzzShape(t types.Type, kitties []string) (string, error) {
+	switch t := types.Unwrap(t).(type) {
+	case types.IntType:
+		return "meow_testing.FuzzInt", nil
+	case types.FloatType:
+		return "meow_testing.FuzzFloat", nil
+	case types.StringType:
+		return "meow_testing.FuzzString", nil
+	case types.BoolType:
+		return "meow_testing.FuzzBool", nil
+	case types.AnyType:
+		return "meow_testing.FuzzAny", nil
+	case types.ListType:
+		elem, err := g.fuzzShape(t.Elem, kitties)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("meow_testing.FuzzList(%s)", elem), nil
+	case types.MapType:
+		elem, err := g.fuzzShape(t.Val, kitties)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("meow_testing.FuzzBasket(%s)", elem), nil
+	case types.CollarType:
+		inner, err := g.fuzzShape(t.Underlying, kitties)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("meow_testing.FuzzKitty(%q, meow_testing.FuzzField{Name: \"value\", Shape: %s})", t.Name, inner), nil
+	case types.KittyType:
+		if slices.Contains(kitties, t.Name) {
+			return "", fmt.Errorf("is a %s, which holds a %s itself, and cannot be fuzzed", kitties[0], t.Name)
+		}
+		kitties = append(kitties, t.Name)
+		// A parameter's type can be taken before the kitty's fields are
+		// known; the kitty's own entry has them.
+		if g.typeInfo != nil {
+			if kt, ok := g.typeInfo.KittyTypes[t.Name]; ok {
+				t = kt
+			}
+		}
+		fields := []string{fmt.Sprintf("%q", t.Name)}
+		for _, f := range t.Fields {
+			shape, err := g.fuzzShape(f.Type, kitties)
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, fmt.Sprintf("meow_testing.FuzzField{Name: %q, Shape: %s}", f.Name, shape))
+		}
+		return fmt.Sprintf("meow_testing.FuzzKitty(%s)", strings.Join(fields, ", ")), nil
+	default:
+		return "", fmt.Errorf("is a %s, which cannot be fuzzed", t)
+	}
+}
+
+func fuzzConverter(typ, rawVar string) string {
 	switch typ {
-	case "int64":
-		return fmt.Sprintf("meow.NewInt(%s)", rawVar)
 	case "float64":
 		return fmt.Sprintf("meow.NewFloat(%s)", rawVar)
 	case "string":
@@ -234,3 +369,13 @@ func (g *Generator) fuzzConverter(typ, rawVar string) string {
 		return fmt.Sprintf("meow.NewInt(%s)", rawVar)
 	}
 }
+
+// prefixEach is each of items with sep before it.
+func prefixEach(sep string, items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(sep)
+		b.WriteString(item)
+	}
+	return b.String()
+}
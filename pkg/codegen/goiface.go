@@ -0,0 +1,161 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A kitty groomed as a Go interface — `groom Logger as io.Writer` — is handed
+// to Go as an adapter: a struct holding the kitty, with one method for each of
+// the interface's, whose body asks meow.Adapt for the kitty's own method as a
+// Go function of the same signature and calls it. The struct is registered
+// with meow.RegisterAdapter so the bridge can find it when a Go function asks
+// for that interface.
+//
+// The signatures come from the Go package, which only the compiler can read,
+// so they arrive here already written out as Go source; see GoInterface.
+
+// GoInterface is a Go interface a kitty is groomed as, read from the package
+// that declares it.
+type GoInterface struct {
+	// Kitty is the type groomed.
+	Kitty string
+	// Package and Name are the interface as the program wrote it: io and
+	// Writer.
+	Package, Name string
+	// Type is the interface as generated code writes it: go_io.Writer.
+	Type string
+	// Methods are the interface's methods, embedded ones included.
+	Methods []GoMethod
+	// Imports are the packages the signatures name, by the alias they are
+	// written under. A `nab go` package keeps the alias it already has.
+	Imports map[string]string
+}
+
+// GoMethod is one method of a GoInterface.
+type GoMethod struct {
+	// Name is the Go method's name: Write.
+	Name string
+	// Method is the kitty's method that answers it: write.
+	Method string
+	// Params and Results are the Go types of the signature, as generated
+	// code writes them.
+	Params, Results []string
+	// Variadic marks a last parameter written ...T; its entry in Params is
+	// the T.
+	Variadic bool
+}
+
+// SetGoInterfaces sets the Go interfaces the program's kitties are groomed as.
+func (g *Generator) SetGoInterfaces(ifaces []GoInterface) {
+	g.goInterfaces = ifaces
+}
+
+// adapterName is the Go type a kitty is handed to Go as, for one interface.
+func adapterName(iface GoInterface) string {
+	return fmt.Sprintf("meow_adapter_%s_%s_%s", iface.Kitty, iface.Package, iface.Name)
+}
+
+// genAdapters generates the adapter types.
+func (g *Generator) genAdapters() []string {
+	var decls []string
+	for _, iface := range g.goInterfaces {
+		var b strings.Builder
+		name := adapterName(iface)
+		fmt.Fprintf(&b, "type %s struct{ self meow.Value }\n\n", name)
+		// The Go compiler says so here if the signatures were read wrongly,
+		// rather than wherever the adapter is first handed over.
+		fmt.Fprintf(&b, "var _ %s = %s{}\n", iface.Type, name)
+		for _, m := range iface.Methods {
+			b.WriteString("\n")
+			b.WriteString(genAdapterMethod(name, m))
+		}
+		decls = append(decls, strings.TrimSuffix(b.String(), "\n"))
+	}
+	return decls
+}
+
+// genAdapterMethod generates one method of an adapter.
+func genAdapterMethod(adapter string, m GoMethod) string {
+	params := make([]string, len(m.Params))
+	paramTypes := make([]string, len(m.Params))
+	args := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		paramTypes[i] = p
+		args[i] = fmt.Sprintf("p%d", i)
+		if m.Variadic && i == len(m.Params)-1 {
+			paramTypes[i] = "..." + p
+			args[i] += "..."
+		}
+		params[i] = fmt.Sprintf("p%d %s", i, paramTypes[i])
+	}
+	results := strings.Join(m.Results, ", ")
+	switch {
+	case len(m.Results) > 1:
+		results = " (" + results + ")"
+	case len(m.Results) == 1:
+		results = " " + results
+	}
+	fnType := fmt.Sprintf("func(%s)%s", strings.Join(paramTypes, ", "), results)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func (a %s) %s(%s)%s {\n", adapter, m.Name, strings.Join(params, ", "), results)
+	call := fmt.Sprintf("meow.Adapt[%s](a.self, %q)(%s)", fnType, m.Method, strings.Join(args, ", "))
+	if len(m.Results) > 0 {
+		fmt.Fprintf(&b, "\treturn %s\n", call)
+	} else {
+		fmt.Fprintf(&b, "\t%s\n", call)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// genAdapterRegistrations registers each adapter with the kitty it is for.
+func (g *Generator) genAdapterRegistrations() string {
+	var b strings.Builder
+	for _, iface := range g.goInterfaces {
+		fmt.Fprintf(&b, "\tmeow.RegisterAdapter(%q, func(v meow.Value) any { return %s{v} })\n",
+			iface.Kitty, adapterName(iface))
+	}
+	return b.String()
+}
+
+// goImportLines writes the import lines of the `nab go` packages the program
+// calls, and of the packages an adapter's signatures name. A `nab go` package
+// an adapter names is imported under its own alias, even if nothing calls it.
+func (g *Generator) goImportLines() string {
+	used := make(map[string]bool)
+	for _, name := range g.usedGoImports() {
+		used[name] = true
+	}
+	extra := make(map[string]string)
+	for _, iface := range g.goInterfaces {
+		for alias, path := range iface.Imports {
+			name, isGo := strings.CutPrefix(alias, "go_")
+			if isGo && g.goImports[name] == path {
+				used[name] = true
+				continue
+			}
+			extra[alias] = path
+		}
+	}
+
+	var b strings.Builder
+	for _, alias := range sortedKeys(used) {
+		fmt.Fprintf(&b, "import go_%s \"%s\"\n", alias, g.goImports[alias])
+	}
+	for _, alias := range sortedKeys(extra) {
+		fmt.Fprintf(&b, "import %s \"%s\"\n", alias, extra[alias])
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
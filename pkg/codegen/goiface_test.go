@@ -0,0 +1,67 @@
+package codegen_test
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+func TestAGroomedKittyGetsAnAdapter(t *testing.T) {
+	src := `nab go "log/slog"
+
+kitty Collector {
+  level: int
+}
+
+groom Collector as slog.Handler {
+  meow handle(ctx, record) {
+    nya(record)
+  }
+  meow log_attrs(msg string, attrs) {
+    nya(msg)
+  }
+}
+`
+	prog, errs := parser.New(lexer.New(src, "test.nyan").Tokens()).Parse()
+	if len(errs) > 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	g := codegen.New()
+	g.SetGoInterfaces([]codegen.GoInterface{{
+		Kitty:   "Collector",
+		Package: "slog",
+		Name:    "Handler",
+		Type:    "go_slog.Handler",
+		Methods: []codegen.GoMethod{
+			{Name: "Handle", Method: "handle", Params: []string{"goiface_context.Context", "go_slog.Record"}, Results: []string{"error"}},
+			{Name: "LogAttrs", Method: "log_attrs", Params: []string{"string", "go_slog.Attr"}, Variadic: true},
+		},
+		Imports: map[string]string{"go_slog": "log/slog", "goiface_context": "context"},
+	}})
+	code, err := g.Generate(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`import go_slog "log/slog"`,
+		`import goiface_context "context"`,
+		"type meow_adapter_Collector_slog_Handler struct{ self meow.Value }",
+		"var _ go_slog.Handler = meow_adapter_Collector_slog_Handler{}",
+		"func (a meow_adapter_Collector_slog_Handler) Handle(p0 goiface_context.Context, p1 go_slog.Record) error {\n" +
+			"\treturn meow.Adapt[func(goiface_context.Context, go_slog.Record) error](a.self, \"handle\")(p0, p1)\n}",
+		"func (a meow_adapter_Collector_slog_Handler) LogAttrs(p0 string, p1 ...go_slog.Attr) {\n" +
+			"\tmeow.Adapt[func(string, ...go_slog.Attr)](a.self, \"log_attrs\")(p0, p1...)\n}",
+		`meow.RegisterAdapter("Collector", func(v meow.Value) any { return meow_adapter_Collector_slog_Handler{v} })`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %q in:\n%s", want, code)
+		}
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Errorf("generated code does not parse: %v\n%s", err, code)
+	}
+}
@@ -0,0 +1,51 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+)
+
+// testHooks are the functions a test file sets up and cleans up with, by the
+// name it gives them, and the field of meow_testing.Hooks each goes in.
+var testHooks = []struct{ name, field string }{
+	{"before_all", "BeforeAll"},
+	{"after_all", "AfterAll"},
+	{"before_each", "BeforeEach"},
+	{"after_each", "AfterEach"},
+}
+
+// markHook notes fn when it is one of the testHooks.
+func (g *Generator) markHook(fn *ast.FuncStmt) error {
+	for _, h := range testHooks {
+		if fn.Name != h.name {
+			continue
+		}
+		if len(fn.Params) != 0 {
+			return fmt.Errorf("hook %s must not take parameters", fn.Name)
+		}
+		if g.hookFuncs == nil {
+			g.hookFuncs = make(map[string]bool)
+		}
+		g.hookFuncs[fn.Name] = true
+	}
+	return nil
+}
+
+// emitHooks hands the file's hooks to the test runtime, ahead of its tests.
+func (g *Generator) emitHooks(b *strings.Builder) {
+	if len(g.hookFuncs) == 0 {
+		return
+	}
+	b.WriteString("\tmeow_testing.SetHooks(meow_testing.Hooks{\n")
+	for _, h := range testHooks {
+		if !g.hookFuncs[h.name] {
+			continue
+		}
+		fmt.Fprintf(b, "\t\t%s: func(args ...meow.Value) meow.Value {\n", h.field)
+		fmt.Fprintf(b, "\t\t\treturn %s()\n", h.name)
+		b.WriteString("\t\t},\n")
+	}
+	b.WriteString("\t})\n")
+}
@@ -0,0 +1,43 @@
+package codegen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+func TestHooksAreHandedToTheRuntime(t *testing.T) {
+	code := generateTest(t, `meow before_each() {
+  nya("fresh")
+}
+
+meow after_all() {
+  nya("done")
+}
+
+meow test_a() {
+  expect(1, 1)
+}
+`)
+	want := "\tmeow_testing.SetHooks(meow_testing.Hooks{\n" +
+		"\t\tAfterAll: func(args ...meow.Value) meow.Value {\n\t\t\treturn after_all()\n\t\t},\n" +
+		"\t\tBeforeEach: func(args ...meow.Value) meow.Value {\n\t\t\treturn before_each()\n\t\t},\n" +
+		"\t})\n\tmeow_testing.Suite(\n"
+	if !strings.Contains(code, want) {
+		t.Errorf("missing %q in:\n%s", want, code)
+	}
+}
+
+func TestAHookTakingParametersIsRefused(t *testing.T) {
+	prog, errs := parser.New(lexer.New("meow before_each(x) {\n  nya(x)\n}\n", "test_file.nyan").Tokens()).Parse()
+	if len(errs) > 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	_, err := codegen.NewTest().GenerateTest(prog)
+	if err == nil || !strings.Contains(err.Error(), "hook before_each must not take parameters") {
+		t.Errorf("GenerateTest = %v, want the hook refused", err)
+	}
+}
@@ -0,0 +1,275 @@
+package codegen
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/token"
+)
+
+// A library is the other way round from a program: Go calls into Meow rather
+// than Meow being all there is. What is generated is an ordinary Go package —
+// no main, no process to end — whose exported functions are the Meow ones
+// under Go's spelling, so `meow price_with_tax` is called as PriceWithTax.
+//
+// The Meow functions themselves are generated exactly as they are for a
+// program, and keep their Meow names so the calls between them are untouched.
+// What is exported is a thin function in front of each one, with the same
+// signature: native Go types for a function typed all the way through, since
+// that is what genTypedFuncDecl already gives it, and meow.Value for one that
+// is not.
+
+// DocComments maps top-level function names to the comment written directly
+// above them, which becomes the Go doc comment of what they are exported as.
+type DocComments map[string]string
+
+// ExtractDocComments scans a token stream for the comments written directly
+// above each top-level meow, the way a Go doc comment sits above a func.
+//
+// A comment is only a function's if nothing comes between them, not even a
+// blank line, and only if it begins its own line: a note trailing the last
+// statement of something else is about that statement.
+func ExtractDocComments(tokens iter.Seq[token.Token]) DocComments {
+	result := make(DocComments)
+	var pending []string
+	depth := 0
+	lineStart := true
+	blank := false
+	awaitingName := false
+
+	for t := range tokens {
+		switch t.Type {
+		case token.NEWLINE:
+			// A newline straight after another one is a blank line, which
+			// parts a comment from whatever comes after it.
+			if blank {
+				pending = nil
+			}
+			blank = true
+			lineStart = true
+			continue
+		case token.COMMENT:
+			if lineStart && depth == 0 {
+				pending = append(pending, commentLines(t)...)
+			} else {
+				pending = nil
+			}
+			blank = false
+			continue
+		}
+		blank = false
+		lineStart = false
+
+		switch t.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		case token.TRILL:
+			// trill goes in front of the meow it marks, so the comment is
+			// still the function's.
+			continue
+		case token.MEOW:
+			if depth == 0 {
+				awaitingName = true
+				continue
+			}
+		case token.IDENT:
+			if awaitingName && len(pending) > 0 {
+				result[t.Literal] = strings.Join(pending, "\n")
+			}
+		}
+		awaitingName = false
+		pending = nil
+	}
+	return result
+}
+
+// commentLines gives the text of a comment, one line at a time, without the
+// marks that made it one.
+func commentLines(t token.Token) []string {
+	if !t.BlockComment {
+		line := strings.TrimPrefix(t.Literal, "#")
+		return []string{strings.TrimPrefix(line, " ")}
+	}
+	text := strings.Trim(t.Literal, "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// NewLib creates a code generator for a library: a Go package named pkg,
+// generated from the file named source.
+func NewLib(pkg, source string) *Generator {
+	return &Generator{libPackage: pkg, libSource: source}
+}
+
+// SetDocComments sets the comments that become the exported functions' docs.
+func (g *Generator) SetDocComments(dc DocComments) {
+	g.docComments = dc
+}
+
+// GenerateLib produces the Go source of a library package from a Program AST.
+//
+// Top-level statements run when the package is initialised, which is when a
+// binding written there gets its value; a Furball they end on is a panic,
+// since a package that cannot initialise has nothing to hand back.
+func (g *Generator) GenerateLib(prog *ast.Program) (string, error) {
+	if err := g.collect(prog); err != nil {
+		return "", err
+	}
+	var exports []string
+	exportedAs := make(map[string]string)
+	for _, stmt := range prog.Stmts {
+		fn, ok := stmt.(*ast.FuncStmt)
+		if !ok || !isLibExport(fn.Name) {
+			continue
+		}
+		name := ast.GoName(fn.Name)
+		if other, taken := exportedAs[name]; taken {
+			return "", fmt.Errorf("Hiss! %s and %s would both be exported as %s, nya~", other, fn.Name, name)
+		}
+		exportedAs[name] = fn.Name
+		// A function whose name is already Go's exported spelling is exported
+		// as itself, with nothing in front of it.
+		if name != fn.Name {
+			exports = append(exports, g.genLibExport(fn, name))
+		}
+	}
+	return g.emitLib(exports), nil
+}
+
+// isLibExport reports whether a top-level function is part of what a library
+// offers. Tests stay behind, and so does a name written with a leading
+// underscore, which is how a library keeps a helper to itself.
+func isLibExport(name string) bool {
+	for _, prefix := range []string{"test_", "catwalk_", "fuzz_", "_"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return !isGeneratedName(name)
+}
+
+// genLibExport generates the exported function in front of a Meow one.
+func (g *Generator) genLibExport(fn *ast.FuncStmt, name string) string {
+	var b strings.Builder
+	b.WriteString(g.libDoc(fn, name))
+	params := make([]string, len(fn.Params))
+	names := make([]string, len(fn.Params))
+	ret := "meow.Value"
+	if g.isFullyTypedFunc(fn) {
+		ft := g.typeInfo.FuncTypes[fn.Name]
+		for i, p := range fn.Params {
+			params[i] = p.Name + " " + goTypeString(ft.Params[i])
+			names[i] = p.Name
+		}
+		ret = goTypeString(ft.Return)
+	} else {
+		for i, p := range fn.Params {
+			params[i] = p.Name + " meow.Value"
+			names[i] = p.Name
+		}
+	}
+	fmt.Fprintf(&b, "func %s(%s) %s {\n", name, strings.Join(params, ", "), ret)
+	fmt.Fprintf(&b, "\treturn %s(%s)\n", fn.Name, strings.Join(names, ", "))
+	b.WriteString("}")
+	return b.String()
+}
+
+// libDoc writes the doc comment of what a Meow function is exported as.
+//
+// The comment written above it in Meow is its doc, with the Meow name at its
+// start swapped for the Go one so it reads the way Go docs do. Whether the
+// answer can be a Furball is said either way, since that is the one thing a
+// Go caller cannot tell from the signature.
+func (g *Generator) libDoc(fn *ast.FuncStmt, name string) string {
+	var lines []string
+	doc := g.docComments[fn.Name]
+	switch {
+	case doc == "":
+		lines = append(lines, fmt.Sprintf("%s is %s, from %s.", name, fn.Name, g.libSource))
+	case strings.HasPrefix(doc, fn.Name+" "):
+		lines = append(lines, strings.Split(name+strings.TrimPrefix(doc, fn.Name), "\n")...)
+	default:
+		lines = append(lines, fmt.Sprintf("%s is %s, from %s.", name, fn.Name, g.libSource), "")
+		lines = append(lines, strings.Split(doc, "\n")...)
+	}
+	lines = append(lines, "")
+	if g.isFullyTypedFunc(fn) {
+		lines = append(lines, "A hiss inside it panics with the Furball's message.")
+	} else {
+		lines = append(lines, "It takes and gives back Meow values, and a failure comes back as a Furball.")
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+		fmt.Fprintf(&b, "// %s\n", line)
+	}
+	return b.String()
+}
+
+// emitLib lays out a library package.
+func (g *Generator) emitLib(exports []string) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by meow compiler. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "// Package %s is generated by meow build --lib from %s.\n", g.libPackage, g.libSource)
+	fmt.Fprintf(&b, "package %s\n\n", g.libPackage)
+	needsMeow := g.needsMeowImport()
+	for _, e := range exports {
+		if strings.Contains(e, "meow.") {
+			needsMeow = true
+		}
+	}
+	if needsMeow {
+		b.WriteString("import meow \"github.com/135yshr/meow/runtime/meowrt\"\n")
+	}
+	for _, name := range g.usedImports() {
+		fmt.Fprintf(&b, "import meow_%s \"%s\"\n", name, g.imports[name])
+	}
+	b.WriteString(g.goImportLines())
+	b.WriteString("\n")
+
+	b.WriteString(g.genGlobalDecls())
+
+	if initCode := g.genLearnInit(); initCode != "" {
+		b.WriteString(initCode)
+		b.WriteString("\n")
+	}
+
+	for _, e := range exports {
+		b.WriteString(e)
+		b.WriteString("\n\n")
+	}
+
+	for _, fn := range g.funcs {
+		b.WriteString(fn)
+		b.WriteString("\n\n")
+	}
+
+	// The same inner function a program's main calls, for the same reason:
+	// the short-circuit `return __f` needs something to return from.
+	if len(g.topLevel) > 0 {
+		b.WriteString("func __meow_init() meow.Value {\n")
+		for _, line := range g.topLevel {
+			b.WriteString("\t")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		b.WriteString("\treturn meow.NewNil()\n")
+		b.WriteString("}\n\n")
+		b.WriteString("func init() {\n")
+		b.WriteString("\tif f, failed := meow.AsFurball(__meow_init()); failed {\n")
+		b.WriteString("\t\tpanic(meow.Located(f.Message))\n")
+		b.WriteString("\t}\n")
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
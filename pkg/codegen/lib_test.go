@@ -0,0 +1,142 @@
+package codegen_test
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+func generateLib(t *testing.T, input string) (string, error) {
+	t.Helper()
+	p := parser.New(lexer.New(input, "pricing.nyan").Tokens())
+	prog, parseErrs := p.Parse()
+	if len(parseErrs) > 0 {
+		t.Fatalf("parse errors: %v", parseErrs)
+	}
+	ti, errs := checker.New().Check(prog)
+	if len(errs) > 0 {
+		t.Fatalf("checker errors: %v", errs)
+	}
+	g := codegen.NewLib("pricing", "pricing.nyan")
+	g.SetTypeInfo(ti)
+	g.SetDocComments(codegen.ExtractDocComments(lexer.New(input, "pricing.nyan").Tokens()))
+	return g.GenerateLib(prog)
+}
+
+func TestALibraryIsAPackageWithoutMain(t *testing.T) {
+	code, err := generateLib(t, `nyan rate = 10
+
+meow with_tax(price int) int {
+  bring price + price * 10 / 100
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package pricing\n",
+		"func WithTax(price int64) int64 {\n\treturn with_tax(price)\n}",
+		"func init() {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %q in:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "func main()") {
+		t.Errorf("a library has no main:\n%s", code)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Errorf("generated code does not parse: %v\n%s", err, code)
+	}
+}
+
+func TestABoxedFunctionIsExportedWithMeowValues(t *testing.T) {
+	code, err := generateLib(t, `meow count(items litter) int {
+  bring len(items)
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func Count(items meow.Value) meow.Value {") {
+		t.Errorf("expected a boxed export in:\n%s", code)
+	}
+}
+
+func TestWhatALibraryKeepsToItself(t *testing.T) {
+	code, err := generateLib(t, `meow _helper(n int) int {
+  bring n * 2
+}
+
+meow test_helper() {
+  expect(_helper(1), 2)
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unwanted := range []string{"func Helper(", "func TestHelper("} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("did not expect %q in:\n%s", unwanted, code)
+		}
+	}
+}
+
+func TestTwoNamesExportedAsOneAreRefused(t *testing.T) {
+	_, err := generateLib(t, `meow add_one(n int) int {
+  bring n + 1
+}
+
+meow addOne(n int) int {
+  bring n + 1
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "AddOne") {
+		t.Errorf("err = %v, want the clash named", err)
+	}
+}
+
+func TestTheMeowCommentIsTheGoDoc(t *testing.T) {
+	code, err := generateLib(t, `# with_tax adds tax to a price in yen.
+# It rounds down.
+trill meow with_tax(price int) int {
+  bring price + price * 10 / 100
+}
+
+# Not a doc comment: a blank line parts it from the function.
+
+meow plain(n int) int {
+  bring n
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "// WithTax adds tax to a price in yen.\n// It rounds down.\n") {
+		t.Errorf("expected the comment as WithTax's doc in:\n%s", code)
+	}
+	if !strings.Contains(code, "// Plain is plain, from pricing.nyan.\n") {
+		t.Errorf("expected a doc of its own for Plain in:\n%s", code)
+	}
+}
+
+func TestExtractDocCommentsSkipsTrailingAndNestedComments(t *testing.T) {
+	src := `nyan x = 1 # about x
+meow outer() {
+  # about inner
+  meow inner() {
+    bring 1
+  }
+  bring inner()
+}
+`
+	docs := codegen.ExtractDocComments(lexer.New(src, "test.nyan").Tokens())
+	if len(docs) != 0 {
+		t.Errorf("docs = %v, want none", docs)
+	}
+}
@@ -0,0 +1,50 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+)
+
+// markParallel notes a test that begins with parallel(), which says it can
+// run beside the file's other parallel tests. Like Go's t.Parallel(), it is
+// the test's own first word on the matter; anywhere else it would promise
+// something about a test already under way, so it is refused there.
+func (g *Generator) markParallel(fn *ast.FuncStmt) error {
+	for i, stmt := range fn.Body {
+		if !isParallelCall(stmt) {
+			continue
+		}
+		isTest := strings.HasPrefix(fn.Name, "test_") || strings.HasPrefix(fn.Name, "catwalk_")
+		if i != 0 || !isTest {
+			return fmt.Errorf("parallel() has to be the first statement of a test_ or catwalk_ function, not in %s", fn.Name)
+		}
+		if g.parallelFuncs == nil {
+			g.parallelFuncs = make(map[string]bool)
+		}
+		g.parallelFuncs[fn.Name] = true
+	}
+	return nil
+}
+
+// parallelField is what a test's Case says about it running in parallel.
+func (g *Generator) parallelField(name string) string {
+	if g.parallelFuncs[name] {
+		return ", Parallel: true"
+	}
+	return ""
+}
+
+func isParallelCall(stmt ast.Stmt) bool {
+	es, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := es.Expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	ident, ok := call.Fn.(*ast.Ident)
+	return ok && ident.Name == "parallel"
+}
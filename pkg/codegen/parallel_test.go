@@ -0,0 +1,51 @@
+package codegen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/codegen"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+)
+
+func TestATestBeginningWithParallelIsMarked(t *testing.T) {
+	code := generateTest(t, `meow test_alone() {
+  expect(1, 1)
+}
+
+meow test_beside() {
+  parallel()
+  expect(2, 2)
+}
+`)
+	if !strings.Contains(code, "return test_beside()\n\t\t}, Parallel: true},") {
+		t.Errorf("test_beside is not marked parallel:\n%s", code)
+	}
+	if strings.Contains(code, "return test_alone()\n\t\t}, Parallel: true},") {
+		t.Errorf("test_alone is marked parallel:\n%s", code)
+	}
+}
+
+func TestParallelAnywhereButFirstIsRefused(t *testing.T) {
+	for name, src := range map[string]string{
+		"late": `meow test_late() {
+  expect(1, 1)
+  parallel()
+}
+`,
+		"not a test": `meow helper() {
+  parallel()
+}
+`,
+	} {
+		prog, errs := parser.New(lexer.New(src, "test_file.nyan").Tokens()).Parse()
+		if len(errs) > 0 {
+			t.Fatalf("%s: parse errors: %v", name, errs)
+		}
+		_, err := codegen.NewTest().GenerateTest(prog)
+		if err == nil || !strings.Contains(err.Error(), "parallel() has to be the first statement") {
+			t.Errorf("%s: GenerateTest = %v, want parallel() refused", name, err)
+		}
+	}
+}
@@ -438,10 +438,11 @@ func needsSpaceBefore(toks []token.Token, idx int, prev token.TokenType) bool {
 		return isBlockKeyword(prev) || opensALoopSubject(toks, idx)
 	}
 	// LBRACKET: an index reaches back into whatever it follows, so it sits
-	// tight against it — `resp["body"]`, not `resp ["body"]`. Opening a litter
-	// it is a value like any other and takes the spacing of what came before.
+	// tight against it — `resp["body"]`, not `resp ["body"]`, and so does what
+	// a litter or basket type holds, `litter[int]`. Opening a litter it is a
+	// value like any other and takes the spacing of what came before.
 	if cur == token.LBRACKET {
-		return !isExpressionEnd(prev)
+		return !isExpressionEnd(prev) && prev != token.TYPE_LITTER && prev != token.TYPE_BASKET
 	}
 	// NOT operator: no space after
 	if prev == token.NOT {
@@ -57,6 +57,21 @@ func TestFormatOperatorSpacing(t *testing.T) {
 	}
 }
 
+func TestFormatElemTypeSpacing(t *testing.T) {
+	input := `meow f(xs litter [int], m basket[ string ]) int {
+bring 0
+}
+`
+	want := `meow f(xs litter[int], m basket[string]) int {
+  bring 0
+}
+`
+	got := format(t, input)
+	if got != want {
+		t.Errorf("element type spacing mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 func TestFormatCommentPreservation(t *testing.T) {
 	input := `# this is a comment
 nyan x = 1
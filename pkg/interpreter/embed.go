@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/parser"
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// Embedding: a Go program using Meow as its scripting language.
+//
+// The playground hands the interpreter a whole program and reads back what it
+// printed. A service that keeps its pricing rules or its config in Meow wants
+// the other way round: the program is a library of functions, and the service
+// calls into it — with its own values, and with functions of its own the
+// program can call back. Define is how those go in, Load runs the program so
+// its functions are there to be called, and Call is how one is reached.
+//
+// Values cross over the same bridge a `nab go` call uses, so a Go record read
+// in comes out as a basket, and a basket read back is filled into a record by
+// meowrt.ToGo the way an argument to a Go function would be.
+
+// Define makes a Go value known to the program by name, before it is loaded.
+//
+// A Meow value goes in as it is. A Go function becomes one Meow can call, with
+// its arguments read the way a `nab go` call reads them and a trailing error
+// turned into a Furball; a function asking for a context is handed the one the
+// run was given. Anything else is read with meowrt.FromGo.
+func (interp *Interpreter) Define(name string, v any) {
+	interp.hostNames = append(interp.hostNames, name)
+	interp.globals.Define(name, interp.hostValue(name, v))
+}
+
+// hostValue reads a value a host defines as the Meow value the program sees.
+func (interp *Interpreter) hostValue(name string, v any) meowrt.Value {
+	if mv, ok := v.(meowrt.Value); ok {
+		return mv
+	}
+	fn := reflect.ValueOf(v)
+	if !fn.IsValid() || fn.Kind() != reflect.Func {
+		return meowrt.FromGo(v)
+	}
+	t := fn.Type()
+	arity := t.NumIn()
+	if arity > 0 && t.In(0) == reflect.TypeFor[context.Context]() {
+		arity--
+	}
+	// A variadic function takes however many it is given, and a Func with a
+	// fixed arity would wait for more before calling it.
+	if t.IsVariadic() {
+		arity = -1
+	}
+	return meowrt.NewFuncWithArity(name, arity, func(args ...meowrt.Value) meowrt.Value {
+		ctx := interp.ctx
+		if ctx == nil {
+			return meowrt.CallGo(name, v, args...)
+		}
+		return meowrt.CallGoContext(ctx, name, v, args...)
+	})
+}
+
+// Load parses, checks and runs a program, leaving its functions and bindings
+// there for Call. filename is what errors say the source was read from.
+//
+// Names given to Define are known to the checker, so a program may call what
+// the host provides without being told it is undefined.
+func (interp *Interpreter) Load(ctx context.Context, filename, src string) error {
+	l := lexer.New(src, filename)
+	p := parser.New(l.Tokens())
+	prog, parseErrs := p.Parse()
+	if len(parseErrs) > 0 {
+		errs := make([]error, len(parseErrs))
+		for i, e := range parseErrs {
+			errs[i] = e
+		}
+		return errors.Join(errs...)
+	}
+
+	c := checker.New()
+	for _, name := range interp.hostNames {
+		c.Declare(name)
+	}
+	ti, checkErrs := c.Check(prog)
+	if len(checkErrs) > 0 {
+		errs := make([]error, len(checkErrs))
+		for i, e := range checkErrs {
+			errs[i] = e
+		}
+		return errors.Join(errs...)
+	}
+	interp.SetTypeInfo(ti)
+	return interp.RunSafe(ctx, prog)
+}
+
+// Call calls a function the loaded program defines, reading each argument with
+// meowrt.FromGo. The answer is the Meow value the function brought back; read
+// it into a Go one with meowrt.ToGo.
+//
+// Every call is a run of its own, held to the interpreter's limits and to ctx
+// the way RunSafe is. A Furball the function answers with is the call's error
+// rather than its value, as it would be the program's had it reached the top.
+func (interp *Interpreter) Call(ctx context.Context, name string, args ...any) (meowrt.Value, error) {
+	if !interp.globals.Has(name) {
+		return nil, fmt.Errorf("Hiss! undefined function %s, nya~", name)
+	}
+	fn, ok := interp.globals.Get(name).(*meowrt.Func)
+	if !ok {
+		return nil, fmt.Errorf("Hiss! %s is not callable, nya~", name)
+	}
+	in := make([]meowrt.Value, len(args))
+	for i, a := range args {
+		in[i] = meowrt.FromGo(a)
+	}
+
+	interp.resetBudget()
+	interp.exitCode = 0
+	meowrt.Here("")
+	var result meowrt.Value
+	err := interp.guard(ctx, func() {
+		defer func() {
+			// A function that scrams has ended what it was asked to do, not
+			// failed at it, the same as a program that does.
+			if r := recover(); r != nil {
+				sig, ok := r.(meowrt.ScramSignal)
+				if !ok {
+					panic(r)
+				}
+				interp.exitCode = sig.Code
+				result = meowrt.NewNil()
+			}
+		}()
+		result = meowrt.Call(fn, in...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := result.(*meowrt.Furball); ok && !f.Handled {
+		return nil, fmt.Errorf("%s", meowrt.Located(f.Message))
+	}
+	return result, nil
+}
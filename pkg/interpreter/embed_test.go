@@ -0,0 +1,209 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+func TestAHostCallsAFunctionTheProgramDefines(t *testing.T) {
+	interp := New(&bytes.Buffer{})
+	src := `meow price(base int, qty int) int {
+  bring base * qty
+}
+`
+	if err := interp.Load(context.Background(), "pricing.nyan", src); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got, err := interp.Call(context.Background(), "price", 7, 6)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	var n int
+	if err := meowrt.ToGo(got, &n); err != nil {
+		t.Fatalf("ToGo: %v", err)
+	}
+	if n != 42 {
+		t.Errorf("price(7, 6) = %d, want 42", n)
+	}
+}
+
+func TestTheProgramCallsWhatTheHostDefines(t *testing.T) {
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.Define("discount", func(percent int) float64 { return 1 - float64(percent)/100 })
+	interp.Define("currency", "JPY")
+	interp.Define("shout", meowrt.NewFunc("shout", func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewString(strings.ToUpper(args[0].String()))
+	}))
+	src := `nya(shout(currency))
+meow total(n int) float {
+  bring to_float(n) * discount(10)
+}
+`
+	if err := interp.Load(context.Background(), "host.nyan", src); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "JPY" {
+		t.Errorf("output = %q, want %q", got, "JPY")
+	}
+	got, err := interp.Call(context.Background(), "total", 200)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	var f float64
+	if err := meowrt.ToGo(got, &f); err != nil {
+		t.Fatalf("ToGo: %v", err)
+	}
+	if f != 180 {
+		t.Errorf("total(200) = %v, want 180", f)
+	}
+}
+
+func TestABasketComesBackAsARecord(t *testing.T) {
+	type quote struct {
+		Item  string
+		Total int
+	}
+	interp := New(&bytes.Buffer{})
+	src := `meow quote(item string, total int) basket {
+  bring {"item": item, "total": total}
+}
+`
+	if err := interp.Load(context.Background(), "quote.nyan", src); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got, err := interp.Call(context.Background(), "quote", "tuna", 300)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	var q quote
+	if err := meowrt.ToGo(got, &q); err != nil {
+		t.Fatalf("ToGo: %v", err)
+	}
+	if q != (quote{Item: "tuna", Total: 300}) {
+		t.Errorf("quote = %+v", q)
+	}
+}
+
+func TestAHostErrorIsAFurballTheProgramCanCatch(t *testing.T) {
+	interp := New(&bytes.Buffer{})
+	interp.Define("lookup", func(key string) (string, error) {
+		if key == "" {
+			return "", errors.New("no key")
+		}
+		return "found " + key, nil
+	})
+	src := `meow find(key string) string {
+  bring lookup(key) ~> "default"
+}
+meow strict(key string) string {
+  bring lookup(key)
+}
+`
+	if err := interp.Load(context.Background(), "lookup.nyan", src); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got, err := interp.Call(context.Background(), "find", "")
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if got.String() != "default" {
+		t.Errorf("find(\"\") = %s, want default", got)
+	}
+	if _, err := interp.Call(context.Background(), "strict", ""); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("strict(\"\") error = %v, want the host's error", err)
+	}
+}
+
+func TestAHostFunctionIsHandedTheRunsContext(t *testing.T) {
+	type key struct{}
+	interp := New(&bytes.Buffer{})
+	interp.Define("who", func(ctx context.Context) string {
+		s, _ := ctx.Value(key{}).(string)
+		return s
+	})
+	if err := interp.Load(context.Background(), "who.nyan", "meow ask() string {\n  bring who()\n}\n"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), key{}, "tama")
+	got, err := interp.Call(ctx, "ask")
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if got.String() != "tama" {
+		t.Errorf("ask() = %s, want tama", got)
+	}
+}
+
+func TestLoadReportsWhatIsWrongWithTheProgram(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"a parse error", "meow (\n", "broken.nyan"},
+		{"an undefined name", "nya(nosuch)\n", "undefined variable nosuch"},
+		{"a failure at the top level", `hiss("nope")` + "\n", "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp := New(&bytes.Buffer{})
+			err := interp.Load(context.Background(), "broken.nyan", tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallSaysWhenThereIsNothingToCall(t *testing.T) {
+	interp := New(&bytes.Buffer{})
+	if err := interp.Load(context.Background(), "x.nyan", "nyan rate = 3\n"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for name, want := range map[string]string{
+		"missing": "undefined function missing",
+		"rate":    "rate is not callable",
+	} {
+		if _, err := interp.Call(context.Background(), name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Call(%q) error = %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestEveryCallIsHeldToTheLimits(t *testing.T) {
+	interp := New(&bytes.Buffer{})
+	interp.SetStepLimit(10_000)
+	src := `meow spin() {
+  purr (yarn) {
+    nyan n = 1
+  }
+}
+meow quick() int {
+  bring 1
+}
+`
+	if err := interp.Load(context.Background(), "spin.nyan", src); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := interp.Call(context.Background(), "spin"); err == nil || !strings.Contains(err.Error(), "step limit exceeded") {
+		t.Errorf("spin() error = %v, want the step limit", err)
+	}
+	// The limit a call ran into is its own; the next call starts afresh.
+	if _, err := interp.Call(context.Background(), "quick"); err != nil {
+		t.Errorf("quick() after spin() = %v", err)
+	}
+
+	interp.SetStepLimit(1 << 40)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := interp.Call(ctx, "spin"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("spin() under a deadline = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
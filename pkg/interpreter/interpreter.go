@@ -1,8 +1,13 @@
 package interpreter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/135yshr/meow/pkg/ast"
 	"github.com/135yshr/meow/pkg/checker"
@@ -27,6 +32,23 @@ type returnSignal struct {
 // stepLimitExceeded signals that the step limit was reached.
 type stepLimitExceeded struct{}
 
+// allocLimitExceeded signals that a program asked for more than it may hold:
+// what names the limit, so the error can say which one it was.
+type allocLimitExceeded struct {
+	what  string
+	limit int64
+}
+
+// runStopped signals that the context the run was given is done — its deadline
+// passed, or whoever started it gave up waiting.
+type runStopped struct {
+	err error
+}
+
+// ctxPollEvery is how many steps go by between looks at the run's context.
+// Asking on every step would cost more than most steps do.
+const ctxPollEvery = 1024
+
 // boltSignal signals that the loop should be left, and slinkSignal that this
 // turn is over. They are raised where they are written and caught by the
 // enclosing loop, the way bring is caught by the enclosing function.
@@ -45,6 +67,26 @@ type Interpreter struct {
 	stepCount  int64
 	stepLimit  int64
 	exitCode   int
+	// ctx is the context RunSafe was handed, looked at every ctxPollEvery
+	// steps. A plain Run has none, and runs until it is done.
+	ctx context.Context
+	// elementCount and byteCount add up what the run has grown so far: the
+	// elements and bytes each litter, basket and string it built has over the
+	// biggest of what it was built from. An append is charged for the element
+	// it adds and a join for the bytes it adds, not for the copy either makes
+	// of the rest, so a program building a long litter a step at a time is
+	// charged for the litter rather than for every litter on the way to it.
+	elementCount int64
+	elementLimit int64
+	byteCount    int64
+	byteLimit    int64
+	// stopped is the limit the run has already hit, if any. It is raised
+	// again on every step after, because gag recovers whatever it is handed
+	// and a limit it could swallow would be no limit at all.
+	stopped any
+	// hostNames are the names Define has given the program, which the
+	// checker is told about when Load checks it.
+	hostNames []string
 }
 
 // New creates a new Interpreter that writes output to w.
@@ -56,6 +98,10 @@ func New(w io.Writer) *Interpreter {
 		collarDefs: make(map[string]*ast.CollarStmt),
 		funcDefs:   make(map[string]*ast.FuncStmt),
 		stepLimit:  10_000_000,
+		// Generous for anything a playground program means to do, and far
+		// short of the gigabytes one shred of a long enough string can ask for.
+		elementLimit: 10_000_000,
+		byteLimit:    128 << 20,
 	}
 }
 
@@ -69,14 +115,48 @@ func (interp *Interpreter) SetStepLimit(limit int64) {
 	interp.stepLimit = limit
 }
 
+// SetElementLimit sets how many litter and basket elements a run may allocate
+// in all.
+func (interp *Interpreter) SetElementLimit(limit int64) {
+	interp.elementLimit = limit
+}
+
+// SetStringLimit sets how many bytes of string a run may allocate in all.
+func (interp *Interpreter) SetStringLimit(limit int64) {
+	interp.byteLimit = limit
+}
+
 // RunSafe executes the program and returns any error (including panics).
-func (interp *Interpreter) RunSafe(prog *ast.Program) (err error) {
+//
+// The run ends early when ctx is done, which is how a caller puts a wall-clock
+// limit on it: the step limit bounds how much a program does, not how long it
+// takes to do it. The error then wraps ctx's own, so errors.Is can tell a
+// deadline from a cancellation.
+func (interp *Interpreter) RunSafe(ctx context.Context, prog *ast.Program) error {
+	return interp.guard(ctx, func() { interp.Run(prog) })
+}
+
+// guard runs fn under ctx, turning whatever stops it — a limit, a cancelled
+// context, a failure the program did not catch — into the error returned.
+func (interp *Interpreter) guard(ctx context.Context, fn func()) (err error) {
+	interp.ctx = ctx
+	defer func() { interp.ctx = nil }()
 	defer func() {
 		if r := recover(); r != nil {
-			switch r.(type) {
+			switch sig := r.(type) {
 			case stepLimitExceeded:
 				err = fmt.Errorf("%s", meowrt.Located(
 					fmt.Sprintf("Hiss! step limit exceeded (%d steps), nya~", interp.stepLimit)))
+			case allocLimitExceeded:
+				err = fmt.Errorf("%s", meowrt.Located(
+					fmt.Sprintf("Hiss! %s limit exceeded (%d), nya~", sig.what, sig.limit)))
+			case runStopped:
+				what := "run cancelled"
+				if errors.Is(sig.err, context.DeadlineExceeded) {
+					what = "time limit exceeded"
+				}
+				err = fmt.Errorf("%s: %w", meowrt.Located(
+					fmt.Sprintf("Hiss! %s, nya~", what)), sig.err)
 			default:
 				// Prefixed with where the program was, the way a compiled one
 				// reports a failure, so the same program reads the same either
@@ -89,7 +169,7 @@ func (interp *Interpreter) RunSafe(prog *ast.Program) (err error) {
 			}
 		}
 	}()
-	interp.Run(prog)
+	fn()
 	return nil
 }
 
@@ -105,7 +185,7 @@ func (interp *Interpreter) ExitCode() int {
 // where it asked to and keeps whatever it printed on the way.
 func (interp *Interpreter) Run(prog *ast.Program) {
 	meowrt.ClearMethods()
-	interp.stepCount = 0
+	interp.resetBudget()
 	interp.exitCode = 0
 	// The playground runs one program after another in the same process, so a
 	// position left over from the last one must not be reported against this.
@@ -148,13 +228,89 @@ func (interp *Interpreter) Run(prog *ast.Program) {
 	}
 }
 
+// resetBudget gives the next run the whole of every limit again.
+func (interp *Interpreter) resetBudget() {
+	interp.stepCount = 0
+	interp.elementCount = 0
+	interp.byteCount = 0
+	interp.stopped = nil
+}
+
 func (interp *Interpreter) checkStep() {
+	if interp.stopped != nil {
+		panic(interp.stopped)
+	}
 	interp.stepCount++
 	if interp.stepCount > interp.stepLimit {
-		panic(stepLimitExceeded{})
+		interp.stop(stepLimitExceeded{})
+	}
+	if interp.ctx != nil && interp.stepCount%ctxPollEvery == 0 {
+		interp.pollContext()
+	}
+}
+
+// pollContext ends the run if its context is done.
+//
+// The deadline is read off the clock as well as asked of the context. The
+// playground runs on a single thread with nothing to preempt a busy program,
+// so the timer that would mark the context done may not get to run until the
+// program already has — which is exactly the program the deadline is for.
+func (interp *Interpreter) pollContext() {
+	if err := interp.ctx.Err(); err != nil {
+		interp.stop(runStopped{err: err})
+	}
+	if deadline, ok := interp.ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		interp.stop(runStopped{err: context.DeadlineExceeded})
+	}
+}
+
+// stop ends the run with the given signal, and remembers it so that checkStep
+// raises it again should anything recover it on the way up.
+func (interp *Interpreter) stop(sig any) {
+	interp.stopped = sig
+	panic(sig)
+}
+
+// reserve charges the run for elements and bytes it is about to allocate,
+// ending it if that takes it past either limit.
+func (interp *Interpreter) reserve(elements, bytes int64) {
+	interp.elementCount += elements
+	if interp.elementCount > interp.elementLimit {
+		interp.stop(allocLimitExceeded{what: "element", limit: interp.elementLimit})
+	}
+	interp.byteCount += bytes
+	if interp.byteCount > interp.byteLimit {
+		interp.stop(allocLimitExceeded{what: "string byte", limit: interp.byteLimit})
 	}
 }
 
+// charge charges the run for a value it has just built, beyond the biggest of
+// the values it was built from.
+func (interp *Interpreter) charge(v meowrt.Value, from ...meowrt.Value) meowrt.Value {
+	elements, bytes := size(v)
+	var fromElements, fromBytes int64
+	for _, f := range from {
+		e, b := size(f)
+		fromElements, fromBytes = max(fromElements, e), max(fromBytes, b)
+	}
+	interp.reserve(max(elements-fromElements, 0), max(bytes-fromBytes, 0))
+	return v
+}
+
+// size is what the run's limits count of v: the elements of a litter or
+// basket, and the bytes of a string.
+func size(v meowrt.Value) (elements, bytes int64) {
+	switch v := v.(type) {
+	case *meowrt.List:
+		return int64(len(v.Items)), 0
+	case *meowrt.Map:
+		return int64(len(v.Items)), 0
+	case *meowrt.String:
+		return 0, int64(len(v.Val))
+	}
+	return 0, 0
+}
+
 // --- Statement Execution ---
 
 func (interp *Interpreter) execStmt(stmt ast.Stmt, env *Environment) {
@@ -243,7 +399,7 @@ func isWalkable(v meowrt.Value) bool {
 	case *meowrt.List, *meowrt.Map:
 		return true
 	}
-	return false
+	return meowrt.IsGoSequence(v)
 }
 
 func (interp *Interpreter) execRange(s *ast.RangeStmt, env *Environment) {
@@ -503,7 +659,8 @@ func (interp *Interpreter) evalBinary(e *ast.BinaryExpr, env *Environment) meowr
 
 	switch e.Op {
 	case token.PLUS:
-		return meowrt.Add(left, right)
+		// Joining two strings is the one operator that allocates.
+		return interp.charge(meowrt.Add(left, right), left, right)
 	case token.MINUS:
 		return meowrt.Sub(left, right)
 	case token.STAR:
@@ -537,7 +694,79 @@ func requireArgs(name string, args []meowrt.Value, count int) {
 	}
 }
 
+// allocatingBuiltins are the builtins whose result is newly allocated, and so
+// charged against the run's limits once it is made. The ones reserveFor counts
+// up front are not here, and the rest hand back something that already was, or
+// something too small to count.
+var allocatingBuiltins = map[string]bool{
+	"to_string": true, "upper": true, "lower": true, "pad": true,
+	"sort": true, "reverse": true, "tangle": true,
+	"append": true, "lick": true, "picky": true,
+}
+
 func (interp *Interpreter) dispatchBuiltin(name string, args []meowrt.Value) (meowrt.Value, bool) {
+	interp.reserveFor(name, args)
+	v, ok := interp.callBuiltin(name, args)
+	if ok && allocatingBuiltins[name] {
+		interp.charge(v, args...)
+	}
+	return v, ok
+}
+
+// reserveFor refuses a builtin call whose result alone would take the run past
+// its limits, before it is made.
+//
+// Charging afterwards is enough for most builtins, whose results are no bigger
+// than what they were given. These few can be asked for far more than that —
+// shredding a string makes an element of every byte — and a limit that is
+// only checked once the gigabytes are already allocated has not done its job.
+// Their sizes can be counted without being built, so they are.
+func (interp *Interpreter) reserveFor(name string, args []meowrt.Value) {
+	str := func(i int) (string, bool) {
+		if i >= len(args) {
+			return "", false
+		}
+		s, ok := args[i].(*meowrt.String)
+		if !ok {
+			return "", false
+		}
+		return s.Val, true
+	}
+	switch name {
+	case "shred":
+		s, ok1 := str(0)
+		sep, ok2 := str(1)
+		if !ok1 || !ok2 {
+			return
+		}
+		n := utf8.RuneCountInString(s)
+		if sep != "" {
+			n = strings.Count(s, sep) + 1
+		}
+		interp.reserve(int64(n), int64(len(s)))
+	case "to_bytes":
+		if s, ok := str(0); ok {
+			interp.reserve(int64(len(s)), 0)
+		}
+	case "to_runes":
+		if s, ok := str(0); ok {
+			interp.reserve(int64(utf8.RuneCountInString(s)), int64(len(s)))
+		}
+	case "replace":
+		s, ok1 := str(0)
+		from, ok2 := str(1)
+		to, ok3 := str(2)
+		if !ok1 || !ok2 || !ok3 || from == "" {
+			return
+		}
+		grows := int64(strings.Count(s, from)) * int64(len(to)-len(from))
+		interp.reserve(0, int64(len(s))+grows)
+	}
+}
+
+// callBuiltin calls the named builtin, reporting false for a name that is not
+// one.
+func (interp *Interpreter) callBuiltin(name string, args []meowrt.Value) (meowrt.Value, bool) {
 	switch name {
 	case "nya":
 		return interp.builtinNya(args), true
@@ -783,7 +1012,7 @@ func (interp *Interpreter) evalList(e *ast.ListLit, env *Environment) meowrt.Val
 	for i, item := range e.Items {
 		items[i] = interp.evalExpr(item, env)
 	}
-	return meowrt.NewList(items...)
+	return interp.charge(meowrt.NewList(items...))
 }
 
 func (interp *Interpreter) evalMap(e *ast.MapLit, env *Environment) meowrt.Value {
@@ -793,7 +1022,7 @@ func (interp *Interpreter) evalMap(e *ast.MapLit, env *Environment) meowrt.Value
 		val := interp.evalExpr(e.Vals[i], env)
 		items[meowrt.AsString(key)] = val
 	}
-	return meowrt.NewMap(items)
+	return interp.charge(meowrt.NewMap(items))
 }
 
 func (interp *Interpreter) evalIndex(e *ast.IndexExpr, env *Environment) meowrt.Value {
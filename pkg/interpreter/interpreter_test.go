@@ -2,8 +2,11 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/135yshr/meow/pkg/ast"
 	"github.com/135yshr/meow/pkg/checker"
@@ -29,7 +32,7 @@ func runMeow(t *testing.T, source string) string {
 	var buf bytes.Buffer
 	interp := New(&buf)
 	interp.SetTypeInfo(ti)
-	if err := interp.RunSafe(prog); err != nil {
+	if err := interp.RunSafe(context.Background(), prog); err != nil {
 		t.Fatalf("runtime error: %v", err)
 	}
 	return buf.String()
@@ -51,7 +54,7 @@ func runMeowError(t *testing.T, source string) string {
 	var buf bytes.Buffer
 	interp := New(&buf)
 	interp.SetTypeInfo(ti)
-	err := interp.RunSafe(prog)
+	err := interp.RunSafe(context.Background(), prog)
 	if err == nil {
 		t.Fatal("expected error but got none")
 	}
@@ -608,7 +611,7 @@ loop()
 	interp := New(&buf)
 	interp.SetTypeInfo(ti)
 	interp.SetStepLimit(1000)
-	err := interp.RunSafe(prog)
+	err := interp.RunSafe(context.Background(), prog)
 	if err == nil {
 		t.Fatal("expected step limit error")
 	}
@@ -1119,7 +1122,7 @@ func TestScramReportsItsStatus(t *testing.T) {
 			}
 			var buf bytes.Buffer
 			interp := New(&buf)
-			if err := interp.RunSafe(prog); err != nil {
+			if err := interp.RunSafe(context.Background(), prog); err != nil {
 				t.Fatalf("runtime error: %v", err)
 			}
 			if got := interp.ExitCode(); got != tt.want {
@@ -1163,7 +1166,7 @@ func TestScramIsNotCaught(t *testing.T) {
 			}
 			var buf bytes.Buffer
 			interp := New(&buf)
-			if err := interp.RunSafe(prog); err != nil {
+			if err := interp.RunSafe(context.Background(), prog); err != nil {
 				t.Fatalf("runtime error: %v", err)
 			}
 
@@ -1199,7 +1202,7 @@ func TestNyaDoesNotPrintAFailure(t *testing.T) {
 	var buf bytes.Buffer
 	prog := parseForTest(t, source)
 	interp := New(&buf)
-	err := interp.RunSafe(prog)
+	err := interp.RunSafe(context.Background(), prog)
 
 	if err == nil {
 		t.Fatal("expected the program to fail")
@@ -1213,7 +1216,7 @@ func TestNyaDoesNotPrintAFailure(t *testing.T) {
 func runMeowExpectingFailure(t *testing.T, source string) error {
 	t.Helper()
 	var buf bytes.Buffer
-	err := New(&buf).RunSafe(parseForTest(t, source))
+	err := New(&buf).RunSafe(context.Background(), parseForTest(t, source))
 	if err == nil {
 		t.Fatal("expected the program to fail")
 	}
@@ -1266,7 +1269,7 @@ func TestTheStepLimitSaysWhereItGaveUp(t *testing.T) {
 	var buf bytes.Buffer
 	interp := New(&buf)
 	interp.SetStepLimit(1000)
-	err := interp.RunSafe(parseForTest(t, source))
+	err := interp.RunSafe(context.Background(), parseForTest(t, source))
 
 	if err == nil {
 		t.Fatal("expected the program to give up")
@@ -1382,3 +1385,125 @@ nya(double(21))
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+// A step is not a fixed amount of time, so the step limit alone cannot stop a
+// program that is slow rather than long. The context's deadline can.
+func TestADeadlineStopsTheRun(t *testing.T) {
+	source := "purr (yarn) {\n  nyan n = 1\n}\n"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	err := New(&buf).RunSafe(ctx, parseForTest(t, source))
+
+	if err == nil {
+		t.Fatal("expected the run to be stopped")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want it to wrap the deadline", err)
+	}
+	if !strings.Contains(err.Error(), "time limit exceeded") {
+		t.Errorf("got %q, want it to say it ran out of time", err.Error())
+	}
+}
+
+func TestACancelledContextStopsTheRun(t *testing.T) {
+	source := "purr (yarn) {\n  nyan n = 1\n}\n"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var buf bytes.Buffer
+	err := New(&buf).RunSafe(ctx, parseForTest(t, source))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want it to wrap the cancellation", err)
+	}
+	if !strings.Contains(err.Error(), "run cancelled") {
+		t.Errorf("got %q, want it to say it was cancelled", err.Error())
+	}
+}
+
+// One shred of a long enough string asks for an element per byte. The limit is
+// checked before the pieces are made, or it would only report the gigabytes
+// after they were gone.
+func TestShreddingPastTheElementLimitIsRefused(t *testing.T) {
+	source := "nyan s = pad(\"\", 5000)\nnyan parts = shred(s, \"\")\nnya(len(parts))\n"
+
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.SetElementLimit(1000)
+	err := interp.RunSafe(context.Background(), parseForTest(t, source))
+
+	if err == nil {
+		t.Fatal("expected the element limit to be reached")
+	}
+	if !strings.Contains(err.Error(), "element limit exceeded (1000)") {
+		t.Errorf("got %q, want it to name the element limit", err.Error())
+	}
+	if buf.String() != "" {
+		t.Errorf("got %q, want nothing printed", buf.String())
+	}
+}
+
+func TestAnAppendLoopIsChargedForWhatItBuilds(t *testing.T) {
+	source := "meow grow(xs, n) {\n  sniff (n == 0) { bring xs }\n  bring grow(append(xs, n), n - 1)\n}\nnya(len(grow([], 2000)))\n"
+
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.SetElementLimit(1000)
+	err := interp.RunSafe(context.Background(), parseForTest(t, source))
+
+	if err == nil || !strings.Contains(err.Error(), "element limit exceeded") {
+		t.Fatalf("got %v, want the element limit to be reached", err)
+	}
+}
+
+// Each append copies the litter, but adds only one element to it: building a
+// long litter a step at a time is charged for the litter, not for the copies.
+func TestALongLitterBuiltAStepAtATimeFitsTheDefaultLimits(t *testing.T) {
+	source := "meow grow(xs, s, n) {\n  sniff (n == 0) { bring [len(xs), len(s)] }\n  bring grow(append(xs, n), s + \"x\", n - 1)\n}\nnya(grow([], \"\", 100000))\n"
+
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.SetStepLimit(1 << 40)
+	if err := interp.RunSafe(context.Background(), parseForTest(t, source)); err != nil {
+		t.Fatalf("RunSafe: %v", err)
+	}
+	if got := buf.String(); got != "[100000, 100000]\n" {
+		t.Errorf("got %q, want [100000, 100000]", got)
+	}
+}
+
+func TestGrowingAStringPastTheLimitIsRefused(t *testing.T) {
+	source := "meow double(s, n) {\n  sniff (n == 0) { bring s }\n  bring double(s + s, n - 1)\n}\nnya(len(double(\"meow\", 20)))\n"
+
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.SetStringLimit(1 << 16)
+	err := interp.RunSafe(context.Background(), parseForTest(t, source))
+
+	if err == nil {
+		t.Fatal("expected the string limit to be reached")
+	}
+	if !strings.Contains(err.Error(), "string byte limit exceeded (65536)") {
+		t.Errorf("got %q, want it to name the string limit", err.Error())
+	}
+}
+
+// gag recovers whatever it is handed. A limit it could swallow would let the
+// program carry on allocating as though nothing had happened.
+func TestALimitCannotBeGagged(t *testing.T) {
+	source := "nyan r = gag(paw() { shred(pad(\"\", 5000), \"\") })\nnya(\"carried on\")\n"
+
+	var buf bytes.Buffer
+	interp := New(&buf)
+	interp.SetElementLimit(1000)
+	err := interp.RunSafe(context.Background(), parseForTest(t, source))
+
+	if err == nil || !strings.Contains(err.Error(), "element limit exceeded") {
+		t.Fatalf("got %v, want the element limit to be reached", err)
+	}
+	if buf.String() != "" {
+		t.Errorf("got %q, want the program stopped where it hit the limit", buf.String())
+	}
+}
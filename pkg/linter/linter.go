@@ -82,3 +82,22 @@ func (l *Linter) Lint(prog *ast.Program) []Diagnostic {
 	})
 	return diags
 }
+
+// Disable turns off the rules with the given names. A name no rule has is an
+// error, so that a misspelled rule is not quietly left on.
+func (l *Linter) Disable(names ...string) error {
+	for _, name := range names {
+		found := false
+		for i, rule := range l.rules {
+			if rule.Name() == name {
+				l.rules = append(l.rules[:i], l.rules[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Hiss! There is no lint rule called %q, nya~", name)
+		}
+	}
+	return nil
+}
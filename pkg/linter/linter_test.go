@@ -205,3 +205,17 @@ purr i (10) {
 		t.Fatalf("expected no diagnostics for clean code, got %d", len(diags))
 	}
 }
+
+func TestDisabledRuleIsNotChecked(t *testing.T) {
+	l := New()
+	if err := l.Disable("unused-var"); err != nil {
+		t.Fatal(err)
+	}
+	diags := l.Lint(parse(t, `nyan x = 1`))
+	if found := findByRule(diags, "unused-var"); len(found) != 0 {
+		t.Fatalf("unused-var was disabled but reported: %v", found)
+	}
+	if err := l.Disable("unused-vars"); err == nil {
+		t.Fatal("expected an error for a rule that does not exist")
+	}
+}
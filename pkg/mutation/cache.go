@@ -0,0 +1,331 @@
+package mutation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/lexer"
+	"github.com/135yshr/meow/pkg/token"
+)
+
+// Cache keeps the results of earlier runs, by what was run, so that what has
+// not changed since is not built and run again. A source file and its tests
+// that have not changed at all give back the results of the whole run, under
+// Key. After a change, each mutant's result is still there under MutantKey
+// when the code around the mutant and the tests that reach it are as they
+// were, and only the mutants of what changed are tested again.
+type Cache struct {
+	Dir string
+}
+
+// cacheVersion is part of every key, for a change to how mutants are tested
+// to set aside what was cached before it.
+const cacheVersion = "3"
+
+// DefaultCache is the cache in the user's cache directory.
+func DefaultCache() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: filepath.Join(dir, "meow", "mutation")}, nil
+}
+
+// BaseKey is the part of every key that is not the source or its tests: the
+// meow that tests the mutants, tool, and anything else the program is built
+// from, such as the versions of the Go packages it fetches.
+func BaseKey(tool string, others ...[]byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "meow mutation %s\ntool %q\n", cacheVersion, tool)
+	for _, o := range others {
+		fmt.Fprintf(h, "other %d\n", len(o))
+		h.Write(o)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Key is what the results of testing mutants of source with tests are kept
+// under, as a whole; base is BaseKey's.
+func Key(base string, source []byte, tests [][]byte, mutants []Mutant) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "run %s\n", base)
+	fmt.Fprintf(h, "source %d\n", len(source))
+	h.Write(source)
+	for _, t := range tests {
+		fmt.Fprintf(h, "test %d\n", len(t))
+		h.Write(t)
+	}
+	for _, m := range mutants {
+		fmt.Fprintf(h, "mutant %d %d %s\n", m.ID, m.Kind, m.Description)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// A File is a .nyan file as it was read, and as it parsed.
+type File struct {
+	Source []byte
+	Prog   *ast.Program
+}
+
+// MutantKeys are what the result of testing each of mutants, of source, is
+// kept under on its own; base is BaseKey's.
+//
+// A mutant's key is the top-level statement of source it is in, where in it
+// the mutant is, and what it changes, with the tests cov says reach it — all
+// the tests, when cov cannot say — and whatever of the test files is not a
+// test, which any of them may call. The statements of source that any of
+// those name are in it too, and those they name in turn: the functions,
+// globals and kitties the mutant's code and its tests use, a change to any of
+// which may change whether the tests notice it. The rest of source and the
+// tests that do not reach the mutant are left out, so an edit to one function
+// sets aside the results of the mutants of what uses it, and no others.
+func MutantKeys(base string, source File, tests []File, cov *Coverage, mutants []Mutant) []string {
+	var shared []byte
+	testText := make(map[string][]byte)
+	var all []string
+	for _, f := range tests {
+		for i, stmt := range f.Prog.Stmts {
+			text := stmtText(f, i)
+			if fn, ok := stmt.(*ast.FuncStmt); ok && (strings.HasPrefix(fn.Name, "test_") || strings.HasPrefix(fn.Name, "catwalk_")) {
+				testText[fn.Name] = text
+				all = append(all, fn.Name)
+				continue
+			}
+			shared = append(shared, text...)
+		}
+	}
+	deps := newDependencies(source)
+
+	keys := make([]string, len(mutants))
+	for i, m := range mutants {
+		h := sha256.New()
+		fmt.Fprintf(h, "mutant %s\n", base)
+		start := 0
+		for j, stmt := range source.Prog.Stmts {
+			if stmt.Pos().Line > m.Pos.Line {
+				break
+			}
+			start = j
+		}
+		around := stmtText(source, start)
+		fmt.Fprintf(h, "around %d\n", len(around))
+		h.Write(around)
+		line := m.Pos.Line
+		if len(source.Prog.Stmts) > 0 {
+			line -= source.Prog.Stmts[start].Pos().Line
+		}
+		// The description says where the mutant is in the file, which is
+		// not part of what it is.
+		what := strings.ReplaceAll(m.Description, m.Pos.String(), "")
+		fmt.Fprintf(h, "at %d:%d %d %q %q %q\n", line, m.Pos.Column, m.Kind, what, m.Original, m.Replacement)
+
+		reach := all
+		if cov != nil {
+			if tests, known := cov.Tests(m.Pos); known {
+				reach = tests
+			}
+		}
+		reach = slices.Sorted(slices.Values(reach))
+		for _, name := range reach {
+			fmt.Fprintf(h, "test %s %d\n", name, len(testText[name]))
+			h.Write(testText[name])
+		}
+		fmt.Fprintf(h, "shared %d\n", len(shared))
+		h.Write(shared)
+
+		named := [][]byte{around, shared}
+		for _, name := range reach {
+			named = append(named, testText[name])
+		}
+		for _, j := range deps.of(start, named...) {
+			text := deps.text[j]
+			fmt.Fprintf(h, "uses %d\n", len(text))
+			h.Write(text)
+		}
+		keys[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return keys
+}
+
+// dependencies are the top-level statements of a source file, and the names
+// each declares, for the statements a piece of code uses to be found by the
+// names it has.
+type dependencies struct {
+	text [][]byte
+	// declaring are the statements that declare each name. A statement
+	// that declares none, such as a nab, is one all code may depend on.
+	declaring map[string][]int
+	always    []int
+}
+
+func newDependencies(f File) *dependencies {
+	d := &dependencies{declaring: make(map[string][]int)}
+	for i, stmt := range f.Prog.Stmts {
+		d.text = append(d.text, stmtText(f, i))
+		names := declared(stmt)
+		if len(names) == 0 {
+			d.always = append(d.always, i)
+		}
+		for _, name := range names {
+			d.declaring[name] = append(d.declaring[name], i)
+		}
+	}
+	return d
+}
+
+// of are the statements, other than the start-th, that texts name and those
+// name in turn, in the order of the file. A name is taken to be the
+// statement's that declares it wherever it appears, which takes in more than
+// is used when a local has the name of a top-level statement, but never less.
+func (d *dependencies) of(start int, texts ...[]byte) []int {
+	seen := map[int]bool{start: true}
+	var found []int
+	visit := func(text []byte) {
+		for tok := range lexer.New(string(text), "").Tokens() {
+			if tok.Type != token.IDENT {
+				continue
+			}
+			for _, j := range d.declaring[tok.Literal] {
+				if !seen[j] {
+					seen[j] = true
+					found = append(found, j)
+				}
+			}
+		}
+	}
+	for _, text := range texts {
+		visit(text)
+	}
+	for k := 0; k < len(found); k++ {
+		visit(d.text[found[k]])
+	}
+	for _, j := range d.always {
+		if !seen[j] {
+			found = append(found, j)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+// declared names what the top-level statement stmt declares: a function,
+// global or type by its name, and the methods a learn gives a type by the
+// type's name and theirs, as a call of one names it.
+func declared(stmt ast.Stmt) []string {
+	switch s := stmt.(type) {
+	case *ast.FuncStmt:
+		return []string{s.Name}
+	case *ast.VarStmt:
+		return []string{s.Name}
+	case *ast.KittyStmt:
+		return []string{s.Name}
+	case *ast.BreedStmt:
+		return []string{s.Name}
+	case *ast.CollarStmt:
+		return []string{s.Name}
+	case *ast.TrickStmt:
+		return []string{s.Name}
+	case *ast.LearnStmt:
+		names := []string{s.TypeName}
+		for _, m := range s.Methods {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+	return nil
+}
+
+// stmtText is the source of the i-th top-level statement of f: its lines, up
+// to where the next one starts.
+func stmtText(f File, i int) []byte {
+	lines := bytes.SplitAfter(f.Source, []byte("\n"))
+	stmts := f.Prog.Stmts
+	if i >= len(stmts) {
+		return f.Source
+	}
+	from := max(stmts[i].Pos().Line-1, 0)
+	to := len(lines)
+	if i+1 < len(stmts) {
+		to = max(stmts[i+1].Pos().Line-1, from)
+	}
+	from, to = min(from, len(lines)), min(to, len(lines))
+	return bytes.Join(lines[from:to], nil)
+}
+
+// Load gives back the results kept under key, if there are any.
+func (c *Cache) Load(key string) ([]RunResult, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var results []RunResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, false
+	}
+	return results, true
+}
+
+// LoadMutant gives back what became of the mutant kept under key, if it is
+// there.
+func (c *Cache) LoadMutant(key string) (Status, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return 0, false
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return 0, false
+	}
+	return status, true
+}
+
+// StoreMutant keeps what became of a mutant under key.
+func (c *Cache) StoreMutant(key string, status Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return c.write(key, data)
+}
+
+// Store keeps results under key.
+func (c *Cache) Store(key string, results []RunResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return c.write(key, data)
+}
+
+// write keeps data under key. It writes to a file of its own first, so that a
+// run stopped partway leaves nothing half-written to be loaded.
+func (c *Cache) write(key string, data []byte) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
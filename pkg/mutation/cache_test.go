@@ -0,0 +1,131 @@
+package mutation_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/mutation"
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+func TestCacheGivesBackWhatWasStored(t *testing.T) {
+	cache := &mutation.Cache{Dir: t.TempDir()}
+	mutants := mutation.Enumerate(parse(t, `nyan x = 1 + 2`))
+	key := mutation.Key(mutation.BaseKey("v1.0.0"), []byte("nyan x = 1 + 2"), [][]byte{[]byte("meow test_x() {}")}, mutants)
+
+	if _, ok := cache.Load(key); ok {
+		t.Fatal("Load found results in an empty cache")
+	}
+	want := []mutation.RunResult{{ID: 0, Status: mutation.Killed}, {ID: 1, Status: mutation.NoCoverage}}
+	if err := cache.Store(key, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := cache.Load(key)
+	if !ok || !slices.Equal(got, want) {
+		t.Errorf("Load = %v, %t, want %v", got, ok, want)
+	}
+}
+
+func TestCacheKeyChangesWithTheSourceOrTests(t *testing.T) {
+	source := []byte("nyan x = 1 + 2")
+	mutants := mutation.Enumerate(parse(t, string(source)))
+	tests := [][]byte{[]byte("meow test_x() {}")}
+	base := mutation.BaseKey("v1.0.0")
+	key := mutation.Key(base, source, tests, mutants)
+
+	if mutation.Key(base, source, tests, mutants) != key {
+		t.Error("the same source and tests have two keys")
+	}
+	if mutation.Key(base, []byte("nyan x = 1 + 3"), tests, mutants) == key {
+		t.Error("a change to the source keeps the key")
+	}
+	if mutation.Key(base, source, [][]byte{[]byte("meow test_y() {}")}, mutants) == key {
+		t.Error("a change to the tests keeps the key")
+	}
+	// The boundary between one file and the next is part of the key.
+	if mutation.Key(base, source, [][]byte{[]byte("meow test_x() {"), []byte("}")}, mutants) == key {
+		t.Error("splitting a test file in two keeps the key")
+	}
+	if mutation.Key(mutation.BaseKey("v1.1.0"), source, tests, mutants) == key {
+		t.Error("another meow keeps the key")
+	}
+	if mutation.Key(mutation.BaseKey("v1.0.0", []byte("example.com/whisker v1.0.0")), source, tests, mutants) == key {
+		t.Error("another meow.lock keeps the key")
+	}
+}
+
+// mutantKeys are the keys of the mutants of source, tested with the tests of
+// test, test_double reaching double and test_half half.
+func mutantKeys(t *testing.T, base, source, test string) map[string]string {
+	t.Helper()
+	block := func(from, to int) coverage.Block {
+		return coverage.Block{FileName: "test.nyan", StartLine: from, StartCol: 1, EndLine: to, EndCol: 1, NumStmt: 1, Count: 1}
+	}
+	var cov mutation.Coverage
+	cov.Add("test_double", []coverage.Block{block(1, 3)})
+	cov.Add("test_half", []coverage.Block{block(5, 7)})
+
+	prog := parse(t, source)
+	mutants := mutation.Enumerate(prog)
+	keys := mutation.MutantKeys(base, mutation.File{Source: []byte(source), Prog: prog},
+		[]mutation.File{{Source: []byte(test), Prog: parse(t, test)}}, &cov, mutants)
+	byWhat := make(map[string]string)
+	for i, m := range mutants {
+		byWhat[fmt.Sprintf("%d %s", m.Pos.Line, m.Kind)] = keys[i]
+	}
+	return byWhat
+}
+
+func TestAMutantKeepsItsKeyThroughChangesElsewhere(t *testing.T) {
+	source := "meow double(x int) int {\n  bring x * 2\n}\n\nmeow half(x int) int {\n  bring x / 2\n}\n"
+	test := "meow test_double() {\n  expect(double(2), 4)\n}\n\nmeow test_half() {\n  expect(half(4), 2)\n}\n"
+	base := mutation.BaseKey("v1.0.0")
+	was := mutantKeys(t, base, source, test)
+	if len(was) < 4 {
+		t.Fatalf("mutants = %v, want some of each function", was)
+	}
+	same := func(now map[string]string, line int) bool {
+		for what, key := range now {
+			if strings.HasPrefix(what, fmt.Sprint(line)+" ") && was[what] != key {
+				return false
+			}
+		}
+		return true
+	}
+
+	halfChanged := mutantKeys(t, base, strings.Replace(source, "x / 2", "x / 2 + 0", 1), test)
+	if !same(halfChanged, 2) {
+		t.Error("a change to half set aside the results of double's mutants")
+	}
+	if same(halfChanged, 6) {
+		t.Error("a change to half kept the results of its own mutants")
+	}
+
+	testChanged := mutantKeys(t, base, source, strings.Replace(test, "half(4), 2", "half(6), 3", 1))
+	if !same(testChanged, 2) || same(testChanged, 6) {
+		t.Error("a change to test_half did not set aside just the results of half's mutants")
+	}
+
+	if same(mutantKeys(t, mutation.BaseKey("v1.1.0"), source, test), 2) {
+		t.Error("another meow kept the results")
+	}
+}
+
+func TestAMutantLosesItsKeyWhenWhatItCallsChanges(t *testing.T) {
+	source := "meow double(x int) int {\n  bring scale(x) * 2\n}\n\nmeow half(x int) int {\n  bring x / 2\n}\n\nmeow scale(x int) int {\n  bring x\n}\n"
+	test := "meow test_double() {\n  expect(double(2), 4)\n}\n\nmeow test_half() {\n  expect(half(4), 2)\n}\n"
+	base := mutation.BaseKey("v1.0.0")
+	was := mutantKeys(t, base, source, test)
+	now := mutantKeys(t, base, strings.Replace(source, "  bring x\n", "  bring x + 1\n", 1), test)
+
+	for what, key := range now {
+		switch {
+		case strings.HasPrefix(what, "2 ") && was[what] == key:
+			t.Errorf("a change to scale kept the result of %s of double, which calls it", what)
+		case strings.HasPrefix(what, "6 ") && was[what] != key:
+			t.Errorf("a change to scale set aside the result of %s of half, which does not", what)
+		}
+	}
+}
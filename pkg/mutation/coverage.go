@@ -0,0 +1,85 @@
+package mutation
+
+import (
+	"slices"
+
+	"github.com/135yshr/meow/pkg/token"
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+// Coverage is which tests reach which statements of the code under test, put
+// together from a coverage profile of each test run on its own with no mutant
+// active. A mutant only a few tests reach need only be tested with those, and
+// one no test reaches need not be tested at all: nothing would notice it.
+type Coverage struct {
+	blocks []coverage.Block
+	// hitBy are the tests that reached each of blocks.
+	hitBy [][]string
+	// at is where blocks has each block, counts aside.
+	at map[coverage.Block]int
+}
+
+// Add records the profile of a run of test.
+func (c *Coverage) Add(test string, profile []coverage.Block) {
+	for _, b := range profile {
+		i := c.index(b)
+		if b.Count > 0 && !slices.Contains(c.hitBy[i], test) {
+			c.hitBy[i] = append(c.hitBy[i], test)
+		}
+	}
+}
+
+// index is where blocks has b, counts aside, adding it when it is not there.
+// A test that runs beside the others in a process of its own writes its
+// blocks to the profile again, and they are the same blocks.
+func (c *Coverage) index(b coverage.Block) int {
+	b.Count = 0
+	if i, ok := c.at[b]; ok {
+		return i
+	}
+	if c.at == nil {
+		c.at = make(map[coverage.Block]int)
+	}
+	c.at[b] = len(c.blocks)
+	c.blocks = append(c.blocks, b)
+	c.hitBy = append(c.hitBy, nil)
+	return len(c.blocks) - 1
+}
+
+// Tests are the tests that reach the code at pos: those that reached the
+// innermost statement it is part of. known is false when pos is in no
+// statement the profiles have, and it cannot be told what reaches it.
+//
+// A profile gives where a statement starts, and for an if or a loop the line
+// its body ends on, so a statement is taken to run from where it starts to
+// the end of its last line. The innermost is the one of them that starts
+// last.
+func (c *Coverage) Tests(pos token.Position) (tests []string, known bool) {
+	best := -1
+	for i, b := range c.blocks {
+		if b.FileName != pos.File || !contains(b, pos) {
+			continue
+		}
+		if best < 0 || startsAfter(b, c.blocks[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	return c.hitBy[best], true
+}
+
+func contains(b coverage.Block, pos token.Position) bool {
+	if pos.Line < b.StartLine || pos.Line > b.EndLine {
+		return false
+	}
+	return pos.Line > b.StartLine || pos.Column >= b.StartCol
+}
+
+func startsAfter(a, b coverage.Block) bool {
+	if a.StartLine != b.StartLine {
+		return a.StartLine > b.StartLine
+	}
+	return a.StartCol > b.StartCol
+}
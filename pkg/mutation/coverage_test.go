@@ -0,0 +1,64 @@
+package mutation_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/mutation"
+	"github.com/135yshr/meow/pkg/token"
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+func TestCoverageFindsTheTestsThatReachAMutant(t *testing.T) {
+	// clamp.nyan:
+	//   5  sniff (x > 10) {
+	//   6    bring 10
+	//   7  }
+	//   8  bring x
+	block := func(line, col, endLine int, count int64) coverage.Block {
+		return coverage.Block{FileName: "clamp.nyan", StartLine: line, StartCol: col, EndLine: endLine, EndCol: 1, NumStmt: 1, Count: count}
+	}
+	var cov mutation.Coverage
+	cov.Add("test_small", []coverage.Block{block(5, 3, 7, 1), block(6, 5, 6, 0), block(8, 3, 8, 1)})
+	cov.Add("test_large", []coverage.Block{block(5, 3, 7, 1), block(6, 5, 6, 1), block(8, 3, 8, 0)})
+	cov.Add("test_other", []coverage.Block{block(5, 3, 7, 0), block(6, 5, 6, 0), block(8, 3, 8, 0)})
+
+	at := func(line, col int) token.Position {
+		return token.Position{File: "clamp.nyan", Line: line, Column: col}
+	}
+	for _, tt := range []struct {
+		name  string
+		pos   token.Position
+		tests []string
+		known bool
+	}{
+		{"condition", at(5, 12), []string{"test_small", "test_large"}, true},
+		{"body", at(6, 11), []string{"test_large"}, true},
+		{"after", at(8, 3), []string{"test_small"}, true},
+		{"before any statement", at(5, 1), nil, false},
+		{"another file", token.Position{File: "clamp_test.nyan", Line: 6, Column: 11}, nil, false},
+	} {
+		tests, known := cov.Tests(tt.pos)
+		if known != tt.known || !slices.Equal(tests, tt.tests) {
+			t.Errorf("%s: Tests(%v) = %v, %t, want %v, %t", tt.name, tt.pos, tests, known, tt.tests, tt.known)
+		}
+	}
+}
+
+func TestCoverageOfAMutantNoTestReachesIsEmpty(t *testing.T) {
+	var cov mutation.Coverage
+	unreached := coverage.Block{FileName: "cats.nyan", StartLine: 3, StartCol: 3, EndLine: 3, EndCol: 4, NumStmt: 1}
+	cov.Add("test_a", []coverage.Block{unreached})
+	cov.Add("test_b", []coverage.Block{unreached, unreached})
+
+	tests, known := cov.Tests(token.Position{File: "cats.nyan", Line: 3, Column: 10})
+	if !known || len(tests) != 0 {
+		t.Errorf("Tests = %v, %t, want none and known", tests, known)
+	}
+}
+
+func TestOnlyTestsMatchesTheNamesAlone(t *testing.T) {
+	if got, want := mutation.OnlyTests([]string{"test_a", "test_a.b"}), `^(test_a|test_a\.b)$`; got != want {
+		t.Errorf("OnlyTests = %q, want %q", got, want)
+	}
+}
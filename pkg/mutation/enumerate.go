@@ -9,7 +9,7 @@ import (
 
 // Enumerate walks the AST and returns all possible mutations.
 func Enumerate(prog *ast.Program) []Mutant {
-	var e enumerator
+	e := enumerator{declared: declaredNames(prog)}
 	for _, stmt := range prog.Stmts {
 		e.enumStmt(stmt)
 	}
@@ -19,6 +19,9 @@ func Enumerate(prog *ast.Program) []Mutant {
 type enumerator struct {
 	mutants []Mutant
 	nextID  MutantID
+	// declared holds every name the program gives something of its own, at
+	// any depth; see enumBuiltinSwap.
+	declared map[string]bool
 }
 
 func (e *enumerator) add(desc string, pos token.Position, kind MutantKind, apply, undo func()) {
@@ -33,12 +36,17 @@ func (e *enumerator) add(desc string, pos token.Position, kind MutantKind, apply
 	e.nextID++
 }
 
+// replaces records that the mutant added last changes the token original at
+// its position into replacement.
+func (e *enumerator) replaces(original, replacement string) {
+	m := &e.mutants[len(e.mutants)-1]
+	m.Original, m.Replacement = original, replacement
+}
+
 func (e *enumerator) enumStmt(stmt ast.Stmt) {
 	switch s := stmt.(type) {
 	case *ast.FuncStmt:
-		for _, body := range s.Body {
-			e.enumStmt(body)
-		}
+		e.enumBody(s.Body)
 	case *ast.IfStmt:
 		e.enumExpr(s.Condition)
 		origCond := s.Condition
@@ -54,20 +62,48 @@ func (e *enumerator) enumStmt(stmt ast.Stmt) {
 			},
 			func() { s.Condition = origCond },
 		)
-		for _, body := range s.Body {
-			e.enumStmt(body)
-		}
-		for _, body := range s.ElseBody {
-			e.enumStmt(body)
-		}
+		e.enumBranchSwap(s)
+		e.enumBody(s.Body)
+		e.enumBody(s.ElseBody)
 	case *ast.RangeStmt:
 		if s.Start != nil {
 			e.enumExpr(s.Start)
 		}
 		e.enumExpr(s.End)
-		for _, body := range s.Body {
-			e.enumStmt(body)
+		if s.Start != nil && s.Inclusive {
+			origEnd := s.End
+			e.add(
+				fmt.Sprintf("shrink range end by 1 at %s", s.Pos()),
+				s.Pos(), RangeShrink,
+				func() {
+					s.End = &ast.BinaryExpr{
+						Token: s.Token,
+						Op:    token.MINUS,
+						Left:  origEnd,
+						Right: &ast.IntLit{Token: s.Token, Value: 1},
+					}
+				},
+				func() { s.End = origEnd },
+			)
+			origStart := s.Start
+			e.add(
+				fmt.Sprintf("shrink range start by 1 at %s", s.Pos()),
+				s.Pos(), RangeShrink,
+				func() {
+					s.Start = &ast.BinaryExpr{
+						Token: s.Token,
+						Op:    token.PLUS,
+						Left:  origStart,
+						Right: &ast.IntLit{Token: s.Token, Value: 1},
+					}
+				},
+				func() { s.Start = origStart },
+			)
 		}
+		e.enumBody(s.Body)
+	case *ast.WhileStmt:
+		e.enumExpr(s.Cond)
+		e.enumBody(s.Body)
 	case *ast.ReturnStmt:
 		if s.Value != nil {
 			origValue := s.Value
@@ -86,6 +122,23 @@ func (e *enumerator) enumStmt(stmt ast.Stmt) {
 	}
 }
 
+// enumBody enumerates the statements of a body, and those mutations that
+// replace a statement of it with another.
+func (e *enumerator) enumBody(stmts []ast.Stmt) {
+	for i, stmt := range stmts {
+		if bolt, ok := stmt.(*ast.BoltStmt); ok {
+			e.add(
+				fmt.Sprintf("replace bolt with slink at %s", bolt.Pos()),
+				bolt.Pos(), BoltToSlink,
+				func() { stmts[i] = &ast.SlinkStmt{Token: bolt.Token} },
+				func() { stmts[i] = bolt },
+			)
+			e.replaces("bolt", "slink")
+		}
+		e.enumStmt(stmt)
+	}
+}
+
 func (e *enumerator) enumExpr(expr ast.Expr) {
 	switch ex := expr.(type) {
 	case *ast.BinaryExpr:
@@ -103,6 +156,7 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 			func() { ex.Value = !ex.Value },
 			func() { ex.Value = origVal },
 		)
+		e.replaces(boolText[origVal], boolText[!origVal])
 	case *ast.IntLit:
 		origVal := ex.Value
 		if ex.Value == 0 {
@@ -112,6 +166,7 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 				func() { ex.Value = 1 },
 				func() { ex.Value = origVal },
 			)
+			e.replaces(ex.Token.Literal, "1")
 		} else {
 			e.add(
 				fmt.Sprintf("int %d→0 at %s", ex.Value, ex.Pos()),
@@ -119,6 +174,7 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 				func() { ex.Value = 0 },
 				func() { ex.Value = origVal },
 			)
+			e.replaces(ex.Token.Literal, "0")
 		}
 	case *ast.StringLit:
 		origVal := ex.Value
@@ -129,6 +185,7 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 				func() { ex.Value = "mutant" },
 				func() { ex.Value = origVal },
 			)
+			e.replaces(`""`, `"mutant"`)
 		} else {
 			e.add(
 				fmt.Sprintf("string %q→\"\" at %s", ex.Value, ex.Pos()),
@@ -136,18 +193,19 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 				func() { ex.Value = "" },
 				func() { ex.Value = origVal },
 			)
+			e.replaces(`"`+ex.Token.Literal+`"`, `""`)
 		}
 	case *ast.CallExpr:
+		e.enumBuiltinSwap(ex.Fn)
 		for _, arg := range ex.Args {
 			e.enumExpr(arg)
 		}
 	case *ast.LambdaExpr:
+		e.enumLambdaIdentity(ex)
 		if ex.Body != nil {
 			e.enumExpr(ex.Body)
 		}
-		for _, stmt := range ex.Block {
-			e.enumStmt(stmt)
-		}
+		e.enumBody(ex.Block)
 	case *ast.ListLit:
 		for _, item := range ex.Items {
 			e.enumExpr(item)
@@ -163,6 +221,11 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 			func() { ex.Right = nil },
 			func() { ex.Right = origRight },
 		)
+		// A builtin piped into without arguments, xs |=| head, is not a
+		// call of its own to be reached below.
+		if _, ok := ex.Right.(*ast.Ident); ok {
+			e.enumBuiltinSwap(ex.Right)
+		}
 		e.enumExpr(ex.Left)
 		e.enumExpr(ex.Right)
 	case *ast.CatchExpr:
@@ -181,12 +244,146 @@ func (e *enumerator) enumExpr(expr ast.Expr) {
 		}
 	case *ast.MatchExpr:
 		e.enumExpr(ex.Subject)
+		e.enumArmRemove(ex)
 		for _, arm := range ex.Arms {
 			e.enumExpr(arm.Body)
 		}
 	}
 }
 
+// declaredNames collects what the program declares: its top-level functions,
+// globals and types, and the parameters, locals and loop variables declared
+// inside them. Scope is not followed; a name declared anywhere counts
+// everywhere, which can only cost a mutant, never make a wrong one.
+func declaredNames(prog *ast.Program) map[string]bool {
+	names := make(map[string]bool)
+	params := func(ps []ast.Param) {
+		for _, p := range ps {
+			names[p.Name] = true
+		}
+	}
+	for _, stmt := range prog.Stmts {
+		for _, name := range declared(stmt) {
+			names[name] = true
+		}
+	}
+	walkStmts(prog, func(stmt ast.Stmt) {
+		switch s := stmt.(type) {
+		case *ast.FuncStmt:
+			params(s.Params)
+		case *ast.VarStmt:
+			names[s.Name] = true
+		case *ast.RangeStmt:
+			names[s.Var] = true
+			names[s.IndexVar] = true
+		}
+	})
+	walkExprs(prog, func(expr ast.Expr) {
+		if le, ok := expr.(*ast.LambdaExpr); ok {
+			params(le.Params)
+		}
+	})
+	delete(names, "")
+	return names
+}
+
+// builtinSwaps are the builtins that have a counterpart a test should tell
+// them from.
+var builtinSwaps = map[string]string{
+	"lick":  "picky",
+	"picky": "lick",
+	"head":  "tail",
+	"tail":  "head",
+}
+
+// enumBuiltinSwap swaps a call of a builtin for its counterpart.
+//
+// A call is only taken for the builtin when nothing in the program answers to
+// its name. head and tail are not keywords, so a function, a parameter or a
+// local may be called that too, and swapping it for the other builtin would
+// be a mutant of the user's code rather than of their choice of builtin. A Go
+// function is always called through its package and never gets here.
+func (e *enumerator) enumBuiltinSwap(fn ast.Expr) {
+	ident, ok := fn.(*ast.Ident)
+	if !ok || e.declared[ident.Name] {
+		return
+	}
+	swapped, ok := builtinSwaps[ident.Name]
+	if !ok {
+		return
+	}
+	origName := ident.Name
+	e.add(
+		fmt.Sprintf("swap %s→%s at %s", origName, swapped, ident.Pos()),
+		ident.Pos(), BuiltinSwap,
+		func() { ident.Name = swapped },
+		func() { ident.Name = origName },
+	)
+	e.replaces(origName, swapped)
+}
+
+// enumArmRemove drops each arm of a peek in turn, so that a value the arm was
+// for falls through to the next. A peek of a single arm is left alone: without
+// it, there is nothing left to match.
+func (e *enumerator) enumArmRemove(ex *ast.MatchExpr) {
+	if len(ex.Arms) < 2 {
+		return
+	}
+	origArms := ex.Arms
+	for i, arm := range origArms {
+		e.add(
+			fmt.Sprintf("remove peek arm %d at %s", i+1, arm.Pattern.Pos()),
+			arm.Pattern.Pos(), ArmRemove,
+			func() {
+				ex.Arms = append(append([]ast.MatchArm{}, origArms[:i]...), origArms[i+1:]...)
+			},
+			func() { ex.Arms = origArms },
+		)
+	}
+}
+
+// enumLambdaIdentity has a lambda give back its first parameter instead of
+// what its body works out, unless that is what the body already does.
+func (e *enumerator) enumLambdaIdentity(ex *ast.LambdaExpr) {
+	if len(ex.Params) == 0 {
+		return
+	}
+	param := ex.Params[0].Name
+	if ident, ok := ex.Body.(*ast.Ident); ok && ident.Name == param {
+		return
+	}
+	origBody, origBlock := ex.Body, ex.Block
+	e.add(
+		fmt.Sprintf("replace lambda body with %s at %s", param, ex.Pos()),
+		ex.Pos(), LambdaIdentity,
+		func() {
+			ex.Body = &ast.Ident{Token: ex.Token, Name: param}
+			ex.Block = nil
+		},
+		func() { ex.Body, ex.Block = origBody, origBlock },
+	)
+}
+
+// enumBranchSwap swaps the bodies of a sniff and of the scratch sniff that
+// follows it, so that each runs when the other was to. A lone sniff and
+// scratch are not swapped: that is the same mutant as negating the condition,
+// which they already have.
+func (e *enumerator) enumBranchSwap(s *ast.IfStmt) {
+	if len(s.ElseBody) != 1 {
+		return
+	}
+	next, ok := s.ElseBody[0].(*ast.IfStmt)
+	if !ok {
+		return
+	}
+	swap := func() { s.Body, next.Body = next.Body, s.Body }
+	e.add(
+		fmt.Sprintf("swap sniff branches at %s", s.Pos()),
+		s.Pos(), BranchSwap,
+		swap, swap,
+	)
+}
+
 var arithmeticSwaps = map[token.TokenType]token.TokenType{
 	token.PLUS:  token.MINUS,
 	token.MINUS: token.PLUS,
@@ -208,6 +405,24 @@ var logicalSwaps = map[token.TokenType]token.TokenType{
 	token.OR:  token.AND,
 }
 
+// opText is how the operators a mutant swaps in are written.
+var opText = map[token.TokenType]string{
+	token.PLUS:  "+",
+	token.MINUS: "-",
+	token.STAR:  "*",
+	token.SLASH: "/",
+	token.EQ:    "==",
+	token.NEQ:   "!=",
+	token.LT:    "<",
+	token.LTE:   "<=",
+	token.GT:    ">",
+	token.GTE:   ">=",
+	token.AND:   "&&",
+	token.OR:    "||",
+}
+
+var boolText = map[bool]string{true: "yarn", false: "hairball"}
+
 func (e *enumerator) enumBinary(ex *ast.BinaryExpr) {
 	origOp := ex.Op
 	if swapped, ok := arithmeticSwaps[ex.Op]; ok {
@@ -217,6 +432,7 @@ func (e *enumerator) enumBinary(ex *ast.BinaryExpr) {
 			func() { ex.Op = swapped },
 			func() { ex.Op = origOp },
 		)
+		e.replaces(ex.Token.Literal, opText[swapped])
 	}
 	if swapped, ok := comparisonSwaps[ex.Op]; ok {
 		e.add(
@@ -225,6 +441,7 @@ func (e *enumerator) enumBinary(ex *ast.BinaryExpr) {
 			func() { ex.Op = swapped },
 			func() { ex.Op = origOp },
 		)
+		e.replaces(ex.Token.Literal, opText[swapped])
 	}
 	if swapped, ok := logicalSwaps[ex.Op]; ok {
 		e.add(
@@ -233,6 +450,7 @@ func (e *enumerator) enumBinary(ex *ast.BinaryExpr) {
 			func() { ex.Op = swapped },
 			func() { ex.Op = origOp },
 		)
+		e.replaces(ex.Token.Literal, opText[swapped])
 	}
 }
 
@@ -252,5 +470,6 @@ func (e *enumerator) enumUnary(ex *ast.UnaryExpr) {
 				ex.Right = origRight
 			},
 		)
+		e.replaces(ex.Token.Literal, "")
 	}
 }
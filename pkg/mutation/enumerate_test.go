@@ -260,3 +260,156 @@ func TestEnumerateMultipleMutations(t *testing.T) {
 		t.Error("expected ReturnNil mutation")
 	}
 }
+
+func TestEnumerateCollectionAndControlFlow(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kind  mutation.MutantKind
+	}{
+		{"lick", `nyan x = lick([1], paw(n) { n + 1 })`, mutation.BuiltinSwap},
+		{"piped picky", `nyan x = [1] |=| picky(paw(n) { n > 0 })`, mutation.BuiltinSwap},
+		{"head", `nyan x = head([1, 2])`, mutation.BuiltinSwap},
+		{"lambda", `nyan f = paw(n) { n + 1 }`, mutation.LambdaIdentity},
+		{"peek arm", `nyan x = peek(1) { 1 => "a", _ => "b" }`, mutation.ArmRemove},
+		{"bolt", `purr x ([1]) { bolt }`, mutation.BoltToSlink},
+		{"sniff branches", `sniff (yarn) { nya(1) } scratch sniff (hairball) { nya(2) }`, mutation.BranchSwap},
+		{"range", `purr i (1..3) { nya(i) }`, mutation.RangeShrink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog := parse(t, tt.input)
+			mutants := mutation.Enumerate(prog)
+			found := false
+			for _, m := range mutants {
+				if m.Kind == tt.kind {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected a mutation of kind %d", tt.kind)
+			}
+
+			schema := mutation.BuildSchema(prog, mutants)
+			for _, m := range mutants {
+				if m.Kind != tt.kind {
+					continue
+				}
+				if !inSchema(schema, m.ID) {
+					t.Errorf("mutant %d (%s) has no schema entry", m.ID, m.Description)
+				}
+			}
+		})
+	}
+}
+
+func inSchema(schema map[ast.Node][]mutation.MutationEntry, id mutation.MutantID) bool {
+	for _, entries := range schema {
+		for _, e := range entries {
+			if e.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestEnumerateNoBranchSwapWithoutElseIf(t *testing.T) {
+	prog := parse(t, `sniff (yarn) { nya(1) } scratch { nya(2) }`)
+	for _, m := range mutation.Enumerate(prog) {
+		if m.Kind == mutation.BranchSwap {
+			t.Errorf("unexpected BranchSwap: %s", m.Description)
+		}
+	}
+}
+
+func TestEnumerateNoBuiltinSwapForANameTheProgramDeclares(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"function", "meow head(xs int) int {\n  bring xs\n}\nnyan x = head(1)"},
+		{"parameter", "meow first(tail int) int {\n  bring tail(1)\n}"},
+		{"lambda parameter", `nyan f = paw(head) { head([1]) }`},
+		{"local", "meow first() int {\n  nyan tail = paw(n) { n }\n  bring tail(1)\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, m := range mutation.Enumerate(parse(t, tt.input)) {
+				if m.Kind == mutation.BuiltinSwap {
+					t.Errorf("unexpected BuiltinSwap: %s", m.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestEnumerateRangeShrinkMovesEachBound(t *testing.T) {
+	prog := parse(t, `purr i (1..3) { nya(i) }`)
+	rs := prog.Stmts[0].(*ast.RangeStmt)
+	start, end := rs.Start, rs.End
+
+	var shrinks []mutation.Mutant
+	mutants := mutation.Enumerate(prog)
+	for _, m := range mutants {
+		if m.Kind == mutation.RangeShrink {
+			shrinks = append(shrinks, m)
+		}
+	}
+	if len(shrinks) != 2 {
+		t.Fatalf("got %d RangeShrink mutants, want one for each bound", len(shrinks))
+	}
+	var moved []string
+	for _, m := range shrinks {
+		m.Apply()
+		switch {
+		case rs.Start != start && rs.End == end:
+			moved = append(moved, "start")
+		case rs.End != end && rs.Start == start:
+			moved = append(moved, "end")
+		default:
+			t.Errorf("%s moved both bounds or neither", m.Description)
+		}
+		m.Undo()
+		if rs.Start != start || rs.End != end {
+			t.Errorf("%s: Undo did not restore the range", m.Description)
+		}
+	}
+	if len(moved) != 2 || moved[0] == moved[1] {
+		t.Errorf("bounds moved = %v, want the end and the start", moved)
+	}
+
+	schema := mutation.BuildSchema(prog, mutants)
+	for _, key := range []ast.Node{start, end} {
+		n := 0
+		for _, entry := range schema[key] {
+			for _, m := range shrinks {
+				if entry.ID == m.ID {
+					n++
+				}
+			}
+		}
+		if n != 1 {
+			t.Errorf("schema has %d RangeShrink entries for the bound at %s, want 1", n, key.Pos())
+		}
+	}
+}
+
+func TestApplyUndoArmRemove(t *testing.T) {
+	prog := parse(t, `nyan x = peek(1) { 1 => "a", 2 => "b", _ => "c" }`)
+	match := prog.Stmts[0].(*ast.VarStmt).Value.(*ast.MatchExpr)
+
+	for _, m := range mutation.Enumerate(prog) {
+		if m.Kind != mutation.ArmRemove {
+			continue
+		}
+		m.Apply()
+		if len(match.Arms) != 2 {
+			t.Errorf("%s: expected 2 arms after apply, got %d", m.Description, len(match.Arms))
+		}
+		m.Undo()
+		if len(match.Arms) != 3 {
+			t.Errorf("%s: expected 3 arms after undo, got %d", m.Description, len(match.Arms))
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package mutation
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// WriteHTML writes files as a page of their source, line by line, with the
+// mutants that got past the tests shown under the line they change: the
+// line as the mutant has it, when it changes a single token, and what it
+// does otherwise. A line whose mutants were all killed is only marked as
+// such, for the page to be about what the tests miss.
+func WriteHTML(w io.Writer, files []FileResult) error {
+	page := htmlPage{Score: Score(files)}
+	page.Class = scoreClass(page.Score)
+	for _, f := range files {
+		page.Files = append(page.Files, htmlFileOf(f))
+	}
+	return htmlTemplate.Execute(w, page)
+}
+
+type htmlPage struct {
+	Score float64
+	Class string
+	Files []htmlFile
+}
+
+type htmlFile struct {
+	Path                         string
+	Killed, Survived, NoCoverage int
+	Score                        float64
+	Class                        string
+	Lines                        []htmlLine
+}
+
+type htmlLine struct {
+	Number  int
+	Text    string
+	Class   string
+	Killed  int
+	Mutants []htmlMutant
+}
+
+type htmlMutant struct {
+	ID          MutantID
+	Status      string
+	Class       string
+	Description string
+	// Inline is whether the line is shown as the mutant has it: Before,
+	// Original given way to Replacement, and After.
+	Inline                               bool
+	Before, Original, Replacement, After string
+}
+
+func htmlFileOf(f FileResult) htmlFile {
+	hf := htmlFile{Path: f.Path, Score: Score([]FileResult{f})}
+	hf.Class = scoreClass(hf.Score)
+	text := strings.Split(strings.TrimRight(string(f.Source), "\n"), "\n")
+	hf.Lines = make([]htmlLine, len(text))
+	for i, t := range text {
+		hf.Lines[i] = htmlLine{Number: i + 1, Text: t}
+	}
+
+	status := f.statusOf()
+	for _, m := range f.Mutants {
+		s, ok := status[m.ID]
+		if !ok {
+			continue
+		}
+		switch s {
+		case Killed:
+			hf.Killed++
+		case NoCoverage:
+			hf.NoCoverage++
+		default:
+			hf.Survived++
+		}
+		if m.Pos.Line < 1 || m.Pos.Line > len(hf.Lines) {
+			continue
+		}
+		line := &hf.Lines[m.Pos.Line-1]
+		if s == Killed {
+			line.Killed++
+			continue
+		}
+		hm := htmlMutant{ID: m.ID, Status: s.String(), Class: statusClass(s), Description: m.Description}
+		hm.Before, hm.After, hm.Inline = splice(line.Text, m)
+		hm.Original, hm.Replacement = m.Original, m.Replacement
+		line.Mutants = append(line.Mutants, hm)
+	}
+
+	for i := range hf.Lines {
+		line := &hf.Lines[i]
+		switch {
+		case len(line.Mutants) > 0:
+			// A survivor is the worse news of the two.
+			line.Class = "nocoverage"
+			for _, m := range line.Mutants {
+				if m.Class == "survived" {
+					line.Class = "survived"
+				}
+			}
+		case line.Killed > 0:
+			line.Class = "killed"
+		}
+	}
+	return hf
+}
+
+// splice is line either side of the token m changes, if it does change a
+// single token and the line has it where m says.
+func splice(line string, m Mutant) (before, after string, ok bool) {
+	if m.Original == "" {
+		return "", "", false
+	}
+	runes := []rune(line)
+	col := m.Pos.Column - 1
+	if col < 0 || col > len(runes) {
+		return "", "", false
+	}
+	rest := string(runes[col:])
+	if !strings.HasPrefix(rest, m.Original) {
+		return "", "", false
+	}
+	return string(runes[:col]), rest[len(m.Original):], true
+}
+
+func statusClass(s Status) string {
+	switch s {
+	case Killed:
+		return "killed"
+	case NoCoverage:
+		return "nocoverage"
+	default:
+		return "survived"
+	}
+}
+
+func scoreClass(score float64) string {
+	switch {
+	case score >= HighThreshold:
+		return "high"
+	case score >= LowThreshold:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+var htmlTemplate = template.Must(template.New("mutation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mutation report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table.summary { border-collapse: collapse; margin-bottom: 2em; }
+table.summary th, table.summary td { padding: 0.3em 1em; text-align: right; border-bottom: 1px solid #ddd; }
+table.summary th:first-child, table.summary td:first-child { text-align: left; }
+.high { color: #1a7f37; }
+.medium { color: #9a6700; }
+.low { color: #cf222e; }
+table.source { border-collapse: collapse; font-family: monospace; width: 100%; }
+table.source td { padding: 0 0.5em; white-space: pre; vertical-align: top; }
+td.number { color: #888; text-align: right; user-select: none; width: 3em; }
+td.mark { width: 5em; font-size: 0.85em; }
+tr.killed td.code { background: #dafbe1; }
+tr.survived td.code { background: #ffebe9; }
+tr.nocoverage td.code { background: #fff8c5; }
+tr.mutant td { font-size: 0.9em; padding-bottom: 0.3em; }
+span.status { display: inline-block; min-width: 7em; font-weight: bold; }
+span.status.survived { color: #cf222e; }
+span.status.nocoverage { color: #9a6700; }
+del { background: #ffcecb; }
+ins { background: #aceebb; text-decoration: none; }
+.description { color: #555; }
+</style>
+</head>
+<body>
+<h1>Mutation report</h1>
+<p>Mutation score: <strong class="{{.Class}}">{{printf "%.1f" .Score}}%</strong></p>
+<table class="summary">
+<tr><th>File</th><th>Killed</th><th>Survived</th><th>No coverage</th><th>Score</th></tr>
+{{- range $i, $f := .Files}}
+<tr><td><a href="#file-{{$i}}">{{$f.Path}}</a></td><td>{{$f.Killed}}</td><td>{{$f.Survived}}</td><td>{{$f.NoCoverage}}</td><td class="{{$f.Class}}">{{printf "%.1f" $f.Score}}%</td></tr>
+{{- end}}
+</table>
+{{- range $i, $f := .Files}}
+<h2 id="file-{{$i}}">{{$f.Path}} <span class="{{$f.Class}}">{{printf "%.1f" $f.Score}}%</span></h2>
+<table class="source">
+{{- range $f.Lines}}
+<tr id="file-{{$i}}-L{{.Number}}" class="{{.Class}}"><td class="number">{{.Number}}</td><td class="mark">{{if .Killed}}{{.Killed}} killed{{end}}</td><td class="code">{{.Text}}</td></tr>
+{{- range .Mutants}}
+<tr class="mutant"><td></td><td></td><td><span class="status {{.Class}}">{{.Status}}</span> {{if .Inline}}{{.Before}}<del>{{.Original}}</del><ins>{{.Replacement}}</ins>{{.After}}  {{end}}<span class="description">#{{.ID}} {{.Description}}</span></td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
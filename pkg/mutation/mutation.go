@@ -1,6 +1,10 @@
 package mutation
 
-import "github.com/135yshr/meow/pkg/token"
+import (
+	"fmt"
+
+	"github.com/135yshr/meow/pkg/token"
+)
 
 // MutantID uniquely identifies a mutation.
 type MutantID int
@@ -20,20 +24,78 @@ const (
 	ReturnNil                         // bring x→bring catnap
 	CatchRemove                       // expr ~> fallback → expr
 	PipeRemove                        // xs |=| f → xs
+	BuiltinSwap                       // lick↔picky, head↔tail
+	ArmRemove                         // drop one arm of a peek
+	LambdaIdentity                    // paw(x) { ... } → paw(x) { x }
+	BoltToSlink                       // bolt → slink
+	BranchSwap                        // sniff (a) {A} scratch sniff (b) {B} → {B} … {A}
+	RangeShrink                       // a..b → a..b-1, a..b → a+1..b
 )
 
+var kindNames = [...]string{
+	ArithmeticSwap:  "ArithmeticSwap",
+	ComparisonSwap:  "ComparisonSwap",
+	LogicalSwap:     "LogicalSwap",
+	NegationRemoval: "NegationRemoval",
+	BoolFlip:        "BoolFlip",
+	IntBoundary:     "IntBoundary",
+	StringEmpty:     "StringEmpty",
+	ConditionNegate: "ConditionNegate",
+	ReturnNil:       "ReturnNil",
+	CatchRemove:     "CatchRemove",
+	PipeRemove:      "PipeRemove",
+	BuiltinSwap:     "BuiltinSwap",
+	ArmRemove:       "ArmRemove",
+	LambdaIdentity:  "LambdaIdentity",
+	BoltToSlink:     "BoltToSlink",
+	BranchSwap:      "BranchSwap",
+	RangeShrink:     "RangeShrink",
+}
+
+func (k MutantKind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("MutantKind(%d)", int(k))
+}
+
 // Mutant represents a single mutation that can be applied to and reverted from the AST.
 type Mutant struct {
 	ID          MutantID
 	Description string
 	Pos         token.Position
 	Kind        MutantKind
+	// Original and Replacement are, for a mutant that changes a single
+	// token, the source text at Pos and what the mutant has there instead.
+	// A mutant that changes more than a token has neither.
+	Original    string
+	Replacement string
 	Apply       func()
 	Undo        func()
 }
 
+// Status is what became of a mutant.
+type Status int
+
+const (
+	Survived   Status = iota // the tests passed with it
+	Killed                   // a test failed, or ran out of time
+	NoCoverage               // no test reaches it, so none was run
+)
+
+func (s Status) String() string {
+	switch s {
+	case Killed:
+		return "killed"
+	case NoCoverage:
+		return "no coverage"
+	default:
+		return "survived"
+	}
+}
+
 // RunResult holds the outcome of running tests against a single mutant.
 type RunResult struct {
 	ID     MutantID
-	Killed bool
+	Status Status
 }
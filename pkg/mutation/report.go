@@ -5,15 +5,21 @@ import (
 	"io"
 )
 
-// Report writes the mutation testing results to the given writer.
+// Report writes the mutation testing results to the given writer. The score
+// is of all the mutants: one no test reaches counts against it as much as one
+// that survives the tests.
 func Report(w io.Writer, mutants []Mutant, results []RunResult) {
 	killed := 0
 	survived := 0
+	uncovered := 0
 
 	for _, r := range results {
-		if r.Killed {
+		switch r.Status {
+		case Killed:
 			killed++
-		} else {
+		case NoCoverage:
+			uncovered++
+		default:
 			survived++
 		}
 	}
@@ -28,20 +34,71 @@ func Report(w io.Writer, mutants []Mutant, results []RunResult) {
 	fmt.Fprintf(w, "Total mutants: %d\n", total)
 	fmt.Fprintf(w, "Killed: %d\n", killed)
 	fmt.Fprintf(w, "Survived: %d\n", survived)
+	if uncovered > 0 {
+		fmt.Fprintf(w, "No coverage: %d\n", uncovered)
+	}
 	fmt.Fprintf(w, "Mutation score: %.1f%%\n", score)
 
-	if survived > 0 {
-		fmt.Fprintf(w, "\n--- Surviving Mutants ---\n")
-		for _, r := range results {
-			if !r.Killed {
-				for _, m := range mutants {
-					if m.ID == r.ID {
-						fmt.Fprintf(w, "  [%d] %s (%s)\n", m.ID, m.Description, m.Pos)
-						break
-					}
-				}
+	listMutants(w, "Surviving Mutants", Survived, mutants, results)
+	listMutants(w, "Mutants No Test Reaches", NoCoverage, mutants, results)
+	fmt.Fprintln(w)
+}
+
+// FileResult is what came of testing the mutants of one source file, for the
+// reports that cover a whole run.
+type FileResult struct {
+	// Path is the source file as it was named on the command line.
+	Path    string
+	Source  []byte
+	Mutants []Mutant
+	Results []RunResult
+}
+
+// Score is the mutation score of files together, as Report works it out for
+// one: the percentage of all their mutants that were killed. A run with no
+// mutants scores 100, as nothing in it got past the tests.
+func Score(files []FileResult) float64 {
+	killed, total := 0, 0
+	for _, f := range files {
+		for _, r := range f.Results {
+			if r.Status == Killed {
+				killed++
+			}
+		}
+		total += len(f.Results)
+	}
+	if total == 0 {
+		return 100
+	}
+	return float64(killed) / float64(total) * 100
+}
+
+// statusOf is what became of each mutant, by its ID.
+func (f FileResult) statusOf() map[MutantID]Status {
+	status := make(map[MutantID]Status, len(f.Results))
+	for _, r := range f.Results {
+		status[r.ID] = r.Status
+	}
+	return status
+}
+
+// listMutants lists, under a heading, the mutants that came to status, if
+// any did.
+func listMutants(w io.Writer, heading string, status Status, mutants []Mutant, results []RunResult) {
+	header := false
+	for _, r := range results {
+		if r.Status != status {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\n--- %s ---\n", heading)
+			header = true
+		}
+		for _, m := range mutants {
+			if m.ID == r.ID {
+				fmt.Fprintf(w, "  [%d] %s (%s)\n", m.ID, m.Description, m.Pos)
+				break
 			}
 		}
 	}
-	fmt.Fprintln(w)
 }
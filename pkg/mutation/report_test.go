@@ -0,0 +1,109 @@
+package mutation_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/pkg/mutation"
+)
+
+// fileResult has the mutants of source killed, but for those of the kinds in
+// survived.
+func fileResult(t *testing.T, source string, survived ...mutation.MutantKind) mutation.FileResult {
+	t.Helper()
+	mutants := mutation.Enumerate(parse(t, source))
+	var results []mutation.RunResult
+	for _, m := range mutants {
+		status := mutation.Killed
+		for _, k := range survived {
+			if m.Kind == k {
+				status = mutation.Survived
+			}
+		}
+		results = append(results, mutation.RunResult{ID: m.ID, Status: status})
+	}
+	return mutation.FileResult{Path: "test.nyan", Source: []byte(source), Mutants: mutants, Results: results}
+}
+
+func TestScore(t *testing.T) {
+	// One mutant, the swap, survives of the three of 1 + 2 ...
+	some := fileResult(t, "nyan x = 1 + 2", mutation.ArithmeticSwap)
+	if got, want := mutation.Score([]mutation.FileResult{some}), float64(2)/3*100; got != want {
+		t.Errorf("Score = %v, want %v", got, want)
+	}
+	// ... and none of another file's three.
+	all := fileResult(t, "nyan y = 3 * 4")
+	if got, want := mutation.Score([]mutation.FileResult{some, all}), float64(5)/6*100; got != want {
+		t.Errorf("Score = %v, want %v", got, want)
+	}
+	if got := mutation.Score(nil); got != 100 {
+		t.Errorf("Score of nothing = %v, want 100", got)
+	}
+}
+
+func TestWriteJSONIsAStrykerReport(t *testing.T) {
+	f := fileResult(t, "nyan x = 1 + 2\nmeow f() {\n  bring 3\n}\n", mutation.ArithmeticSwap)
+	var b strings.Builder
+	if err := mutation.WriteJSON(&b, []mutation.FileResult{f}); err != nil {
+		t.Fatal(err)
+	}
+
+	var report struct {
+		SchemaVersion string
+		Files         map[string]struct {
+			Language string
+			Source   string
+			Mutants  []struct {
+				ID          string
+				MutatorName string
+				Replacement *string
+				Status      string
+				Location    struct {
+					Start, End struct{ Line, Column int }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &report); err != nil {
+		t.Fatalf("not JSON: %v\n%s", err, b.String())
+	}
+	file, ok := report.Files["test.nyan"]
+	if !ok || file.Source != string(f.Source) || report.SchemaVersion == "" {
+		t.Fatalf("report has no test.nyan with its source:\n%s", b.String())
+	}
+	for _, m := range file.Mutants {
+		switch m.MutatorName {
+		case "ArithmeticSwap":
+			if m.Status != "Survived" || m.Replacement == nil || *m.Replacement != "-" {
+				t.Errorf("swap: status %s, replacement %v", m.Status, m.Replacement)
+			}
+			if m.Location.Start.Column != 12 || m.Location.End.Column != 13 {
+				t.Errorf("swap spans columns %d to %d, want 12 to 13", m.Location.Start.Column, m.Location.End.Column)
+			}
+		case "ReturnNil":
+			// Not a single token: it spans the rest of its line.
+			if m.Status != "Killed" || m.Replacement != nil || m.Location.End.Column != 10 {
+				t.Errorf("return: status %s, replacement %v, end %d", m.Status, m.Replacement, m.Location.End.Column)
+			}
+		}
+	}
+}
+
+func TestWriteHTMLShowsSurvivorsOnTheirLine(t *testing.T) {
+	f := fileResult(t, "nyan x = 1 + 2\nnyan y = 3 > 4\n", mutation.ComparisonSwap)
+	var b strings.Builder
+	if err := mutation.WriteHTML(&b, []mutation.FileResult{f}); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	if !strings.Contains(page, "nyan y = 3 <del>&gt;</del><ins>&gt;=</ins> 4") {
+		t.Errorf("page does not show the surviving swap in its line:\n%s", page)
+	}
+	if strings.Contains(page, "<del>+</del>") {
+		t.Errorf("page shows a killed mutant as the line it changes:\n%s", page)
+	}
+	if !strings.Contains(page, `class="killed"`) {
+		t.Errorf("page does not mark the line whose mutants were killed:\n%s", page)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,6 +16,14 @@ import (
 type Runner struct {
 	BinaryPath  string
 	TestTimeout time.Duration
+	// Workers is how many mutants are tested at once. NewRunner has it the
+	// number of CPUs: each mutant is a process of its own, and they share
+	// nothing.
+	Workers int
+	// Coverage, when there is one, has each mutant tested with only the
+	// tests that reach it, and one no test reaches reported as NoCoverage
+	// without running any.
+	Coverage *Coverage
 }
 
 // NewRunner creates a new mutation test runner.
@@ -22,27 +34,45 @@ func NewRunner(binaryPath string, timeout time.Duration) *Runner {
 	return &Runner{
 		BinaryPath:  binaryPath,
 		TestTimeout: timeout,
+		Workers:     runtime.NumCPU(),
 	}
 }
 
-// RunAll executes the test binary once per mutant.
-// A mutant is "killed" if the test binary exits with non-zero status.
+// RunAll tests each mutant, Workers of them at once, and gives back their
+// results in the order of mutants. A mutant is killed if the test binary
+// exits with non-zero status.
 func (r *Runner) RunAll(mutants []Mutant) []RunResult {
 	results := make([]RunResult, len(mutants))
-	for i, m := range mutants {
-		results[i] = RunResult{
-			ID:     m.ID,
-			Killed: r.runOne(m.ID),
+	Each(len(mutants), r.Workers, func(i int) {
+		m := mutants[i]
+		results[i] = RunResult{ID: m.ID, Status: r.runMutant(m)}
+	})
+	return results
+}
+
+func (r *Runner) runMutant(m Mutant) Status {
+	var env []string
+	if r.Coverage != nil {
+		tests, known := r.Coverage.Tests(m.Pos)
+		if known && len(tests) == 0 {
+			return NoCoverage
+		}
+		if known {
+			env = append(env, "MEOW_TEST_RUN="+OnlyTests(tests))
 		}
 	}
-	return results
+	if r.runOne(m.ID, env) {
+		return Killed
+	}
+	return Survived
 }
 
-// runOne runs the test binary with MEOW_MUTANT set to the given mutant ID.
-// Returns true if the mutant was killed (test failed).
-func (r *Runner) runOne(id MutantID) bool {
+// runOne runs the test binary with MEOW_MUTANT set to the given mutant ID,
+// and env besides. Returns true if the mutant was killed (test failed).
+func (r *Runner) runOne(id MutantID, env []string) bool {
 	cmd := exec.Command(r.BinaryPath)
 	cmd.Env = append(os.Environ(), fmt.Sprintf("MEOW_MUTANT=%d", id))
+	cmd.Env = append(cmd.Env, env...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
@@ -61,3 +91,34 @@ func (r *Runner) runOne(id MutantID) bool {
 		return true // Timeout = killed
 	}
 }
+
+// OnlyTests is the -run expression that runs the tests named and no others.
+func OnlyTests(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// Each calls fn with each of 0 to n-1, workers of them at once, and returns
+// when they have all returned.
+func Each(n, workers int, fn func(i int)) {
+	workers = max(1, min(workers, n))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -1,17 +1,23 @@
 package mutation
 
-import "github.com/135yshr/meow/pkg/ast"
+import (
+	"github.com/135yshr/meow/pkg/ast"
+	"github.com/135yshr/meow/pkg/token"
+)
 
-// MutationEntry maps a mutant ID to the alternative expression to generate.
+// MutationEntry maps a mutant ID to the alternative to generate: an
+// expression in place of an expression, or a statement in place of a
+// statement.
 type MutationEntry struct {
 	ID   MutantID
 	Expr ast.Expr
+	Stmt ast.Stmt
 }
 
-// BuildSchema creates a mapping from original AST expressions to their mutation entries.
+// BuildSchema creates a mapping from original AST nodes to their mutation entries.
 // This is used by codegen to embed all mutations in a single binary.
-func BuildSchema(prog *ast.Program, mutants []Mutant) map[ast.Expr][]MutationEntry {
-	schema := make(map[ast.Expr][]MutationEntry)
+func BuildSchema(prog *ast.Program, mutants []Mutant) map[ast.Node][]MutationEntry {
+	schema := make(map[ast.Node][]MutationEntry)
 
 	for _, m := range mutants {
 		// Apply the mutation temporarily to capture the mutated state
@@ -115,6 +121,100 @@ func BuildSchema(prog *ast.Program, mutants []Mutant) map[ast.Expr][]MutationEnt
 				}
 			})
 
+		case BuiltinSwap:
+			// A builtin piped into is called by the pipe, which puts the call
+			// together from its two sides; it is the pipe that has the
+			// alternative.
+			found := false
+			walkExprs(prog, func(expr ast.Expr) {
+				pe, ok := expr.(*ast.PipeExpr)
+				if !ok || found {
+					return
+				}
+				if right := swapCallee(pe.Right, m.Pos); right != nil {
+					entry.Expr = &ast.PipeExpr{Token: pe.Token, Left: pe.Left, Right: right}
+					schema[pe] = append(schema[pe], entry)
+					found = true
+				}
+			})
+			walkExprs(prog, func(expr ast.Expr) {
+				if ce, ok := expr.(*ast.CallExpr); ok && !found && ce.Pos() == m.Pos {
+					entry.Expr = swapCallee(ce, m.Pos)
+					schema[ce] = append(schema[ce], entry)
+					found = true
+				}
+			})
+
+		case ArmRemove:
+			// The mutant is at the arm it drops, which is no longer there.
+			m.Undo()
+			walkExprs(prog, func(expr ast.Expr) {
+				if me, ok := expr.(*ast.MatchExpr); ok && hasArmAt(me, m.Pos) {
+					m.Apply()
+					entry.Expr = &ast.MatchExpr{Token: me.Token, Subject: me.Subject, Arms: me.Arms}
+					schema[me] = append(schema[me], entry)
+				}
+			})
+
+		case LambdaIdentity:
+			walkExprs(prog, func(expr ast.Expr) {
+				if le, ok := expr.(*ast.LambdaExpr); ok && le.Pos() == m.Pos {
+					entry.Expr = &ast.LambdaExpr{Token: le.Token, Params: le.Params, Body: le.Body}
+					schema[le] = append(schema[le], entry)
+				}
+			})
+
+		case RangeShrink:
+			// Undo to capture the original bounds, then Apply to see which of
+			// them the mutant moves: the original is the schema key and the
+			// moved bound is entry.Expr.
+			m.Undo()
+			walkStmts(prog, func(stmt ast.Stmt) {
+				if rs, ok := stmt.(*ast.RangeStmt); ok && rs.Pos() == m.Pos {
+					start, end := rs.Start, rs.End
+					m.Apply()
+					if rs.Start != start {
+						entry.Expr = rs.Start
+						schema[start] = append(schema[start], entry)
+					} else {
+						entry.Expr = rs.End
+						schema[end] = append(schema[end], entry)
+					}
+				}
+			})
+
+		case BoltToSlink:
+			// Undo to find the bolt the slink stands in for.
+			m.Undo()
+			walkStmts(prog, func(stmt ast.Stmt) {
+				if bs, ok := stmt.(*ast.BoltStmt); ok && bs.Pos() == m.Pos {
+					entry.Stmt = &ast.SlinkStmt{Token: bs.Token}
+					schema[bs] = append(schema[bs], entry)
+				}
+			})
+			m.Apply()
+
+		case BranchSwap:
+			walkStmts(prog, func(stmt ast.Stmt) {
+				is, ok := stmt.(*ast.IfStmt)
+				if !ok || is.Pos() != m.Pos {
+					return
+				}
+				next := is.ElseBody[0].(*ast.IfStmt)
+				entry.Stmt = &ast.IfStmt{
+					Token:     is.Token,
+					Condition: is.Condition,
+					Body:      is.Body,
+					ElseBody: []ast.Stmt{&ast.IfStmt{
+						Token:     next.Token,
+						Condition: next.Condition,
+						Body:      next.Body,
+						ElseBody:  next.ElseBody,
+					}},
+				}
+				schema[is] = append(schema[is], entry)
+			})
+
 		case ReturnNil:
 			// Undo to capture original value pointer as schema key,
 			// then Apply to capture the nil literal as entry.Expr.
@@ -135,6 +235,33 @@ func BuildSchema(prog *ast.Program, mutants []Mutant) map[ast.Expr][]MutationEnt
 	return schema
 }
 
+// swapCallee is a copy of the call or bare function fn with the builtin at
+// pos called by the name the mutant gave it, or nil when fn is not that. The
+// copy has an identifier of its own, as the one it is copied from gets its
+// name back when the mutant is undone.
+func swapCallee(fn ast.Expr, pos token.Position) ast.Expr {
+	switch f := fn.(type) {
+	case *ast.Ident:
+		if f.Pos() == pos {
+			return &ast.Ident{Token: f.Token, Name: f.Name}
+		}
+	case *ast.CallExpr:
+		if ident, ok := f.Fn.(*ast.Ident); ok && ident.Pos() == pos {
+			return &ast.CallExpr{Token: f.Token, Fn: swapCallee(ident, pos), Args: f.Args}
+		}
+	}
+	return nil
+}
+
+func hasArmAt(me *ast.MatchExpr, pos token.Position) bool {
+	for _, arm := range me.Arms {
+		if arm.Pattern.Pos() == pos {
+			return true
+		}
+	}
+	return false
+}
+
 // walkStmts walks all statements in the program, calling fn for each.
 func walkStmts(prog *ast.Program, fn func(ast.Stmt)) {
 	for _, stmt := range prog.Stmts {
@@ -160,6 +287,11 @@ func walkStmtTree(stmt ast.Stmt, fn func(ast.Stmt)) {
 		for _, body := range s.Body {
 			walkStmtTree(body, fn)
 		}
+	case *ast.WhileStmt:
+		walkExprStmts(s.Cond, fn)
+		for _, body := range s.Body {
+			walkStmtTree(body, fn)
+		}
 	case *ast.VarStmt:
 		walkExprStmts(s.Value, fn)
 	case *ast.ExprStmt:
@@ -210,6 +342,11 @@ func walkStmtExprs(stmt ast.Stmt, fn func(ast.Expr)) {
 		for _, body := range s.Body {
 			walkStmtExprs(body, fn)
 		}
+	case *ast.WhileStmt:
+		walkExprTree(s.Cond, fn)
+		for _, body := range s.Body {
+			walkStmtExprs(body, fn)
+		}
 	case *ast.ReturnStmt:
 		if s.Value != nil {
 			walkExprTree(s.Value, fn)
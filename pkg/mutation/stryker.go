@@ -0,0 +1,120 @@
+package mutation
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The thresholds a report colors its scores by: at or above High is good, and
+// below Low is bad. They are Stryker's own defaults, so that a dashboard
+// shows a score the way it would show any other project's.
+const (
+	HighThreshold = 80
+	LowThreshold  = 60
+)
+
+// strykerReport is the mutation testing report schema Stryker and the
+// dashboards and viewers built on it read.
+type strykerReport struct {
+	SchemaVersion string                 `json:"schemaVersion"`
+	Thresholds    strykerThresholds      `json:"thresholds"`
+	Files         map[string]strykerFile `json:"files"`
+	Framework     strykerFramework       `json:"framework"`
+}
+
+type strykerThresholds struct {
+	High int `json:"high"`
+	Low  int `json:"low"`
+}
+
+type strykerFramework struct {
+	Name string `json:"name"`
+}
+
+type strykerFile struct {
+	Language string          `json:"language"`
+	Source   string          `json:"source"`
+	Mutants  []strykerMutant `json:"mutants"`
+}
+
+type strykerMutant struct {
+	ID          string          `json:"id"`
+	MutatorName string          `json:"mutatorName"`
+	Replacement *string         `json:"replacement,omitempty"`
+	Description string          `json:"description"`
+	Location    strykerLocation `json:"location"`
+	Status      string          `json:"status"`
+}
+
+type strykerLocation struct {
+	Start strykerPosition `json:"start"`
+	End   strykerPosition `json:"end"`
+}
+
+type strykerPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+var strykerStatus = map[Status]string{
+	Killed:     "Killed",
+	Survived:   "Survived",
+	NoCoverage: "NoCoverage",
+}
+
+// WriteJSON writes files as a Stryker mutation testing report. A mutant that
+// changes a single token spans that token; any other spans the rest of the
+// line it starts on, as its end is not known. Columns count characters, as
+// positions in meow source do.
+func WriteJSON(w io.Writer, files []FileResult) error {
+	report := strykerReport{
+		SchemaVersion: "2",
+		Thresholds:    strykerThresholds{High: HighThreshold, Low: LowThreshold},
+		Files:         make(map[string]strykerFile, len(files)),
+		Framework:     strykerFramework{Name: "meow"},
+	}
+	for _, f := range files {
+		status := f.statusOf()
+		lines := strings.Split(string(f.Source), "\n")
+		mutants := make([]strykerMutant, 0, len(f.Mutants))
+		for _, m := range f.Mutants {
+			s, ok := status[m.ID]
+			if !ok {
+				continue
+			}
+			sm := strykerMutant{
+				ID:          strconv.Itoa(int(m.ID)),
+				MutatorName: m.Kind.String(),
+				Description: m.Description,
+				Location: strykerLocation{
+					Start: strykerPosition{Line: m.Pos.Line, Column: m.Pos.Column},
+					End:   strykerPosition{Line: m.Pos.Line, Column: m.Pos.Column + 1},
+				},
+				Status: strykerStatus[s],
+			}
+			if m.Pos.Line >= 1 && m.Pos.Line <= len(lines) {
+				line := lines[m.Pos.Line-1]
+				if _, _, ok := splice(line, m); ok {
+					replacement := m.Replacement
+					sm.Replacement = &replacement
+					sm.Location.End.Column = m.Pos.Column + utf8.RuneCountInString(m.Original)
+				} else {
+					sm.Location.End.Column = max(sm.Location.End.Column, utf8.RuneCountInString(line)+1)
+				}
+			}
+			mutants = append(mutants, sm)
+		}
+		report.Files[f.Path] = strykerFile{
+			Language: "meow",
+			Source:   string(f.Source),
+			Mutants:  mutants,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
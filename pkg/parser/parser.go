@@ -212,9 +212,9 @@ func (p *Parser) parseTypeExpr() ast.TypeExpr {
 	case token.TYPE_FURBALL:
 		return &ast.BasicType{Token: tok, Name: "furball"}
 	case token.TYPE_LITTER:
-		return &ast.BasicType{Token: tok, Name: "litter"}
+		return &ast.BasicType{Token: tok, Name: "litter", Elem: p.parseElemType()}
 	case token.TYPE_BASKET:
-		return &ast.BasicType{Token: tok, Name: "basket"}
+		return &ast.BasicType{Token: tok, Name: "basket", Elem: p.parseElemType()}
 	case token.IDENT:
 		return &ast.NamedType{Token: tok, Name: tok.Literal}
 	default:
@@ -223,6 +223,18 @@ func (p *Parser) parseTypeExpr() ast.TypeExpr {
 	}
 }
 
+// parseElemType parses the [int] of litter[int] or basket[int], if it is
+// there.
+func (p *Parser) parseElemType() ast.TypeExpr {
+	if p.cur.Type != token.LBRACKET {
+		return nil
+	}
+	p.advance()
+	elem := p.parseTypeExpr()
+	p.expect(token.RBRACKET)
+	return elem
+}
+
 func (p *Parser) isTypeToken() bool {
 	switch p.cur.Type {
 	case token.TYPE_INT, token.TYPE_FLOAT, token.TYPE_STRING, token.TYPE_BOOL,
@@ -452,17 +464,55 @@ func (p *Parser) parseTrickStmt() *ast.TrickStmt {
 func (p *Parser) parseLearnStmt() *ast.LearnStmt {
 	tok := p.advance() // consume groom
 	typeName := p.expect(token.IDENT)
+	// `as` is matched by what it says, the way `tag` is: only here, between
+	// the type and its brace, could it mean anything.
+	var as []ast.GoTypeRef
+	if p.cur.Type == token.IDENT && p.cur.Literal == "as" {
+		p.advance() // consume as
+		for {
+			as = append(as, p.parseGoTypeRef())
+			if p.cur.Type != token.COMMA {
+				break
+			}
+			p.advance()
+		}
+	}
 	p.skipNewlines()
 	p.expect(token.LBRACE)
 	p.skipNewlines()
 	var methods []ast.FuncStmt
 	for p.cur.Type != token.RBRACE && p.cur.Type != token.EOF {
-		fn := p.parseFuncStmt()
+		fn := p.parseMethod()
 		methods = append(methods, *fn)
 		p.skipNewlines()
 	}
 	p.expect(token.RBRACE)
-	return &ast.LearnStmt{Token: tok, TypeName: typeName.Literal, Methods: methods}
+	return &ast.LearnStmt{Token: tok, TypeName: typeName.Literal, Methods: methods, As: as}
+}
+
+// parseMethod parses a method in a groom block. It is a function whose name is
+// only ever reached after a dot, so it may be any name a member may: a groom
+// answering fmt.Stringer needs a method called string.
+func (p *Parser) parseMethod() *ast.FuncStmt {
+	tok := p.expect(token.MEOW)
+	name := p.expectMemberName()
+	p.expect(token.LPAREN)
+	params := p.parseTypedParamList()
+	p.expect(token.RPAREN)
+	var returnType ast.TypeExpr
+	if p.isTypeToken() {
+		returnType = p.parseTypeExpr()
+	}
+	body := p.parseBlock()
+	return &ast.FuncStmt{Token: tok, Name: name.Literal, Params: params, ReturnType: returnType, Body: body}
+}
+
+// parseGoTypeRef parses pkg.Name, a type a `nab go` package exports.
+func (p *Parser) parseGoTypeRef() ast.GoTypeRef {
+	pkg := p.expect(token.IDENT)
+	p.expect(token.DOT)
+	name := p.expect(token.IDENT)
+	return ast.GoTypeRef{Token: pkg, Package: pkg.Literal, Name: name.Literal}
 }
 
 func (p *Parser) parseSelfExpr() ast.Expr {
@@ -382,6 +382,24 @@ func TestUntypedVarStmt(t *testing.T) {
 	}
 }
 
+func TestTypedLitterElemStmt(t *testing.T) {
+	prog := parse(t, `meow total(xs litter[int], m basket) int {
+  bring 0
+}`)
+	fn := prog.Stmts[0].(*ast.FuncStmt)
+	bt := fn.Params[0].TypeAnn.(*ast.BasicType)
+	if bt.Name != "litter" {
+		t.Errorf("expected type 'litter', got %q", bt.Name)
+	}
+	elem, ok := bt.Elem.(*ast.BasicType)
+	if !ok || elem.Name != "int" {
+		t.Fatalf("expected element type 'int', got %v", bt.Elem)
+	}
+	if m := fn.Params[1].TypeAnn.(*ast.BasicType); m.Elem != nil {
+		t.Errorf("expected no element type on a bare basket, got %v", m.Elem)
+	}
+}
+
 func TestTypedFuncStmt(t *testing.T) {
 	prog := parse(t, `meow add(a int, b int) int {
   bring a + b
@@ -582,6 +600,26 @@ func TestParseLearnStmt(t *testing.T) {
 	}
 }
 
+func TestParseLearnStmtAsGoInterfaces(t *testing.T) {
+	prog := parse(t, `groom Logger as io.Writer, fmt.Stringer {
+    meow string() string {
+        bring "logger"
+    }
+}`)
+	learn := prog.Stmts[0].(*ast.LearnStmt)
+	if len(learn.As) != 2 {
+		t.Fatalf("expected 2 interfaces, got %d", len(learn.As))
+	}
+	for i, want := range []string{"io.Writer", "fmt.Stringer"} {
+		if got := learn.As[i].String(); got != want {
+			t.Errorf("As[%d] = %q, want %q", i, got, want)
+		}
+	}
+	if len(learn.Methods) != 1 {
+		t.Errorf("expected 1 method, got %d", len(learn.Methods))
+	}
+}
+
 func TestParseSelfExpr(t *testing.T) {
 	prog := parse(t, `groom Cat {
     meow show() string {
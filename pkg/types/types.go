@@ -58,6 +58,15 @@ type AnyType struct{}
 func (AnyType) String() string     { return "any" }
 func (AnyType) Equals(t Type) bool { _, ok := t.(AnyType); return ok }
 
+// GoSequenceType is what a Go call hands back that purr walks: a channel, an
+// iterator function, or a paginator. Nothing more is known of it than that, so
+// it is AnyType everywhere but a two-variable purr, which it can be the
+// subject of where an unknown value cannot.
+type GoSequenceType struct{}
+
+func (GoSequenceType) String() string     { return "go sequence" }
+func (GoSequenceType) Equals(t Type) bool { _, ok := t.(GoSequenceType); return ok }
+
 // ListType represents a list type with element type.
 type ListType struct{ Elem Type }
 
@@ -198,10 +207,14 @@ func (t TrickType) Equals(other Type) bool {
 	return ok && t.Name == o.Name
 }
 
-// IsAny reports whether t is AnyType.
+// IsAny reports whether t is AnyType, or GoSequenceType, which is known no
+// better.
 func IsAny(t Type) bool {
-	_, ok := t.(AnyType)
-	return ok
+	switch t.(type) {
+	case AnyType, GoSequenceType:
+		return true
+	}
+	return false
 }
 
 // IsNumeric reports whether t is IntType, ByteType, or FloatType.
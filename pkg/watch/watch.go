@@ -0,0 +1,144 @@
+// Package watch runs something again whenever the files it depends on change.
+//
+// It is what `meow run --watch` and `meow test --watch` are made of. Files
+// are polled rather than watched through the operating system: a handful of
+// .nyan files costs nothing to stat twice a second, and polling behaves the
+// same on every platform, over network mounts and through editors that save
+// by writing a new file and renaming it over the old one.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// Watcher runs a function, and runs it again each time the files it watches
+// change.
+type Watcher struct {
+	// Files lists the files to watch. It is asked again at every poll, so a
+	// file that appears — a new test beside a program — is watched from then
+	// on.
+	Files func() []string
+	// Read, when set, lists the files the runs have read, such as those a
+	// build compiled. They are watched as Files are, except that one first
+	// listed is taken as it is then rather than as a change: a run read it
+	// as it was, and a change to it is one made after.
+	Read func() []string
+	// Interval is how often the files are looked at.
+	Interval time.Duration
+	// Quiet is how long the files have to stay as they are before a change
+	// counts. An editor saving several files, or one file in several
+	// writes, is one change rather than a run per write.
+	Quiet time.Duration
+	// Out, when set, is cleared before every run.
+	Out io.Writer
+}
+
+// DefaultWatcher watches files at the pace a person saving them works at.
+func DefaultWatcher(files func() []string) *Watcher {
+	return &Watcher{Files: files, Interval: 200 * time.Millisecond, Quiet: 300 * time.Millisecond, Out: os.Stdout}
+}
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// Run calls run, and calls it again whenever the files change, until ctx is
+// done. A run still going when the files change has its context cancelled,
+// and is waited for, before the next one starts: a long program is stopped
+// rather than left running beside its replacement.
+func (w *Watcher) Run(ctx context.Context, run func(ctx context.Context)) error {
+	seen := w.snapshot(w.read())
+	for {
+		if w.Out != nil {
+			fmt.Fprint(w.Out, clearScreen)
+		}
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			run(runCtx)
+		}()
+
+		next, err := w.waitForChange(ctx, seen)
+		cancel()
+		<-done
+		if err != nil {
+			return err
+		}
+		seen = next
+	}
+}
+
+// waitForChange polls until the files differ from seen and have then stayed
+// the same for w.Quiet, and gives back how they look then.
+func (w *Watcher) waitForChange(ctx context.Context, seen map[string]stamp) (map[string]stamp, error) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case now := <-ticker.C:
+			read := w.read()
+			current := w.snapshot(read)
+			for _, f := range read {
+				if _, ok := seen[f]; !ok {
+					seen[f] = current[f]
+				}
+			}
+			if !same(current, seen) {
+				seen, changedAt = current, now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= w.Quiet {
+				return current, nil
+			}
+		}
+	}
+}
+
+// stamp is what a poll notices about a file. A file that is not there has the
+// zero stamp, so one appearing or going away is a change like any other.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// read is what Read lists, or nothing when it is not set.
+func (w *Watcher) read() []string {
+	if w.Read == nil {
+		return nil
+	}
+	return w.Read()
+}
+
+// snapshot stamps Files and read, the files the runs have read.
+func (w *Watcher) snapshot(read []string) map[string]stamp {
+	files := slices.Concat(w.Files(), read)
+	s := make(map[string]stamp, len(files))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			s[f] = stamp{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			s[f] = stamp{}
+		}
+	}
+	return s
+}
+
+func same(a, b map[string]stamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for f, st := range a {
+		if other, ok := b[f]; !ok || !other.modTime.Equal(st.modTime) || other.size != st.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package watch_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/pkg/watch"
+)
+
+// runs records each run of a watcher, and lets a test wait for the next.
+type runs struct {
+	mu        sync.Mutex
+	started   int
+	cancelled int
+	next      chan int
+}
+
+func (r *runs) run(block bool) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		r.mu.Lock()
+		r.started++
+		n := r.started
+		r.mu.Unlock()
+		r.next <- n
+		if block {
+			<-ctx.Done()
+			r.mu.Lock()
+			r.cancelled++
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *runs) await(t *testing.T, want int) {
+	t.Helper()
+	select {
+	case n := <-r.next:
+		if n != want {
+			t.Fatalf("run %d started, want run %d", n, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run %d never started", want)
+	}
+}
+
+func (r *runs) none(t *testing.T, within time.Duration) {
+	t.Helper()
+	select {
+	case n := <-r.next:
+		t.Fatalf("run %d started with nothing changed", n)
+	case <-time.After(within):
+	}
+}
+
+func touch(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func start(t *testing.T, files []string, run func(context.Context)) func() {
+	t.Helper()
+	w := &watch.Watcher{
+		Files:    func() []string { return files },
+		Interval: 5 * time.Millisecond,
+		Quiet:    50 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- w.Run(ctx, run) }()
+	return func() {
+		cancel()
+		if err := <-stopped; err != context.Canceled {
+			t.Errorf("Run = %v, want it cancelled", err)
+		}
+	}
+}
+
+func TestAChangeRunsAgain(t *testing.T) {
+	cat := filepath.Join(t.TempDir(), "cat.nyan")
+	touch(t, cat, "nya(1)\n")
+	r := &runs{next: make(chan int, 10)}
+	stop := start(t, []string{cat}, r.run(false))
+	defer stop()
+
+	r.await(t, 1)
+	r.none(t, 100*time.Millisecond)
+	touch(t, cat, "nya(22)\n")
+	r.await(t, 2)
+}
+
+func TestABurstOfSavesIsOneRun(t *testing.T) {
+	dir := t.TempDir()
+	cat, test := filepath.Join(dir, "cat.nyan"), filepath.Join(dir, "cat_test.nyan")
+	touch(t, cat, "nya(1)\n")
+	r := &runs{next: make(chan int, 10)}
+	stop := start(t, []string{cat, test}, r.run(false))
+	defer stop()
+
+	r.await(t, 1)
+	for i := range 5 {
+		touch(t, cat, "nya("+string(rune('a'+i))+"123)\n")
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The test file appearing is a change too.
+	touch(t, test, "meow test_cat() {}\n")
+	r.await(t, 2)
+	r.none(t, 150*time.Millisecond)
+}
+
+func TestARunStillGoingIsStopped(t *testing.T) {
+	cat := filepath.Join(t.TempDir(), "cat.nyan")
+	touch(t, cat, "nya(1)\n")
+	r := &runs{next: make(chan int, 10)}
+	stop := start(t, []string{cat}, r.run(true))
+
+	r.await(t, 1)
+	touch(t, cat, "nya(22)\n")
+	r.await(t, 2)
+	stop()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelled != 2 {
+		t.Errorf("%d runs were stopped, want both", r.cancelled)
+	}
+}
+
+func TestTheScreenIsClearedBeforeEachRun(t *testing.T) {
+	cat := filepath.Join(t.TempDir(), "cat.nyan")
+	touch(t, cat, "nya(1)\n")
+	var out syncBuffer
+	w := &watch.Watcher{
+		Files:    func() []string { return []string{cat} },
+		Interval: 5 * time.Millisecond,
+		Quiet:    20 * time.Millisecond,
+		Out:      &out,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 10)
+	go w.Run(ctx, func(context.Context) { ran <- struct{}{} })
+	<-ran
+	touch(t, cat, "nya(22)\n")
+	<-ran
+	cancel()
+	if got := bytes.Count(out.Bytes(), []byte("\033[2J")); got != 2 {
+		t.Errorf("the screen was cleared %d times, want 2", got)
+	}
+}
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) Bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.b.Bytes()...)
+}
+
+func TestAFileARunReadIsWatchedFromThen(t *testing.T) {
+	dir := t.TempDir()
+	cat, lock := filepath.Join(dir, "cat.nyan"), filepath.Join(dir, "meow.lock")
+	touch(t, cat, "nya(1)\n")
+	touch(t, lock, "go 1.26\n")
+	var mu sync.Mutex
+	var read []string
+	r := &runs{next: make(chan int, 10)}
+	w := &watch.Watcher{
+		Files: func() []string { return []string{cat} },
+		Read: func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return read
+		},
+		Interval: 5 * time.Millisecond,
+		Quiet:    50 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, func(ctx context.Context) {
+		// As a build does, the run reads the lock, and says so.
+		mu.Lock()
+		read = []string{lock}
+		mu.Unlock()
+		r.run(false)(ctx)
+	})
+
+	r.await(t, 1)
+	r.none(t, 150*time.Millisecond)
+	touch(t, lock, "go 1.27\n")
+	r.await(t, 2)
+}
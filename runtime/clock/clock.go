@@ -24,6 +24,16 @@ var now = time.Now
 // sleep is swapped out in tests so they need not actually wait.
 var sleep = time.Sleep
 
+// Swap has the clock read the time from nowFn and nap with sleepFn, until
+// restore is called to put back what they replaced. It is how testing.freeze
+// reaches the clock from a Meow test, where the Go tests here set now and
+// sleep themselves.
+func Swap(nowFn func() time.Time, sleepFn func(time.Duration)) (restore func()) {
+	oldNow, oldSleep := now, sleep
+	now, sleep = nowFn, sleepFn
+	return func() { now, sleep = oldNow, oldSleep }
+}
+
 // expectNoArgs reports a Furball when a no-argument function is given some.
 //
 // These functions are variadic so that a wrong argument count is reported as a
@@ -0,0 +1,167 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Branch is one way a decision can go: the body of a sniff or its scratch,
+// an arm of a peek, the fallback of a ~> or the value before it, and the
+// right side of && or || or the left side alone. A statement's block says a
+// sniff ran; its branches say which ways it went.
+type Branch struct {
+	FileName string
+	Line     int
+	Col      int
+	// Label names the branch in a report: "scratch", "peek arm", "~>
+	// fallback" and so on, at the position of the decision it is of.
+	Label string
+	Count int64
+}
+
+var branches []Branch
+
+// RegisterBranch adds a new branch and returns its ID.
+func RegisterBranch(fileName string, line, col int, label string) int {
+	id := len(branches)
+	branches = append(branches, Branch{FileName: fileName, Line: line, Col: col, Label: label})
+	return id
+}
+
+// Take records a taking of the branch with the given ID.
+func Take(id int) {
+	AddBranch(id, 1)
+}
+
+// AddBranch records n takings of the branch with the given ID at once, as
+// Add does runs of a block.
+func AddBranch(id int, n int64) {
+	if mode == "atomic" {
+		atomic.AddInt64(&branches[id].Count, n)
+		return
+	}
+	branches[id].Count += n
+}
+
+// Decide takes the branch yes when cond holds and no when it does not, and
+// gives back cond, for the decision to go on as it would have.
+func Decide(cond bool, yes, no int) bool {
+	if cond {
+		Take(yes)
+	} else {
+		Take(no)
+	}
+	return cond
+}
+
+// Branches returns the current branch list. Used for testing.
+func Branches() []Branch {
+	return branches
+}
+
+// BranchPercent is the percentage of bs that were taken, 100 of none.
+func BranchPercent(bs []Branch) float64 {
+	if len(bs) == 0 {
+		return 100
+	}
+	taken := 0
+	for _, b := range bs {
+		if b.Count > 0 {
+			taken++
+		}
+	}
+	return float64(taken) / float64(len(bs)) * 100
+}
+
+// reportBranches writes the share of the branches that were taken, and then
+// each that never was, where it is: those are the error paths and the
+// fallbacks no test has gone down.
+func reportBranches(w io.Writer) {
+	if len(branches) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "branches: %.1f%% of %d taken, nya~\n", BranchPercent(branches), len(branches))
+	for _, b := range branches {
+		if b.Count == 0 {
+			fmt.Fprintf(w, "  %s:%d:%d: %s never taken\n", b.FileName, b.Line, b.Col, b.Label)
+		}
+	}
+}
+
+// BranchesPath is where the branches of the profile at path are kept: a file
+// beside it rather than lines in it, as go tool cover takes a line of a
+// profile that is not a block for a broken one.
+func BranchesPath(path string) string {
+	return path + ".branches"
+}
+
+// writeBranches appends the branches to the file at path, one to a line, as
+// ReadBranches reads them. A run with no branches leaves the file be.
+//
+// The test binaries of meow test -p append to the one file at once, so each
+// line is written whole, in a write of its own, for no line of one to be cut
+// into by a line of another.
+func writeBranches(path string) error {
+	if len(branches) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return appendBranches(f)
+}
+
+// appendBranches writes the branches to w, each line in a Write of its own.
+func appendBranches(w io.Writer) error {
+	for _, b := range branches {
+		count := b.Count
+		if mode == "set" && count > 0 {
+			count = 1
+		}
+		if _, err := writeBranch(w, b, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBranch writes b as the one line ReadBranches reads it back from, its
+// label last for the spaces it has, in a single write to w.
+func writeBranch(w io.Writer, b Branch, count int64) (int, error) {
+	return fmt.Fprintf(w, "%s:%d.%d %d %s\n", b.FileName, b.Line, b.Col, count, b.Label)
+}
+
+// ReadBranches reads back the branches WriteProfile wrote beside a profile,
+// with Count the count it gave each.
+func ReadBranches(r io.Reader) ([]Branch, error) {
+	var bs []Branch
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		// No label has a colon, so the one before the position is the
+		// last, whatever the file name has.
+		colon := strings.LastIndex(text, ":")
+		fields := strings.SplitN(text[colon+1:], " ", 3)
+		if colon < 0 || len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: %q is not a branch", line, text)
+		}
+		b := Branch{FileName: text[:colon], Label: fields[2]}
+		if _, err := fmt.Sscanf(fields[0]+" "+fields[1], "%d.%d %d", &b.Line, &b.Col, &b.Count); err != nil {
+			return nil, fmt.Errorf("line %d: %q is not a branch: %w", line, text, err)
+		}
+		bs = append(bs, b)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
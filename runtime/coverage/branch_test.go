@@ -0,0 +1,150 @@
+package coverage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecideTakesOneBranchAndKeepsTheCondition(t *testing.T) {
+	Reset()
+	body := RegisterBranch("test.nyan", 2, 3, "sniff body")
+	scratch := RegisterBranch("test.nyan", 2, 3, "scratch")
+
+	if !Decide(true, body, scratch) || Decide(false, body, scratch) || !Decide(true, body, scratch) {
+		t.Error("Decide did not give back the condition it was given")
+	}
+	if bs := Branches(); bs[body].Count != 2 || bs[scratch].Count != 1 {
+		t.Errorf("counts = %d and %d, want 2 and 1", bs[body].Count, bs[scratch].Count)
+	}
+}
+
+func TestReportListsTheBranchesNeverTaken(t *testing.T) {
+	Reset()
+	Register("test.nyan", 1, 1, 1, 10, 1)
+	Hit(0)
+	RegisterBranch("test.nyan", 4, 9, "~> without hiss")
+	RegisterBranch("test.nyan", 4, 9, "~> fallback")
+	RegisterBranch("test.nyan", 6, 5, "peek arm")
+	RegisterBranch("test.nyan", 7, 5, "peek arm")
+	Take(0)
+	AddBranch(2, 3)
+
+	var buf bytes.Buffer
+	Report(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		"branches: 50.0% of 4 taken, nya~",
+		"  test.nyan:4:9: ~> fallback never taken\n",
+		"  test.nyan:7:5: peek arm never taken\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report has no %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "without hiss never") || strings.Contains(got, "6:5") {
+		t.Errorf("report lists a branch that was taken:\n%s", got)
+	}
+}
+
+func TestReportWithoutBranchesSaysNothingOfThem(t *testing.T) {
+	Reset()
+	Register("test.nyan", 1, 1, 1, 10, 1)
+	var buf bytes.Buffer
+	Report(&buf)
+	if strings.Contains(buf.String(), "branches") {
+		t.Errorf("report speaks of branches there are none of: %q", buf.String())
+	}
+}
+
+func TestWriteProfileKeepsTheBranchesBesideIt(t *testing.T) {
+	Reset()
+	SetMode("count")
+	Register("my file.nyan", 1, 1, 1, 10, 1)
+	RegisterBranch("my file.nyan", 2, 3, "sniff body")
+	RegisterBranch("my file.nyan", 2, 3, "scratch")
+	AddBranch(0, 3)
+
+	path := filepath.Join(t.TempDir(), "cover.out")
+	if err := WriteProfile(path); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(profile), "sniff") {
+		t.Errorf("profile has branches in it, which go tool cover cannot read:\n%s", profile)
+	}
+	f, err := os.Open(BranchesPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadBranches(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Branch{
+		{FileName: "my file.nyan", Line: 2, Col: 3, Label: "sniff body", Count: 3},
+		{FileName: "my file.nyan", Line: 2, Col: 3, Label: "scratch"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("read back %v, want %v", got, want)
+	}
+}
+
+func TestMergeAddsUpTheBranches(t *testing.T) {
+	a := &Profile{Mode: "count", Branches: []Branch{
+		{FileName: "b.nyan", Line: 4, Col: 9, Label: "~> fallback", Count: 1},
+		{FileName: "a.nyan", Line: 2, Col: 3, Label: "scratch"},
+	}}
+	b := &Profile{Mode: "count", Branches: []Branch{
+		{FileName: "a.nyan", Line: 2, Col: 3, Label: "scratch", Count: 2},
+		{FileName: "a.nyan", Line: 2, Col: 3, Label: "sniff body"},
+	}}
+	merged, err := Merge(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	merged.WriteBranchesTo(&out)
+	want := "a.nyan:2.3 2 scratch\na.nyan:2.3 0 sniff body\nb.nyan:4.9 1 ~> fallback\n"
+	if out.String() != want {
+		t.Errorf("merged branches:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// lineWriter fails a test given a Write that is not one whole line.
+type lineWriter struct {
+	t     *testing.T
+	lines int
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if strings.Count(string(p), "\n") != 1 || !strings.HasSuffix(string(p), "\n") {
+		w.t.Errorf("Write(%q) is not one whole line", p)
+	}
+	w.lines++
+	return len(p), nil
+}
+
+func TestEachBranchIsWrittenWholeInAWriteOfItsOwn(t *testing.T) {
+	Reset()
+	// Far more than a buffer of 4 KB holds, so that one flushed by its size
+	// would cut a line in two, for the line of another binary appending to
+	// the file to come between its halves.
+	for i := range 500 {
+		RegisterBranch(strings.Repeat("deep/", 10)+"calc.nyan", i+1, 3, "sniff body")
+	}
+	w := &lineWriter{t: t}
+	if err := appendBranches(w); err != nil {
+		t.Fatal(err)
+	}
+	if w.lines != 500 {
+		t.Errorf("wrote %d lines, want 500", w.lines)
+	}
+}
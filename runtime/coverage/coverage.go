@@ -1,9 +1,12 @@
 package coverage
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
 // Block represents a single instrumented statement.
@@ -14,11 +17,31 @@ type Block struct {
 	EndLine   int
 	EndCol    int
 	NumStmt   int
-	Count     int
+	Count     int64
 }
 
 var blocks []Block
 
+// mode is how runs of a block are counted; see SetMode.
+var mode = "set"
+
+// SetMode sets how runs of a block are counted, and so what a profile says
+// of them: "set" is whether a block ran at all, "count" how many times, and
+// "atomic" how many times as well, counted so that tests running beside each
+// other in the one process do not lose a count to each other. "" leaves it
+// as it is, "set" when nothing has set it.
+func SetMode(m string) {
+	if m != "" {
+		mode = m
+	}
+}
+
+// Mode is how runs of a block are counted, as the "mode:" line a profile
+// starts with gives it.
+func Mode() string {
+	return mode
+}
+
 // Register adds a new coverage block and returns its ID.
 func Register(fileName string, startLine, startCol, endLine, endCol, numStmt int) int {
 	id := len(blocks)
@@ -35,7 +58,18 @@ func Register(fileName string, startLine, startCol, endLine, endCol, numStmt int
 
 // Hit records an execution of the block with the given ID.
 func Hit(id int) {
-	blocks[id].Count++
+	Add(id, 1)
+}
+
+// Add records n executions of the block with the given ID at once: those a
+// test run in a process of its own had, handed back to be counted with the
+// rest.
+func Add(id int, n int64) {
+	if mode == "atomic" {
+		atomic.AddInt64(&blocks[id].Count, n)
+		return
+	}
+	blocks[id].Count += n
 }
 
 // Report writes a coverage summary to w.
@@ -43,23 +77,13 @@ func Report(w io.Writer) {
 	if len(blocks) == 0 {
 		return
 	}
-	total := 0
-	covered := 0
-	for _, b := range blocks {
-		total += b.NumStmt
-		if b.Count > 0 {
-			covered += b.NumStmt
-		}
-	}
-	pct := 0.0
-	if total > 0 {
-		pct = float64(covered) / float64(total) * 100
-	}
-	fmt.Fprintf(w, "coverage: %.1f%% of statements, nya~\n", pct)
+	fmt.Fprintf(w, "coverage: %.1f%% of statements, nya~\n", Percent(blocks))
+	reportBranches(w)
 }
 
 // WriteProfile writes block data in Go-compatible coverage profile format.
-// It appends to the file (the caller writes the "mode: set" header).
+// It appends to the file (the caller writes the "mode:" header, of Mode),
+// and the branches to the one at BranchesPath of it.
 func WriteProfile(path string) error {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
@@ -67,20 +91,66 @@ func WriteProfile(path string) error {
 	}
 	defer f.Close()
 	for _, b := range blocks {
-		count := 0
-		if b.Count > 0 {
+		count := b.Count
+		if mode == "set" && count > 0 {
 			count = 1
 		}
 		fmt.Fprintf(f, "%s:%d.%d,%d.%d %d %d\n",
 			b.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol,
 			b.NumStmt, count)
 	}
-	return nil
+	return writeBranches(BranchesPath(path))
+}
+
+// ParseProfile reads back the blocks of a profile WriteProfile wrote, with
+// Count the count it gave each. The "mode:" line a profile starts with, if it
+// has one, is passed over; ReadProfile is what gives it back.
+func ParseProfile(r io.Reader) ([]Block, error) {
+	p, err := ReadProfile(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.Blocks, nil
+}
+
+// ReadProfile reads a profile WriteProfile wrote, "mode:" line and all. A
+// profile with no such line is taken to be of mode "set".
+func ReadProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{Mode: "set"}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if m, ok := strings.CutPrefix(text, "mode:"); ok {
+			p.Mode = strings.TrimSpace(m)
+			continue
+		}
+		// The file name may hold a colon of its own; the one before the
+		// positions is the last.
+		colon := strings.LastIndex(text, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: %q is not a profile block", line, text)
+		}
+		b := Block{FileName: text[:colon]}
+		if _, err := fmt.Sscanf(text[colon+1:], "%d.%d,%d.%d %d %d",
+			&b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.NumStmt, &b.Count); err != nil {
+			return nil, fmt.Errorf("line %d: %q is not a profile block: %w", line, text, err)
+		}
+		p.Blocks = append(p.Blocks, b)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-// Reset clears all registered blocks. Used for testing.
+// Reset clears all registered blocks and branches. Used for testing.
 func Reset() {
 	blocks = nil
+	branches = nil
+	mode = "set"
 }
 
 // Blocks returns the current block list. Used for testing.
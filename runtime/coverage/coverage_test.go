@@ -118,3 +118,110 @@ func TestReset(t *testing.T) {
 		t.Errorf("expected 0 blocks after reset, got %d", len(Blocks()))
 	}
 }
+
+func TestParseProfileReadsWhatWriteProfileWrote(t *testing.T) {
+	Reset()
+	Register("cats.nyan", 1, 1, 1, 2, 1)
+	Register("dir:with:colons/cats.nyan", 2, 3, 4, 1, 1)
+	Hit(1)
+
+	path := filepath.Join(t.TempDir(), "cover.out")
+	if err := os.WriteFile(path, []byte("mode: set\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteProfile(path); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ParseProfile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Block{
+		{FileName: "cats.nyan", StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 2, NumStmt: 1},
+		{FileName: "dir:with:colons/cats.nyan", StartLine: 2, StartCol: 3, EndLine: 4, EndCol: 1, NumStmt: 1, Count: 1},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ParseProfile = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseProfile(strings.NewReader("cats.nyan 1 1\n")); err == nil {
+		t.Error("ParseProfile took a line with no positions")
+	}
+}
+
+func TestWriteProfileCountsUnderCountMode(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetMode("count")
+	Register("test.nyan", 1, 3, 1, 20, 1)
+	Hit(0)
+	Hit(0)
+	Add(0, 3)
+	path := filepath.Join(t.TempDir(), "coverage.out")
+	if err := WriteProfile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "test.nyan:1.3,1.20 1 5\n" {
+		t.Errorf("profile = %q, want the block run 5 times", got)
+	}
+}
+
+func TestReadProfileGivesTheMode(t *testing.T) {
+	p, err := ReadProfile(strings.NewReader("mode: atomic\ncats.nyan:1.1,1.2 1 7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mode != "atomic" || len(p.Blocks) != 1 || p.Blocks[0].Count != 7 {
+		t.Errorf("ReadProfile = %+v", p)
+	}
+	if p, _ := ReadProfile(strings.NewReader("cats.nyan:1.1,1.2 1 1\n")); p.Mode != "set" {
+		t.Errorf("a profile with no mode line is of mode %q, want set", p.Mode)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	read := func(s string) *Profile {
+		t.Helper()
+		p, err := ReadProfile(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	var b strings.Builder
+
+	counted, err := Merge(
+		read("mode: count\nb.nyan:1.1,1.2 1 2\na.nyan:3.1,3.2 1 0\n"),
+		read("mode: atomic\na.nyan:3.1,3.2 1 4\nb.nyan:1.1,1.2 1 1\nb.nyan:1.1,1.2 1 1\n"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counted.WriteTo(&b)
+	if want := "mode: count\na.nyan:3.1,3.2 1 4\nb.nyan:1.1,1.2 1 4\n"; b.String() != want {
+		t.Errorf("merged counts:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	set, err := Merge(read("mode: set\na.nyan:1.1,1.2 1 1\n"), read("mode: set\na.nyan:1.1,1.2 1 1\na.nyan:2.1,2.2 1 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.WriteTo(&b)
+	if want := "mode: set\na.nyan:1.1,1.2 1 1\na.nyan:2.1,2.2 1 0\n"; b.String() != want {
+		t.Errorf("merged set:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	if _, err := Merge(read("mode: set\n"), read("mode: count\n")); err == nil {
+		t.Error("Merge took a set profile and a count one together")
+	}
+}
@@ -0,0 +1,135 @@
+package coverage
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Profile is a coverage profile as a whole: the blocks it has, the branches
+// kept beside it, and how their runs were counted.
+type Profile struct {
+	Mode     string
+	Blocks   []Block
+	Branches []Branch
+}
+
+// Merge puts profiles together into one, of the runs of them all: a block
+// more than one of them has — or one has more than once, as a file tested by
+// more than one test file does — is counted once, with the runs it had in
+// each added up, or for a "set" profile with whether any of them ran it.
+// Branches merge the same way. The blocks are in order of file and of where
+// they start, and the branches of where they are.
+//
+// A "set" profile cannot be merged with a counting one, as it does not say
+// how many times anything ran; "count" and "atomic" profiles count the same
+// way, and merge as the first of them.
+func Merge(profiles ...*Profile) (*Profile, error) {
+	merged := &Profile{Mode: "set"}
+	if len(profiles) > 0 {
+		merged.Mode = profiles[0].Mode
+	}
+	type key struct {
+		file                                 string
+		startLine, startCol, endLine, endCol int
+		numStmt                              int
+	}
+	index := make(map[key]int)
+	type branchKey struct {
+		file      string
+		line, col int
+		label     string
+	}
+	branchIndex := make(map[branchKey]int)
+	for _, p := range profiles {
+		if (p.Mode == "set") != (merged.Mode == "set") {
+			return nil, fmt.Errorf("cannot merge a %q profile with a %q one", merged.Mode, p.Mode)
+		}
+		for _, b := range p.Blocks {
+			k := key{b.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt}
+			i, ok := index[k]
+			if !ok {
+				index[k] = len(merged.Blocks)
+				merged.Blocks = append(merged.Blocks, b)
+				continue
+			}
+			merged.Blocks[i].Count += b.Count
+		}
+		for _, b := range p.Branches {
+			k := branchKey{b.FileName, b.Line, b.Col, b.Label}
+			i, ok := branchIndex[k]
+			if !ok {
+				branchIndex[k] = len(merged.Branches)
+				merged.Branches = append(merged.Branches, b)
+				continue
+			}
+			merged.Branches[i].Count += b.Count
+		}
+	}
+	if merged.Mode == "set" {
+		for i := range merged.Blocks {
+			merged.Blocks[i].Count = min(merged.Blocks[i].Count, 1)
+		}
+		for i := range merged.Branches {
+			merged.Branches[i].Count = min(merged.Branches[i].Count, 1)
+		}
+	}
+	slices.SortStableFunc(merged.Blocks, func(a, b Block) int {
+		return cmp.Or(
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.StartLine, b.StartLine),
+			cmp.Compare(a.StartCol, b.StartCol),
+		)
+	})
+	slices.SortStableFunc(merged.Branches, func(a, b Branch) int {
+		return cmp.Or(
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Col, b.Col),
+		)
+	})
+	return merged, nil
+}
+
+// WriteTo writes p in the format ReadProfile reads, and go tool cover too.
+func (p *Profile) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	count := func(k int, _ error) { n += int64(k) }
+	count(fmt.Fprintf(bw, "mode: %s\n", p.Mode))
+	for _, b := range p.Blocks {
+		count(fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n",
+			b.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count))
+	}
+	return n, bw.Flush()
+}
+
+// WriteBranchesTo writes the branches of p in the format ReadBranches reads,
+// for the file at BranchesPath of where p is written.
+func (p *Profile) WriteBranchesTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, b := range p.Branches {
+		k, _ := writeBranch(bw, b, b.Count)
+		n += int64(k)
+	}
+	return n, bw.Flush()
+}
+
+// Percent is the percentage of the statements of blocks that ran, and 0
+// when there are none.
+func Percent(blocks []Block) float64 {
+	total, covered := 0, 0
+	for _, b := range blocks {
+		total += b.NumStmt
+		if b.Count > 0 {
+			covered += b.NumStmt
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total) * 100
+}
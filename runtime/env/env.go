@@ -19,6 +19,40 @@ func furball(format string, args ...any) meowrt.Value {
 	return &meowrt.Furball{Message: fmt.Sprintf("Hiss! "+format+", nya~", args...)}
 }
 
+// lookupEnv and environ are where the environment is read from; Overlay
+// swaps them out.
+var (
+	lookupEnv = os.LookupEnv
+	environ   = os.Environ
+)
+
+// Overlay has the named variable read as value, or as unset when set is
+// false, until restore is called. The process environment itself is left
+// alone: this is how testing.setenv changes what a Meow test sees for as long
+// as the test runs, and nothing else need notice.
+func Overlay(name, value string, set bool) (restore func()) {
+	oldLookup, oldEnviron := lookupEnv, environ
+	lookupEnv = func(n string) (string, bool) {
+		if n == name {
+			return value, set
+		}
+		return oldLookup(n)
+	}
+	environ = func() []string {
+		var entries []string
+		for _, e := range oldEnviron() {
+			if n, _, _ := strings.Cut(e, "="); n != name {
+				entries = append(entries, e)
+			}
+		}
+		if set {
+			entries = append(entries, name+"="+value)
+		}
+		return entries
+	}
+	return func() { lookupEnv, environ = oldLookup, oldEnviron }
+}
+
 // expectName extracts the variable name from a Value.
 func expectName(fn string, name meowrt.Value) (string, meowrt.Value) {
 	if f, ok := name.(*meowrt.Furball); ok {
@@ -52,7 +86,7 @@ func Hunt(args ...meowrt.Value) meowrt.Value {
 	if fb != nil {
 		return fb
 	}
-	if v, ok := os.LookupEnv(name); ok {
+	if v, ok := lookupEnv(name); ok {
 		return meowrt.NewString(v)
 	}
 	if len(args) == 2 {
@@ -75,7 +109,7 @@ func Sniffed(args ...meowrt.Value) meowrt.Value {
 	if fb != nil {
 		return fb
 	}
-	_, ok := os.LookupEnv(n)
+	_, ok := lookupEnv(n)
 	return meowrt.NewBool(ok)
 }
 
@@ -111,7 +145,7 @@ func Prowl(args ...meowrt.Value) meowrt.Value {
 	if len(args) != 0 {
 		return furball("prowl expects no arguments, got %d", len(args))
 	}
-	entries := os.Environ()
+	entries := environ()
 	names := make([]string, 0, len(entries))
 	for _, e := range entries {
 		name, _, ok := strings.Cut(e, "=")
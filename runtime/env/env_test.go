@@ -184,3 +184,24 @@ func withArgs(t *testing.T, args ...string) {
 	os.Args = args
 	t.Cleanup(func() { os.Args = original })
 }
+
+func TestOverlayIsSeenUntilRestored(t *testing.T) {
+	t.Setenv("MEOW_OVERLAID", "real")
+
+	restore := env.Overlay("MEOW_OVERLAID", "", false)
+	if got := env.Sniffed(meowrt.NewString("MEOW_OVERLAID")); got.String() != "false" {
+		t.Errorf("an overlaid unset variable is still seen, got %q", got.String())
+	}
+	if got := os.Getenv("MEOW_OVERLAID"); got != "real" {
+		t.Errorf("the process environment was changed to %q", got)
+	}
+	inner := env.Overlay("MEOW_OVERLAID", "fake", true)
+	if got := env.Hunt(meowrt.NewString("MEOW_OVERLAID")); got.String() != "fake" {
+		t.Errorf("got %q, want the overlay on top", got.String())
+	}
+	inner()
+	restore()
+	if got := env.Hunt(meowrt.NewString("MEOW_OVERLAID")); got.String() != "real" {
+		t.Errorf("got %q after restoring, want %q", got.String(), "real")
+	}
+}
@@ -0,0 +1,65 @@
+package meowrt
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A kitty groomed as a Go interface is handed to Go as an adapter: a Go type
+// the compiler wrote for it, whose methods are the interface's and whose
+// bodies call the kitty's own through DispatchMethod. Go cannot make a type
+// with methods at run time, so the adapter has to be generated; what is left
+// to the runtime is knowing which adapters a kitty has, and doing the reading
+// in and out that every one of their methods needs.
+
+var (
+	adapterRegistry   = map[string][]func(Value) any{}
+	adapterRegistryMu sync.RWMutex
+)
+
+// RegisterAdapter records that a kitty of the named type can be handed to Go
+// as whatever wrap makes of it. A type groomed as several interfaces has an
+// adapter for each, and the one used is the first that satisfies what the Go
+// side asked for.
+func RegisterAdapter(typeName string, wrap func(Value) any) {
+	adapterRegistryMu.Lock()
+	defer adapterRegistryMu.Unlock()
+	adapterRegistry[typeName] = append(adapterRegistry[typeName], wrap)
+}
+
+// ClearAdapters removes all registered adapters, as ClearMethods does methods.
+func ClearAdapters() {
+	adapterRegistryMu.Lock()
+	defer adapterRegistryMu.Unlock()
+	adapterRegistry = map[string][]func(Value) any{}
+}
+
+// adapt finds an adapter of v that satisfies the interface t.
+func adapt(v Value, t reflect.Type) (reflect.Value, bool) {
+	k, ok := v.(*Kitty)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	adapterRegistryMu.RLock()
+	wraps := adapterRegistry[k.TypeName]
+	adapterRegistryMu.RUnlock()
+	for _, wrap := range wraps {
+		a := reflect.ValueOf(wrap(v))
+		if a.Type().Implements(t) {
+			return a, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Adapt gives the kitty's method as a Go function of type F, which is what
+// a generated adapter method calls. The arguments Go passes are read in and
+// the answer read back out the way they are for a lambda passed as a
+// callback: a Furball is the method's error when F returns one, and a panic
+// when it does not.
+func Adapt[F any](self Value, method string) F {
+	fn := NewFuncWithArity(method, -1, func(args ...Value) Value {
+		return DispatchMethod(self, method, args...)
+	})
+	return goFunc(fn, reflect.TypeFor[F]()).Interface().(F)
+}
@@ -0,0 +1,88 @@
+package meowrt_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// The adapters below are what the compiler writes for `groom Shout as
+// io.Writer, fmt.Stringer`, by hand.
+
+type shoutWriter struct{ self meowrt.Value }
+
+func (a shoutWriter) Write(p0 []byte) (int, error) {
+	return meowrt.Adapt[func([]byte) (int, error)](a.self, "write")(p0)
+}
+
+type shoutStringer struct{ self meowrt.Value }
+
+func (a shoutStringer) String() string {
+	return meowrt.Adapt[func() string](a.self, "string")()
+}
+
+func groomShout(t *testing.T) *strings.Builder {
+	t.Helper()
+	var out strings.Builder
+	meowrt.RegisterMethod("Shout", "write", func(args ...meowrt.Value) meowrt.Value {
+		p := args[1].(*meowrt.List)
+		if len(p.Items) == 0 {
+			return meowrt.NewFurball("Hiss! nothing to shout, nya~")
+		}
+		for _, b := range p.Items {
+			out.WriteString(strings.ToUpper(string(rune(b.(*meowrt.Byte).Val))))
+		}
+		return meowrt.NewInt(int64(len(p.Items)))
+	})
+	meowrt.RegisterMethod("Shout", "string", func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewString("a shout")
+	})
+	meowrt.RegisterAdapter("Shout", func(v meowrt.Value) any { return shoutWriter{v} })
+	meowrt.RegisterAdapter("Shout", func(v meowrt.Value) any { return shoutStringer{v} })
+	t.Cleanup(func() {
+		meowrt.ClearMethods()
+		meowrt.ClearAdapters()
+	})
+	return &out
+}
+
+func TestAGroomedKittyIsHandedToGoAsItsInterface(t *testing.T) {
+	out := groomShout(t)
+	shout := meowrt.NewKitty("Shout", nil)
+
+	got := meowrt.CallGo("io.WriteString", io.WriteString, shout, meowrt.NewString("nya"))
+	if got.String() != "3" || out.String() != "NYA" {
+		t.Errorf("WriteString = %s, wrote %q; want 3 and NYA", got, out.String())
+	}
+	// The adapter chosen is the one that satisfies what was asked for.
+	got = meowrt.CallGo("describe", func(s fmt.Stringer) string { return s.String() }, shout)
+	if got.String() != "a shout" {
+		t.Errorf("String = %s, want a shout", got)
+	}
+}
+
+func TestAFurballFromAnAdaptedMethodIsItsError(t *testing.T) {
+	groomShout(t)
+	shout := meowrt.NewKitty("Shout", nil)
+
+	got := meowrt.CallGo("io.WriteString", io.WriteString, shout, meowrt.NewString(""))
+	f, failed := meowrt.AsFurball(got)
+	if !failed || !strings.Contains(f.Message, "nothing to shout") {
+		t.Errorf("WriteString(\"\") = %s, want the method's Furball", got)
+	}
+}
+
+func TestAKittyNotGroomedAsTheInterfaceIsRefused(t *testing.T) {
+	groomShout(t)
+	plain := meowrt.NewKitty("Plain", nil)
+	if got := meowrt.CallGo("io.WriteString", io.WriteString, plain, meowrt.NewString("nya")); !isFurball(got) {
+		t.Errorf("WriteString to a plain kitty = %s, want a Furball", got)
+	}
+	shout := meowrt.NewKitty("Shout", nil)
+	if got := meowrt.CallGo("close", func(c io.Closer) error { return c.Close() }, shout); !isFurball(got) {
+		t.Errorf("Close on a Shout = %s, want a Furball", got)
+	}
+}
@@ -2,7 +2,9 @@ package meowrt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"time"
@@ -176,6 +178,23 @@ func goMethodName(name string) string {
 // come across as.
 func FromGo(v any) Value { return fromGo(reflect.ValueOf(v)) }
 
+// ToGo reads a Meow value into the Go value target points at, the way a call
+// reads its arguments. It is how a Go program embedding Meow reads an answer
+// back: a basket fills a record by field name, a litter a slice, and anything
+// held from Go goes back as what it was.
+func ToGo(v Value, target any) error {
+	rv := reflect.ValueOf(target)
+	if !rv.IsValid() || rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot read a %s into %T, which is not somewhere to put it", v.Type(), target)
+	}
+	gv, err := toGo(v, rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(gv)
+	return nil
+}
+
 func callReflected(ctx context.Context, what string, fn reflect.Value, args []Value) Value {
 	t := fn.Type()
 
@@ -197,6 +216,7 @@ func callReflected(ctx context.Context, what string, fn reflect.Value, args []Va
 		return NewFurball("Hiss! %s wants %d arguments, got %d, nya~", what, wanted, len(args))
 	}
 
+	call := &goCall{}
 	for i, arg := range args {
 		pos := next + i
 		var pt reflect.Type
@@ -210,10 +230,16 @@ func callReflected(ctx context.Context, what string, fn reflect.Value, args []Va
 		if err != nil {
 			return NewFurball("Hiss! %s argument %d: %s, nya~", what, i+1, err)
 		}
+		if _, ok := arg.(*Func); ok && gv.Kind() == reflect.Func {
+			gv = call.callback(gv)
+		}
+		call.watch(arg, gv)
 		in = append(in, gv)
 	}
 
-	return callAndRecover(what, fn, in)
+	v := callAndRecover(what, fn, in)
+	call.sync()
+	return v
 }
 
 // callAndRecover makes the call, turning a panic into a Furball.
@@ -225,6 +251,13 @@ func callReflected(ctx context.Context, what string, fn reflect.Value, args []Va
 func callAndRecover(what string, fn reflect.Value, in []reflect.Value) (v Value) {
 	defer func() {
 		if r := recover(); r != nil {
+			// A lambda handed in as a callback that failed, where the
+			// callback had no error to fail with, is that failure rather
+			// than the library coming apart.
+			if f, ok := r.(*Furball); ok {
+				v = f
+				return
+			}
 			v = NewFurball("Hiss! %s came apart: %v, nya~", what, r)
 		}
 	}()
@@ -388,6 +421,12 @@ func readGo(rv reflect.Value) Value {
 			return NewString(rv.Interface().(time.Time).Format(time.RFC3339))
 		}
 		return structToMap(rv)
+	case reflect.Chan, reflect.Func:
+		// Held, to be called or walked with purr; a nil one is neither, and
+		// holding it would only put off saying so.
+		if rv.IsNil() {
+			return NewNil()
+		}
 	}
 	return NewOpaque(rv.Type().String(), rv.Interface())
 }
@@ -458,7 +497,7 @@ func toGo(v Value, t reflect.Type) (reflect.Value, error) {
 	}
 	if _, isNil := v.(*NilValue); isNil {
 		switch t.Kind() {
-		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
 			return reflect.Zero(t), nil
 		}
 		return reflect.Value{}, fmt.Errorf("cannot read nothing as a %s", t)
@@ -547,10 +586,14 @@ func toGo(v Value, t reflect.Type) (reflect.Value, error) {
 		// for one — Sprintf, Marshal. What goes in is the plain Go value
 		// behind the Meow one.
 		//
-		// An interface with methods is another matter: only something held
-		// from Go can satisfy one, which is settled above by whether it is
-		// assignable.
+		// An interface with methods is another matter: something held from
+		// Go satisfies one, which is settled above by whether it is
+		// assignable, and so does a kitty groomed as it, through the adapter
+		// the compiler wrote.
 		if t.NumMethod() > 0 {
+			if a, ok := adapt(v, t); ok {
+				return a, nil
+			}
 			return reflect.Value{}, fmt.Errorf("cannot read a %s as a %s", v.Type(), t)
 		}
 		plain, err := toAny(v)
@@ -561,6 +604,15 @@ func toGo(v Value, t reflect.Type) (reflect.Value, error) {
 			return reflect.Zero(t), nil
 		}
 		return reflect.ValueOf(plain), nil
+	case reflect.Func:
+		fn, ok := v.(*Func)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("cannot read a %s as a %s", v.Type(), t)
+		}
+		if fn.Arity >= 0 && fn.Arity != t.NumIn() {
+			return reflect.Value{}, fmt.Errorf("cannot read %s, which takes %d arguments, as a %s", fn, fn.Arity, t)
+		}
+		return goFunc(fn, t), nil
 	case reflect.Struct:
 		// A time went out as the text of it, so that is what comes back in.
 		if t == timeType {
@@ -579,6 +631,103 @@ func toGo(v Value, t reflect.Type) (reflect.Value, error) {
 	return reflect.Value{}, fmt.Errorf("cannot read a %s as a %s", v.Type(), t)
 }
 
+// goFunc makes a Meow function into a Go one of type t, which is what lets a
+// lambda go where a Go call asks for a callback: sort.Slice's less, the
+// mapping strings.Map applies, an http.HandlerFunc.
+//
+// What the Go side passes in is read the way any Go value is, and what the
+// lambda answers is read back as the types t returns. A Furball it answers
+// with becomes the callback's error when t has one to return. When it has none
+// there is nowhere to put the failure but a panic, which callAndRecover turns
+// back into the same Furball once it reaches the Go call the lambda was handed
+// to.
+//
+// That is only so when the callback is called on the goroutine of that call.
+// One Go calls on a goroutine of its own — a handler net/http serves a
+// request with — panics where no callAndRecover is waiting: net/http recovers
+// it itself, logs it and drops the connection, and anything else ends the
+// program. A handler, which has a response to fail with, answers a Furball
+// with a 500 instead; any other such callback is left to panic.
+func goFunc(fn *Func, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]Value, len(in))
+		for i, a := range in {
+			args[i] = fromGo(a)
+		}
+		result := Call(fn, args...)
+		if f, failed := AsFurball(result); failed {
+			if w, ok := responseWriterIn(in); ok && t.NumOut() == 0 {
+				w.WriteHeader(500)
+				io.WriteString(w, f.Message+"\n")
+				return nil
+			}
+		}
+		return goResults(fn, result, t)
+	})
+}
+
+// responseWriter is what a handler answers a request through.
+type responseWriter interface {
+	WriteHeader(statusCode int)
+	Write([]byte) (int, error)
+}
+
+// responseWriterIn finds what a callback was handed to answer a request
+// through, if it was handed one, which is what makes it a handler.
+func responseWriterIn(in []reflect.Value) (responseWriter, bool) {
+	for _, a := range in {
+		if a.Kind() == reflect.Interface && !a.IsNil() && a.CanInterface() {
+			if w, ok := a.Interface().(responseWriter); ok {
+				return w, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// goResults reads what a lambda answered as the results of the Go function
+// type t.
+func goResults(fn *Func, result Value, t reflect.Type) []reflect.Value {
+	n := t.NumOut()
+	out := make([]reflect.Value, n)
+	for i := range n {
+		out[i] = reflect.New(t.Out(i)).Elem()
+	}
+	hasErr := n > 0 && t.Out(n-1) == errorType
+	if f, failed := AsFurball(result); failed {
+		if !hasErr {
+			panic(f)
+		}
+		out[n-1].Set(reflect.ValueOf(errors.New(f.Message)))
+		return out
+	}
+	if hasErr {
+		n--
+	}
+
+	var answers []Value
+	switch n {
+	case 0:
+		return out
+	case 1:
+		answers = []Value{result}
+	default:
+		l, ok := result.(*List)
+		if !ok || len(l.Items) != n {
+			panic(NewFurball("Hiss! %s must answer with a litter of %d values for a %s, nya~", fn.Name, n, t))
+		}
+		answers = l.Items
+	}
+	for i, a := range answers {
+		gv, err := toGo(a, t.Out(i))
+		if err != nil {
+			panic(NewFurball("Hiss! %s answered with something a %s cannot return: %s, nya~", fn.Name, t, err))
+		}
+		out[i].Set(gv)
+	}
+	return out
+}
+
 // toAny reads a Meow value as the plain Go value behind it, which is what a
 // call taking an empty interface is asking for.
 //
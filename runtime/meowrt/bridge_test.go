@@ -6,6 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -968,3 +972,197 @@ func TestReadingAMemberOfAFailure(t *testing.T) {
 		t.Errorf("got %s, want the failure itself", got.String())
 	}
 }
+
+func TestToGoReadsIntoWhatItIsPointedAt(t *testing.T) {
+	type quote struct {
+		Item  string
+		Total int
+	}
+	var q quote
+	m := meowrt.NewMap(map[string]meowrt.Value{
+		"item":  meowrt.NewString("tuna"),
+		"total": meowrt.NewInt(300),
+	})
+	if err := meowrt.ToGo(m, &q); err != nil {
+		t.Fatalf("ToGo: %v", err)
+	}
+	if q != (quote{Item: "tuna", Total: 300}) {
+		t.Errorf("got %+v", q)
+	}
+
+	var n int
+	if err := meowrt.ToGo(meowrt.NewInt(1), n); err == nil || !strings.Contains(err.Error(), "int") {
+		t.Errorf("ToGo into a non-pointer = %v, want it refused", err)
+	}
+	if err := meowrt.ToGo(meowrt.NewString("x"), &n); err == nil {
+		t.Error("ToGo of text into an int should fail")
+	}
+}
+
+func TestALambdaGoesWhereACallbackIsAskedFor(t *testing.T) {
+	held := []int{3, 1, 2}
+	less := meowrt.NewFuncWithArity("less", 2, func(args ...meowrt.Value) meowrt.Value {
+		i, j := meowrt.AsInt(args[0]), meowrt.AsInt(args[1])
+		return meowrt.NewBool(held[i] < held[j])
+	})
+	if got := meowrt.CallGo("sort.Slice", sort.Slice, meowrt.NewOpaque("slice", held), less); isFurball(got) {
+		t.Fatalf("sort.Slice failed: %s", got)
+	}
+	if fmt.Sprint(held) != "[1 2 3]" {
+		t.Errorf("held = %v, want it sorted", held)
+	}
+
+	upper := meowrt.NewFuncWithArity("upper", 1, func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewInt(meowrt.AsInt(args[0]) - 'a' + 'A')
+	})
+	if got := meowrt.CallGo("strings.Map", strings.Map, upper, meowrt.NewString("nyan")); got.String() != "NYAN" {
+		t.Errorf("strings.Map = %s, want NYAN", got)
+	}
+}
+
+func TestAFailingLambdaIsTheCallbacksError(t *testing.T) {
+	fails := meowrt.NewFuncWithArity("check", 1, func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewFurball("Hiss! %s is not allowed, nya~", args[0])
+	})
+	walk := func(names []string, visit func(string) error) error {
+		for _, n := range names {
+			if err := visit(n); err != nil {
+				return fmt.Errorf("visiting %s: %w", n, err)
+			}
+		}
+		return nil
+	}
+	got := meowrt.CallGo("walk", walk, meowrt.NewList(meowrt.NewString("tama")), fails)
+	if !isFurball(got) || !strings.Contains(got.String(), "tama is not allowed") {
+		t.Errorf("got %s, want the lambda's failure as the walk's error", got)
+	}
+
+	// With no error to return, the failure is the call's own.
+	each := func(names []string, visit func(string)) {
+		for _, n := range names {
+			visit(n)
+		}
+	}
+	got = meowrt.CallGo("each", each, meowrt.NewList(meowrt.NewString("tama")), fails)
+	if !isFurball(got) || got.String() != "Hiss! tama is not allowed, nya~" {
+		t.Errorf("got %s, want the lambda's own failure", got)
+	}
+}
+
+func TestALambdaCanAnswerWithSeveralValues(t *testing.T) {
+	split := meowrt.NewFuncWithArity("split", 1, func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewList(meowrt.NewString(args[0].String()), meowrt.NewInt(int64(len(args[0].String()))))
+	})
+	use := func(f func(string) (string, int)) string {
+		s, n := f("nyan")
+		return fmt.Sprintf("%s/%d", s, n)
+	}
+	if got := meowrt.CallGo("use", use, split); got.String() != "nyan/4" {
+		t.Errorf("got %s, want nyan/4", got)
+	}
+}
+
+func TestALambdaMustTakeWhatTheCallbackIsGiven(t *testing.T) {
+	one := meowrt.NewFuncWithArity("one", 1, func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewBool(true)
+	})
+	got := meowrt.CallGo("sort.Slice", sort.Slice, meowrt.NewOpaque("slice", []int{2, 1}), one)
+	if !isFurball(got) || !strings.Contains(got.String(), "takes 1 arguments") {
+		t.Errorf("got %s, want the arity refused", got)
+	}
+	if got := meowrt.CallGo("strings.Map", strings.Map, meowrt.NewString("x"), meowrt.NewString("y")); !isFurball(got) {
+		t.Errorf("got %s, want text refused as a callback", got)
+	}
+}
+
+func isFurball(v meowrt.Value) bool {
+	_, failed := meowrt.AsFurball(v)
+	return failed
+}
+
+func TestSortSliceSortsTheLitterItWasHanded(t *testing.T) {
+	cat := func(name string, age int64) meowrt.Value {
+		return meowrt.NewMap(map[string]meowrt.Value{"name": meowrt.NewString(name), "age": meowrt.NewInt(age)})
+	}
+	cats := meowrt.NewList(cat("tama", 3), cat("mike", 1), cat("kuro", 2))
+	before := append([]meowrt.Value(nil), cats.Items...)
+	// The less reads the litter, as a lambda would, and has to see it in the
+	// order sort.Slice has it in so far.
+	less := meowrt.NewFuncWithArity("less", 2, func(args ...meowrt.Value) meowrt.Value {
+		i, j := meowrt.AsInt(args[0]), meowrt.AsInt(args[1])
+		age := func(k int64) int64 {
+			v, _ := cats.Items[k].(*meowrt.Map).Get("age")
+			return meowrt.AsInt(v)
+		}
+		return meowrt.NewBool(age(i) < age(j))
+	})
+	if got := meowrt.CallGo("sort.Slice", sort.Slice, cats, less); isFurball(got) {
+		t.Fatalf("sort.Slice failed: %s", got)
+	}
+	if cats.Items[0] != before[1] || cats.Items[1] != before[2] || cats.Items[2] != before[0] {
+		t.Errorf("sorted %s, want the same baskets by age", cats)
+	}
+
+	nums := meowrt.NewList(meowrt.NewInt(3), meowrt.NewInt(1), meowrt.NewInt(2))
+	meowrt.CallGo("sort.Ints", sort.Ints, nums)
+	if nums.String() != "[1, 2, 3]" {
+		t.Errorf("sort.Ints left %s, want [1, 2, 3]", nums)
+	}
+}
+
+// net/http calls a handler on a goroutine of its own, where a panic would
+// never reach the call the lambda was handed to.
+func TestAFailingHandlerAnswersWithA500(t *testing.T) {
+	handler := meowrt.NewFuncWithArity("handler", 2, func(args ...meowrt.Value) meowrt.Value {
+		return meowrt.NewFurball("Hiss! No tuna left, nya~")
+	})
+	serve := func(h http.HandlerFunc) (int, string, error) {
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+	got := meowrt.CallGo("serve", serve, handler)
+	if got.String() != "[500, Hiss! No tuna left, nya~\n]" {
+		t.Errorf("got %s, want a 500 with the lambda's failure", got)
+	}
+}
+
+// A callback the Go side makes later, on a goroutine of its own, as
+// time.AfterFunc does, runs while the program that made the call goes on
+// reading the litter. It must not bring the litter up to date from there, or
+// it would write the litter as the program reads it: the litter is only read
+// back on the goroutine the call was made on. Run with -race.
+func TestACallbackOnAnotherGoroutineLeavesTheLitterBe(t *testing.T) {
+	nums := meowrt.NewList(meowrt.NewInt(3), meowrt.NewInt(1), meowrt.NewInt(2))
+	start, done := make(chan struct{}), make(chan struct{})
+	later := func(xs []int64, f func(int64) int64) {
+		go func() {
+			defer close(done)
+			<-start
+			slices.Sort(xs)
+			f(xs[0])
+		}()
+	}
+	each := meowrt.NewFuncWithArity("each", 1, func(args ...meowrt.Value) meowrt.Value {
+		return args[0]
+	})
+	if got := meowrt.CallGo("later", later, nums, each); isFurball(got) {
+		t.Fatalf("later failed: %s", got)
+	}
+	close(start)
+	for range 100 {
+		if got := nums.String(); got != "[3, 1, 2]" {
+			t.Fatalf("the litter became %s under the program reading it", got)
+		}
+	}
+	<-done
+	if got := nums.String(); got != "[3, 1, 2]" {
+		t.Errorf("a callback on a goroutine of its own left the litter %s, want [3, 1, 2]", got)
+	}
+}
@@ -0,0 +1,149 @@
+package meowrt
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// A litter goes to Go as a slice made for the call, and a Go call may do more
+// with a slice than read it: sort.Slice reorders it, and something filling a
+// buffer writes to it. Left at that, the call would work on a copy the
+// program never sees again, and sort.Slice would do nothing at all.
+//
+// So a litter handed to a call as a slice is kept in step with it: read back
+// out of the slice when the call returns, and also before each callback the
+// call makes, because the callback is Meow code reading the litter — the less
+// of sort.Slice compares xs[i] and xs[j] — and has to see it in the order the
+// call has it in by then. An element is read back as the Meow value it was
+// made from wherever the call only moved it, so a basket sorted is still the
+// basket, remembering whatever it was read out of.
+//
+// The litter is brought up to date in place, as a Go slice's elements are, so
+// every name it is bound to sees what the call did to it. That is only ever
+// done on the goroutine the call was made on, which nothing else reads the
+// litter from while the call runs. A callback the call makes on a goroutine
+// of its own, as a server or a worker pool does, does not bring it up to
+// date: two of them at once would write the litter together, and a lambda
+// reading it would read it as it was being written. Such a callback sees the
+// litter as it was when the call was made, until the call returns.
+
+// goCall is the litters of one Go call that went to it as slices.
+type goCall struct {
+	lists []goList
+	// caller is the goroutine the call was made on, whose callbacks are the
+	// only ones to bring the litters up to date.
+	caller uint64
+}
+
+// goList is a litter, the slice it went to the call as, and the values it
+// held by what they went as.
+type goList struct {
+	list  *List
+	slice reflect.Value
+	from  map[any][]Value
+}
+
+// watch keeps arg in step with gv, what it went to the call as, when arg is
+// a litter that went as a slice with elements that can be told apart.
+func (c *goCall) watch(arg Value, gv reflect.Value) {
+	l, ok := arg.(*List)
+	if !ok {
+		return
+	}
+	if gv.Kind() == reflect.Interface {
+		gv = gv.Elem()
+	}
+	if gv.Kind() != reflect.Slice || gv.Len() != len(l.Items) {
+		return
+	}
+	from := make(map[any][]Value, len(l.Items))
+	for i, item := range l.Items {
+		key, ok := elementKey(gv.Index(i))
+		if !ok {
+			return
+		}
+		from[key] = append(from[key], item)
+	}
+	c.lists = append(c.lists, goList{list: l, slice: gv, from: from})
+}
+
+// callback is cb, a callback made of a lambda, made to bring the litters of
+// the call up to date before the lambda reads them, when it is called on the
+// goroutine the call was made on.
+func (c *goCall) callback(cb reflect.Value) reflect.Value {
+	c.caller = goroutineID()
+	return reflect.MakeFunc(cb.Type(), func(in []reflect.Value) []reflect.Value {
+		if len(c.lists) > 0 && goroutineID() == c.caller {
+			c.sync()
+		}
+		return cb.Call(in)
+	})
+}
+
+// goroutineID is the number of the goroutine it is called on, as the first
+// line of a trace of it gives it: "goroutine 7 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	trace := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(trace, ' '); i >= 0 {
+		trace = trace[:i]
+	}
+	id, _ := strconv.ParseUint(string(trace), 10, 64)
+	return id
+}
+
+// sync reads each litter back out of the slice it went to the call as. It is
+// only called on the goroutine the call was made on.
+func (c *goCall) sync() {
+	for _, g := range c.lists {
+		used := make(map[any]int, len(g.from))
+		items := make([]Value, g.slice.Len())
+		moved := false
+		for i := range items {
+			elem := g.slice.Index(i)
+			key, ok := elementKey(elem)
+			if vs := g.from[key]; ok && used[key] < len(vs) {
+				items[i] = vs[used[key]]
+				used[key]++
+			} else {
+				// Written by the call rather than moved there.
+				items[i] = fromGo(elem)
+			}
+			moved = moved || items[i] != g.list.Items[i]
+		}
+		if moved {
+			g.list.Items, g.list.tail = items, nil
+		}
+	}
+}
+
+// elementKey is what an element of a slice is told apart from the others by:
+// itself, when it is a value, and where it points when it is a map, a slice
+// or a pointer, which are what a basket, a litter or a handle go as. Two equal
+// values are the same Meow value as well, so it does not matter which of them
+// is read back where.
+func elementKey(rv reflect.Value) (any, bool) {
+	if rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, true
+		}
+		rv = rv.Elem()
+	}
+	type ref struct {
+		t   reflect.Type
+		ptr uintptr
+		len int
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return ref{t: rv.Type(), ptr: rv.Pointer()}, true
+	case reflect.Slice:
+		return ref{t: rv.Type(), ptr: rv.Pointer(), len: rv.Len()}, true
+	}
+	if !rv.Comparable() {
+		return nil, false
+	}
+	return rv.Interface(), true
+}
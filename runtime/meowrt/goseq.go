@@ -0,0 +1,150 @@
+package meowrt
+
+import (
+	"context"
+	"reflect"
+)
+
+// Walking what Go hands back one at a time.
+//
+// Go has several ways of saying "here are some things, in turn": a channel, an
+// iterator function — iter.Seq and iter.Seq2 — and, in the SDKs generated from
+// service descriptions, a paginator that is asked HasMorePages and then
+// NextPage until it runs out. Held as an Opaque, none of these could be walked;
+// a program wanting the pages of an API had to follow its tokens by hand, in a
+// recursion, because there was nothing for purr to take. Each is walked here,
+// the way Go's own range walks the first two, so what a library offers as a
+// sequence is one in Meow too.
+//
+// The one-variable purr binds what Go's one-variable range binds — a
+// channel's element, an iter.Seq's value, an iter.Seq2's key — and a page for
+// a paginator. The two-variable purr binds a count and that, as for a litter,
+// except over an iter.Seq2, whose key and value are already a pair.
+
+// goWalk walks a held Go value that is a sequence, handing each step to yield
+// as a key and a value. keyed reports whether the key is the sequence's own
+// rather than a count. ok is false when v is not a sequence at all.
+func goWalk(v Value) (walk func(yield func(k, v Value) bool), keyed, ok bool) {
+	o, held := AsOpaque(v)
+	if !held || o.V == nil {
+		return nil, false, false
+	}
+	rv := reflect.ValueOf(o.V)
+	switch rv.Kind() {
+	case reflect.Chan:
+		if rv.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, false, false
+		}
+		return counted(func(yield func(Value) bool) {
+			for {
+				x, open := rv.Recv()
+				if !open || !yield(fromGo(x)) {
+					return
+				}
+			}
+		}), false, true
+	case reflect.Func:
+		n, isSeq := seqArity(rv.Type())
+		if !isSeq {
+			break
+		}
+		if n == 1 {
+			return counted(func(yield func(Value) bool) {
+				rv.Call([]reflect.Value{yieldFunc(rv.Type().In(0), func(in []reflect.Value) bool {
+					return yield(fromGo(in[0]))
+				})})
+			}), false, true
+		}
+		return func(yield func(k, v Value) bool) {
+			rv.Call([]reflect.Value{yieldFunc(rv.Type().In(0), func(in []reflect.Value) bool {
+				return yield(fromGo(in[0]), fromGo(in[1]))
+			})})
+		}, true, true
+	}
+	if pages, isPaginator := paginator(rv); isPaginator {
+		return counted(pages), false, true
+	}
+	return nil, false, false
+}
+
+// IsGoSequence reports whether v is something held from Go that purr walks:
+// a channel, an iterator function, or a paginator.
+func IsGoSequence(v Value) bool {
+	_, _, ok := goWalk(v)
+	return ok
+}
+
+// counted pairs each step of a walk with its count, from zero, the way a
+// litter's elements are paired with their index.
+func counted(walk func(yield func(Value) bool)) func(yield func(k, v Value) bool) {
+	return func(yield func(k, v Value) bool) {
+		var i int64
+		walk(func(v Value) bool {
+			ok := yield(NewInt(i), v)
+			i++
+			return ok
+		})
+	}
+}
+
+// seqArity reports whether t has the shape of iter.Seq or iter.Seq2 — a
+// function taking a yield function and giving nothing back — and how many
+// values the yield takes. The shape is what counts rather than the name: an
+// iterator declared before package iter existed is walked the same.
+func seqArity(t reflect.Type) (int, bool) {
+	if t.NumIn() != 1 || t.NumOut() != 0 || t.IsVariadic() {
+		return 0, false
+	}
+	y := t.In(0)
+	if y.Kind() != reflect.Func || y.NumOut() != 1 || y.Out(0).Kind() != reflect.Bool || y.IsVariadic() {
+		return 0, false
+	}
+	if n := y.NumIn(); n == 1 || n == 2 {
+		return n, true
+	}
+	return 0, false
+}
+
+// yieldFunc makes the yield function an iterator is called with.
+func yieldFunc(t reflect.Type, yield func([]reflect.Value) bool) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		return []reflect.Value{reflect.ValueOf(yield(in)).Convert(t.Out(0))}
+	})
+}
+
+// paginator reads rv as a paginator: something with HasMorePages() bool and a
+// NextPage that fetches the next one. Each page is fetched with a context of
+// its own, as a call is, and read as a call's result is.
+//
+// A page that fails is the last step of the walk, as its furball. Asking
+// again would ask for the same page — a paginator does not move past one it
+// could not fetch — so the walk would never end.
+func paginator(rv reflect.Value) (func(yield func(Value) bool), bool) {
+	more := rv.MethodByName("HasMorePages")
+	next := rv.MethodByName("NextPage")
+	if !more.IsValid() || !next.IsValid() {
+		return nil, false
+	}
+	mt := more.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return func(yield func(Value) bool) {
+		for more.Call(nil)[0].Bool() {
+			page := nextPage(next)
+			if _, failed := page.(*Furball); failed {
+				yield(page)
+				return
+			}
+			if !yield(page) {
+				return
+			}
+		}
+	}, true
+}
+
+func nextPage(next reflect.Value) Value {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeoutForBridge)
+	defer cancel()
+	return callReflected(ctx, "NextPage", next, nil)
+}
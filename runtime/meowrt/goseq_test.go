@@ -0,0 +1,130 @@
+package meowrt
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAGoChannelIsWalkedUntilItCloses(t *testing.T) {
+	ch := make(chan string, 2)
+	ch <- "nya"
+	ch <- "mew"
+	close(ch)
+
+	got := solo(FromGo(ch))
+	if !slices.Equal(got, []string{"nya", "mew"}) {
+		t.Errorf("got %v, want [nya mew]", got)
+	}
+}
+
+func TestAnIterSeqIsWalked(t *testing.T) {
+	// SplitSeq's iterator is single-use, so each walk asks for its own.
+	seq := func() Value { return FromGo(strings.SplitSeq("a,b,c", ",")) }
+
+	if got := solo(seq()); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("solo = %v, want [a b c]", got)
+	}
+	first, second := pair(seq())
+	if !slices.Equal(first, []string{"0", "1", "2"}) || !slices.Equal(second, []string{"a", "b", "c"}) {
+		t.Errorf("pair = %v %v, want a count and [a b c]", first, second)
+	}
+}
+
+func TestAnIterSeq2IsWalkedByKeyAndValue(t *testing.T) {
+	var seq iter.Seq2[string, int] = func(yield func(string, int) bool) {
+		_ = yield("a", 1) && yield("b", 2)
+	}
+
+	if got := solo(FromGo(seq)); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("solo = %v, want the keys [a b]", got)
+	}
+	first, second := pair(FromGo(seq))
+	if !slices.Equal(first, []string{"a", "b"}) || !slices.Equal(second, []string{"1", "2"}) {
+		t.Errorf("pair = %v %v, want [a b] [1 2]", first, second)
+	}
+}
+
+// Leaving the loop early — bolt — is the iterator being told to stop, not a
+// yield it goes on calling.
+func TestLeavingAWalkStopsTheIterator(t *testing.T) {
+	asked := 0
+	var seq iter.Seq[int] = func(yield func(int) bool) {
+		for i := range 10 {
+			asked++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	for v := range RangeSolo(FromGo(seq)) {
+		if v.String() == "2" {
+			break
+		}
+	}
+	if asked != 3 {
+		t.Errorf("the iterator was asked %d times, want 3", asked)
+	}
+}
+
+type fakePage struct {
+	Items []string
+}
+
+type fakePaginator struct {
+	pages [][]string
+	fail  bool
+}
+
+func (p *fakePaginator) HasMorePages() bool { return len(p.pages) > 0 }
+
+func (p *fakePaginator) NextPage(ctx context.Context, _ ...func(*int)) (*fakePage, error) {
+	if ctx == nil {
+		return nil, errors.New("no context")
+	}
+	if p.fail {
+		return nil, errors.New("throttled")
+	}
+	page := &fakePage{Items: p.pages[0]}
+	p.pages = p.pages[1:]
+	return page, nil
+}
+
+func TestAPaginatorIsWalkedPageByPage(t *testing.T) {
+	p := &fakePaginator{pages: [][]string{{"a", "b"}, {}, {"c"}}}
+
+	var got []string
+	for page := range RangeSolo(FromGo(p)) {
+		got = append(got, page.(*Map).Items["items"].String())
+	}
+	if want := []string{"[a, b]", "[]", "[c]"}; !slices.Equal(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+// A paginator asked again after a failure asks for the same page, so the
+// failure ends the walk rather than repeating forever.
+func TestAPageThatFailsEndsTheWalk(t *testing.T) {
+	p := &fakePaginator{pages: [][]string{{"a"}}, fail: true}
+
+	var got []Value
+	for page := range RangeSolo(FromGo(p)) {
+		got = append(got, page)
+	}
+	if _, failed := got[0].(*Furball); len(got) != 1 || !failed || !strings.Contains(got[0].String(), "throttled") {
+		t.Errorf("pages = %v, want the one furball", got)
+	}
+}
+
+func TestANilGoChannelIsNothing(t *testing.T) {
+	var ch chan int
+	if _, isNil := FromGo(ch).(*NilValue); !isNil {
+		t.Errorf("FromGo(nil chan) = %s, want catnap", FromGo(ch))
+	}
+	if IsGoSequence(FromGo(&fakePage{})) {
+		t.Error("a record is not a sequence")
+	}
+}
@@ -3,6 +3,7 @@ package meowrt
 import (
 	"fmt"
 	"iter"
+	"sync/atomic"
 )
 
 // Iter returns an iterator over the list items.
@@ -109,6 +110,14 @@ func Curl(lst Value, init Value, fn Value) Value {
 }
 
 // Append appends a value to a list, returning a new list.
+//
+// The list it was given is left as it was, but not always its backing array:
+// a list Append made has room to spare after its items, and the first list
+// appended to in that room takes the next slot rather than a copy of all
+// that came before. A program growing a litter an element at a time copies
+// it now and then, as a Go slice is, and not on every append. The slot goes
+// to whichever list claims it first; appending to the same list again, or to
+// one that ends before the claimed part, copies.
 func Append(lst Value, v Value) Value {
 	l, fb := requireList("append", lst)
 	if fb != nil {
@@ -117,10 +126,16 @@ func Append(lst Value, v Value) Value {
 	if f, ok := v.(*Furball); ok {
 		return f
 	}
-	items := make([]Value, len(l.Items)+1)
+	n := len(l.Items)
+	if l.tail != nil && n < cap(l.Items) && l.tail.CompareAndSwap(int64(n), int64(n+1)) {
+		return &List{Items: append(l.Items, v), tail: l.tail}
+	}
+	items := make([]Value, n+1, max(2*n, 4))
 	copy(items, l.Items)
-	items[len(l.Items)] = v
-	return NewList(items...)
+	items[n] = v
+	tail := new(atomic.Int64)
+	tail.Store(int64(n + 1))
+	return &List{Items: items, tail: tail}
 }
 
 // Head returns the first element of a list.
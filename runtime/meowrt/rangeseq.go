@@ -20,7 +20,8 @@ func sortedKeys(m *Map) []string {
 }
 
 // RangeSolo yields what the one-variable `purr x (v)` binds on each turn:
-// a litter's elements, a basket's keys, or the numbers counted up to.
+// a litter's elements, a basket's keys, the steps of a Go sequence (see
+// goWalk), or the numbers counted up to.
 //
 // A basket yields keys rather than values because that is what a program has
 // to have — the value is one lookup away, and the key is not recoverable from
@@ -41,6 +42,15 @@ func RangeSolo(v Value) iter.Seq[Value] {
 				}
 			}
 		default:
+			if walk, keyed, ok := goWalk(v); ok {
+				walk(func(k, e Value) bool {
+					if keyed {
+						return yield(k)
+					}
+					return yield(e)
+				})
+				return
+			}
 			n := AsInt(v)
 			for i := int64(0); i < n; i++ {
 				if !yield(NewInt(i)) {
@@ -52,7 +62,8 @@ func RangeSolo(v Value) iter.Seq[Value] {
 }
 
 // RangePair yields what the two-variable `purr a, b (v)` binds: a litter's
-// index and element, or a basket's key and value.
+// index and element, a basket's key and value, or a Go sequence's count and
+// step.
 //
 // Counting is offered too, so that a subject whose kind is only known at run
 // time has an answer in either form; both variables take the counter, as there
@@ -73,6 +84,10 @@ func RangePair(v Value) iter.Seq2[Value, Value] {
 				}
 			}
 		default:
+			if walk, _, ok := goWalk(v); ok {
+				walk(yield)
+				return
+			}
 			n := AsInt(v)
 			for i := int64(0); i < n; i++ {
 				if !yield(NewInt(i), NewInt(i)) {
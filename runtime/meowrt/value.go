@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 // Value is the core interface for all Meow values.
@@ -189,6 +190,11 @@ type List struct {
 	// Items is the slice of values in the list.
 	Items []Value
 	origin
+	// tail is how far the backing array of Items has been claimed, when it
+	// was made by Append with room to spare: the next Append of a list whose
+	// items end there may take the next slot for itself, where any other
+	// copies. Nil for every other list, whose spare room is not Append's.
+	tail *atomic.Int64
 }
 
 // NewList creates a new List value from the given items.
@@ -187,3 +187,25 @@ func TestABasketIsShownTheSameWayTwice(t *testing.T) {
 		}
 	}
 }
+
+// Append may grow into the room it left after a list it made, but two lists
+// appended to from the same one must not share the slot.
+func TestAppendLeavesEveryListAsItWas(t *testing.T) {
+	base := meowrt.NewList()
+	for i := range 5 {
+		base = meowrt.Append(base, meowrt.NewInt(int64(i))).(*meowrt.List)
+	}
+	a := meowrt.Append(base, meowrt.NewString("a"))
+	b := meowrt.Append(base, meowrt.NewString("b"))
+	aa := meowrt.Append(a, meowrt.NewString("aa"))
+	for v, want := range map[meowrt.Value]string{
+		base: "[0, 1, 2, 3, 4]",
+		a:    "[0, 1, 2, 3, 4, a]",
+		b:    "[0, 1, 2, 3, 4, b]",
+		aa:   "[0, 1, 2, 3, 4, a, aa]",
+	} {
+		if got := v.String(); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
@@ -32,6 +32,21 @@ var randomFloat = rand.Float64
 // randomBytes is swapped out in tests to make the outcome predictable.
 var randomBytes = crand.Read
 
+// Swap has roll and pick draw from intN, drift from float and tuft from
+// bytes, until restore is called to put back what they replaced. It is how
+// testing.seed and testing.queue reach this package from a Meow test.
+func Swap(intN func(int64) int64, float func() float64, bytes func([]byte) (int, error)) (restore func()) {
+	oldInt, oldFloat, oldBytes := randomInt, randomFloat, randomBytes
+	randomInt, randomFloat, randomBytes = intN, float, bytes
+	return func() { randomInt, randomFloat, randomBytes = oldInt, oldFloat, oldBytes }
+}
+
+// Current gives back what the package draws from now, for a Swap that only
+// replaces some of it to pass the rest through.
+func Current() (intN func(int64) int64, float func() float64, bytes func([]byte) (int, error)) {
+	return randomInt, randomFloat, randomBytes
+}
+
 // Roll returns a random integer in [0, n).
 func Roll(args ...meowrt.Value) meowrt.Value {
 	if len(args) != 1 {
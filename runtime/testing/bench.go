@@ -0,0 +1,69 @@
+package meowtest
+
+import (
+	"testing"
+
+	"github.com/135yshr/meow/pkg/checker"
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// Bench runs a bench_ function as the body of a Go benchmark.
+//
+// The function is handed a benchmark handle, a kitty whose members are what a
+// Meow program needs of a *testing.B:
+//
+//	b.n                how many times to do the work, in a counting loop
+//	b.loop()           whether to go round again, for purr (b.loop()) { ... }
+//	b.reset_timer()    forget the time and allocations spent so far
+//	b.stop_timer()     stop counting, for setup in the middle of a run
+//	b.start_timer()    start counting again
+//
+// The handle is made afresh each time Go calls the benchmark, as b.N is only
+// settled for that call. A Furball the function answers with fails the
+// benchmark with its message, so a benchmark of broken code says so rather
+// than timing how fast it breaks.
+func Bench(b *testing.B, fn func(handle meowrt.Value) meowrt.Value) {
+	b.Helper()
+	if f, failed := fn(NewBenchHandle(b)).(*meowrt.Furball); failed {
+		b.Fatal(f.Message)
+	}
+}
+
+// BenchHandle is the type name of the handle a bench_ function is given, the
+// one the checker lets a bench_ function declare its parameter as.
+const BenchHandle = checker.BenchType
+
+// NewBenchHandle makes the handle a bench_ function is given for b.
+func NewBenchHandle(b *testing.B) meowrt.Value {
+	// b.loop() is called once per iteration, so its answers are made once
+	// rather than per call: timing a benchmark should not mostly time the
+	// handle.
+	yes, no := meowrt.NewBool(true), meowrt.NewBool(false)
+	nothing := meowrt.NewNil()
+	fields := map[string]meowrt.Value{
+		"n": meowrt.NewInt(int64(b.N)),
+		"loop": meowrt.NewFuncWithArity("loop", 0, func(...meowrt.Value) meowrt.Value {
+			if b.Loop() {
+				return yes
+			}
+			return no
+		}),
+		"reset_timer": meowrt.NewFuncWithArity("reset_timer", 0, func(...meowrt.Value) meowrt.Value {
+			b.ResetTimer()
+			return nothing
+		}),
+		"stop_timer": meowrt.NewFuncWithArity("stop_timer", 0, func(...meowrt.Value) meowrt.Value {
+			b.StopTimer()
+			return nothing
+		}),
+		"start_timer": meowrt.NewFuncWithArity("start_timer", 0, func(...meowrt.Value) meowrt.Value {
+			b.StartTimer()
+			return nothing
+		}),
+	}
+	return &meowrt.Kitty{
+		TypeName:   BenchHandle,
+		FieldNames: []string{"n", "loop", "reset_timer", "stop_timer", "start_timer"},
+		Fields:     fields,
+	}
+}
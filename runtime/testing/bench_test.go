@@ -0,0 +1,37 @@
+package meowtest_test
+
+import (
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+func TestTheBenchHandleLoopsAsGoDoes(t *testing.T) {
+	calls := 0
+	result := testing.Benchmark(func(b *testing.B) {
+		meowtest.Bench(b, func(handle meowrt.Value) meowrt.Value {
+			meowrt.CallMember(handle, "reset_timer")
+			for meowrt.CallMember(handle, "loop").IsTruthy() {
+				calls++
+			}
+			return meowrt.NewNil()
+		})
+	})
+	if result.N == 0 || calls < result.N {
+		t.Errorf("the loop went round %d times for N = %d", calls, result.N)
+	}
+}
+
+func TestTheBenchHandleHoldsN(t *testing.T) {
+	var seen []int64
+	testing.Benchmark(func(b *testing.B) {
+		handle := meowtest.NewBenchHandle(b)
+		seen = append(seen, meowrt.GetMember(handle, "n").(*meowrt.Int).Val)
+		for range b.N {
+		}
+	})
+	if len(seen) == 0 || seen[0] != 1 {
+		t.Errorf("n was %v, want it to start at Go's first N, 1", seen)
+	}
+}
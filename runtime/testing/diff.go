@@ -0,0 +1,331 @@
+package meowtest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// colored has expect's diffs colored, for a terminal to show; see
+// Options.Color.
+var colored bool
+
+const (
+	colorGot      = "\033[31m"
+	colorExpected = "\033[32m"
+	colorReset    = "\033[0m"
+)
+
+// maxDifferences is how many differences a diff lists before it gives up and
+// counts the rest: past that, the values have little in common, and the first
+// few say so as well as all of them would.
+const maxDifferences = 20
+
+// difference is one place two values differ, with the path to it from the
+// top: [3].name, ["tags"][0]. A difference in a string of several lines has
+// the lines of each instead of the two values.
+type difference struct {
+	path          string
+	got, expected string
+	lines         []string
+}
+
+// missing stands in for the element or key one side of a difference does not
+// have.
+const missing = "(missing)"
+
+// describeMismatch says how got differs from expected, which expect has found
+// to differ. Two plain values are put the way expect always put them; a list,
+// a basket or a kitty is gone through, and each place it differs is given on
+// a line of its own, and a string of several lines is compared line by line.
+func describeMismatch(got, expected meowrt.Value) string {
+	var diffs []difference
+	diffValues("", got, expected, &diffs)
+	if len(diffs) == 0 || (len(diffs) == 1 && diffs[0].path == "" && diffs[0].lines == nil) {
+		return fmt.Sprintf("expected %s, got %s", expected.String(), got.String())
+	}
+
+	var b strings.Builder
+	b.WriteString("got differs from expected:")
+	for i, d := range diffs {
+		if i == maxDifferences {
+			fmt.Fprintf(&b, "\n      ... and %d more", len(diffs)-i)
+			break
+		}
+		path := d.path
+		if path == "" {
+			path = "(value)"
+		}
+		if d.lines != nil {
+			fmt.Fprintf(&b, "\n      %s: lines differ (%s, %s):", path, paint(colorExpected, "- expected"), paint(colorGot, "+ got"))
+			for _, line := range d.lines {
+				b.WriteString("\n        ")
+				b.WriteString(line)
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "\n      %s: %s != %s", path, paint(colorGot, d.got), paint(colorExpected, d.expected))
+	}
+	return b.String()
+}
+
+func paint(color, s string) string {
+	if !colored {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// diffValues adds the places got and expected differ, below path, to diffs.
+// Values of different kinds differ as wholes; plain values are compared the
+// way expect compares them, by how they print.
+func diffValues(path string, got, expected meowrt.Value, diffs *[]difference) {
+	switch e := expected.(type) {
+	case *meowrt.List:
+		if g, ok := got.(*meowrt.List); ok {
+			for i := range max(len(g.Items), len(e.Items)) {
+				diffMember(fmt.Sprintf("%s[%d]", path, i), g.Items, e.Items, i, diffs)
+			}
+			return
+		}
+	case *meowrt.Map:
+		if g, ok := got.(*meowrt.Map); ok {
+			keys := make([]string, 0, len(g.Items)+len(e.Items))
+			for k := range g.Items {
+				keys = append(keys, k)
+			}
+			for k := range e.Items {
+				if _, ok := g.Items[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				diffKey(fmt.Sprintf("%s[%q]", path, k), g.Items, e.Items, k, diffs)
+			}
+			return
+		}
+	case *meowrt.Kitty:
+		if g, ok := got.(*meowrt.Kitty); ok && g.TypeName == e.TypeName {
+			for _, name := range e.FieldNames {
+				diffKey(path+"."+name, g.Fields, e.Fields, name, diffs)
+			}
+			return
+		}
+	case *meowrt.String:
+		if g, ok := got.(*meowrt.String); ok && g.Val != e.Val &&
+			(strings.Contains(g.Val, "\n") || strings.Contains(e.Val, "\n")) {
+			*diffs = append(*diffs, difference{path: path, lines: diffLines(g.Val, e.Val)})
+			return
+		}
+	}
+	if got.String() != expected.String() {
+		*diffs = append(*diffs, difference{path: path, got: show(got), expected: show(expected)})
+	}
+}
+
+func diffMember(path string, got, expected []meowrt.Value, i int, diffs *[]difference) {
+	switch {
+	case i >= len(got):
+		*diffs = append(*diffs, difference{path: path, got: missing, expected: show(expected[i])})
+	case i >= len(expected):
+		*diffs = append(*diffs, difference{path: path, got: show(got[i]), expected: missing})
+	default:
+		diffValues(path, got[i], expected[i], diffs)
+	}
+}
+
+func diffKey(path string, got, expected map[string]meowrt.Value, key string, diffs *[]difference) {
+	g, inGot := got[key]
+	e, inExpected := expected[key]
+	switch {
+	case !inGot:
+		*diffs = append(*diffs, difference{path: path, got: missing, expected: show(e)})
+	case !inExpected:
+		*diffs = append(*diffs, difference{path: path, got: show(g), expected: missing})
+	default:
+		diffValues(path, g, e, diffs)
+	}
+}
+
+// show is a value as a diff gives it: a string is quoted, so that one that
+// is empty or ends in a space can be seen for what it is.
+func show(v meowrt.Value) string {
+	if s, ok := v.(*meowrt.String); ok {
+		return fmt.Sprintf("%q", s.Val)
+	}
+	return v.String()
+}
+
+// diffContext is how many lines that are the same a line diff keeps on each
+// side of a change; longer runs of them are cut short.
+const diffContext = 2
+
+// maxDiffEdits is how many lines a line diff adds and removes at most in
+// lining the two strings up. Two long strings with little in common would
+// take time and memory to line up that a failing test is not worth, and the
+// diff would be too long to read; past this, the diff gives the start and end
+// of each instead.
+const maxDiffEdits = 1000
+
+// diffLine is a line of a diff: op is '-' for a line only expected has, '+'
+// for one only got has, ' ' for one they share, and '.' for lines left out.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines compares two strings line by line, and gives back the lines of
+// the diff: "- " for a line only expected has, "+ " for one only got has, and
+// "  " for one they share.
+func diffLines(got, expected string) []string {
+	g := strings.Split(got, "\n")
+	e := strings.Split(expected, "\n")
+
+	// What the two start and end with is theirs in common however the rest
+	// lines up, and is most of what a failing expect compares.
+	prefix := 0
+	for prefix < len(e) && prefix < len(g) && e[prefix] == g[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(e)-prefix && suffix < len(g)-prefix && e[len(e)-1-suffix] == g[len(g)-1-suffix] {
+		suffix++
+	}
+	var all []diffLine
+	for _, l := range e[:prefix] {
+		all = append(all, diffLine{' ', l})
+	}
+	middleE, middleG := e[prefix:len(e)-suffix], g[prefix:len(g)-suffix]
+	if edits, ok := editScript(middleE, middleG); ok {
+		all = append(all, edits...)
+	} else {
+		all = append(all, summarize('-', middleE)...)
+		all = append(all, summarize('+', middleG)...)
+	}
+	for _, l := range e[len(e)-suffix:] {
+		all = append(all, diffLine{' ', l})
+	}
+
+	near := func(k int) bool {
+		for d := max(0, k-diffContext); d <= min(len(all)-1, k+diffContext); d++ {
+			if all[d].op == '-' || all[d].op == '+' {
+				return true
+			}
+		}
+		return false
+	}
+	var out []string
+	cut := false
+	for k, l := range all {
+		if l.op == '.' {
+			out = append(out, "  "+l.text)
+			cut = false
+			continue
+		}
+		if l.op == ' ' && !near(k) {
+			if !cut {
+				out = append(out, "  ...")
+				cut = true
+			}
+			continue
+		}
+		cut = false
+		text := string(l.op) + " " + l.text
+		switch l.op {
+		case '-':
+			text = paint(colorExpected, text)
+		case '+':
+			text = paint(colorGot, text)
+		}
+		out = append(out, text)
+	}
+	return out
+}
+
+// editScript lines e and g up with the fewest lines added and removed, by
+// Myers' algorithm, which takes time and memory in the size of the difference
+// rather than the product of the two lengths. It gives up, answering false,
+// past maxDiffEdits.
+func editScript(e, g []string) ([]diffLine, bool) {
+	n, m := len(e), len(g)
+	limit := min(n+m, maxDiffEdits)
+	// v[off+k] is how far into e the furthest path on diagonal k, where
+	// k is how many more lines of e than of g it has gone through, has got.
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // a line of g added
+			} else {
+				x = v[off+k-1] + 1 // a line of e removed
+			}
+			y := x - k
+			for x < n && y < m && e[x] == g[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+				return backtrack(e, g, trace), true
+			}
+		}
+		trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+	}
+	return nil, false
+}
+
+// backtrack follows the path editScript found back from the end, trace[d]
+// being how far each diagonal had got after d edits.
+func backtrack(e, g []string, trace [][]int) []diffLine {
+	x, y := len(e), len(g)
+	var rev []diffLine
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // diagonal k at prev[k+d-1]
+		k := x - y
+		pk := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			pk = k + 1
+		}
+		px := prev[pk+d-1]
+		py := px - pk
+		for x > px && y > py {
+			rev = append(rev, diffLine{' ', e[x-1]})
+			x, y = x-1, y-1
+		}
+		if pk == k+1 {
+			rev = append(rev, diffLine{'+', g[y-1]})
+			y--
+		} else {
+			rev = append(rev, diffLine{'-', e[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		rev = append(rev, diffLine{' ', e[x-1]})
+		x, y = x-1, y-1
+	}
+	slices.Reverse(rev)
+	return rev
+}
+
+// summarize is lines, all marked op, with only the first and last few given
+// when there are many.
+func summarize(op byte, lines []string) []diffLine {
+	const shown = 5
+	var out []diffLine
+	for i, l := range lines {
+		if len(lines) > 2*shown && i == shown {
+			out = append(out, diffLine{'.', fmt.Sprintf("... %d more lines", len(lines)-2*shown)})
+		}
+		if len(lines) <= 2*shown || i < shown || i >= len(lines)-shown {
+			out = append(out, diffLine{op, l})
+		}
+	}
+	return out
+}
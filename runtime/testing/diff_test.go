@@ -0,0 +1,127 @@
+package meowtest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+func cat(name string, age int64) meowrt.Value {
+	return meowrt.NewKitty("Cat", []string{"name", "age"}, meowrt.NewString(name), meowrt.NewInt(age))
+}
+
+func expectMessage(t *testing.T, args ...meowrt.Value) string {
+	t.Helper()
+	f, ok := meowtest.Expect(args...).(*meowrt.Furball)
+	if !ok {
+		t.Fatal("expect passed, want it failed")
+	}
+	return f.Message
+}
+
+func TestExpectGivesThePathToWhatDiffers(t *testing.T) {
+	setup(t)
+	got := meowrt.NewList(cat("Mike", 2), meowrt.NewMap(map[string]meowrt.Value{
+		"tags": meowrt.NewList(meowrt.NewString("a")),
+		"size": meowrt.NewInt(3),
+	}))
+	want := meowrt.NewList(cat("Mike", 3), meowrt.NewMap(map[string]meowrt.Value{
+		"tags": meowrt.NewList(meowrt.NewString("a"), meowrt.NewString("b")),
+		"kind": meowrt.NewString("box"),
+	}))
+	msg := expectMessage(t, got, want)
+	for _, line := range []string{
+		"got differs from expected:",
+		`[0].age: 2 != 3`,
+		`[1]["kind"]: (missing) != "box"`,
+		`[1]["size"]: 3 != (missing)`,
+		`[1]["tags"][1]: (missing) != "b"`,
+	} {
+		if !strings.Contains(msg, line) {
+			t.Errorf("message = %q, want %q in it", msg, line)
+		}
+	}
+}
+
+func TestExpectKeepsPlainValuesOnOneLine(t *testing.T) {
+	setup(t)
+	if msg := expectMessage(t, meowrt.NewInt(1), meowrt.NewInt(2), meowrt.NewString("sum")); msg != "sum: expected 2, got 1" {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestExpectDiffsLongStringsByLine(t *testing.T) {
+	setup(t)
+	got := "a\nb\nc\nd\ne\nf\ng\nh"
+	want := "a\nb\nc\nd\nE\nf\ng\nh"
+	msg := expectMessage(t, meowrt.NewString(got), meowrt.NewString(want))
+	want = `      (value): lines differ (- expected, + got):
+          ...
+          c
+          d
+        - E
+        + e
+          f
+          g
+          ...`
+	if !strings.HasSuffix(msg, want) {
+		t.Errorf("message =\n%s\nwant it to end\n%s", msg, want)
+	}
+}
+
+func TestExpectLinesUpAMovedLine(t *testing.T) {
+	setup(t)
+	msg := expectMessage(t, meowrt.NewString("b\nc\nd\na\ne"), meowrt.NewString("a\nb\nc\nd\ne"))
+	want := `      (value): lines differ (- expected, + got):
+        - a
+          b
+          c
+          d
+        + a
+          e`
+	if !strings.HasSuffix(msg, want) {
+		t.Errorf("message =\n%s\nwant it to end\n%s", msg, want)
+	}
+}
+
+func TestExpectDiffsLongStringsWithoutLiningUpTooMuch(t *testing.T) {
+	setup(t)
+	var got, want strings.Builder
+	for i := range 10000 {
+		fmt.Fprintf(&got, "got %d\n", i)
+		fmt.Fprintf(&want, "want %d\n", i)
+	}
+	start := time.Now()
+	msg := expectMessage(t, meowrt.NewString(got.String()), meowrt.NewString(want.String()))
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the diff took %s", d)
+	}
+	for _, line := range []string{"- want 0", "- want 9999", "... 9990 more lines", "+ got 0", "+ got 9999"} {
+		if !strings.Contains(msg, line) {
+			t.Errorf("message =\n%s\nwant %q in it", msg, line)
+		}
+	}
+	if n := strings.Count(msg, "\n"); n > 40 {
+		t.Errorf("message is %d lines long, want the middles left out", n)
+	}
+
+	// One line apart in ten thousand is lined up, as it is in a short one.
+	msg = expectMessage(t, meowrt.NewString(strings.Replace(got.String(), "got 5000\n", "got 5000!\n", 1)), meowrt.NewString(got.String()))
+	if !strings.Contains(msg, "- got 5000\n") || !strings.Contains(msg, "+ got 5000!\n") || strings.Count(msg, "\n") > 10 {
+		t.Errorf("message =\n%s\nwant only line 5000 in it", msg)
+	}
+}
+
+func TestColoredDiffsAreMarked(t *testing.T) {
+	buf, _ := setup(t)
+	meowtest.RunSuite(meowtest.Options{Color: true}, meowtest.Case{Name: "test_color", Fn: func(...meowrt.Value) meowrt.Value {
+		return meowtest.Expect(meowrt.NewList(meowrt.NewInt(1)), meowrt.NewList(meowrt.NewInt(2)))
+	}})
+	if !strings.Contains(buf.String(), "[0]: \033[31m1\033[0m != \033[32m2\033[0m") {
+		t.Errorf("output = %q, want got in red and expected in green", buf.String())
+	}
+}
@@ -0,0 +1,81 @@
+package meowtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// eventMarker starts each line of a test binary's output that is an event
+// rather than something a test printed. The compiler reads the binary's
+// output line by line, and a program is free to print a line of JSON of its
+// own; the marker is a control character no one prints by accident.
+const eventMarker = "\x1emeow-event "
+
+// event is what the binary knows of something that happened to a test. The
+// compiler fills in the rest of a `go test -json` event — when, and which
+// file — as it reads them.
+type event struct {
+	Action  string
+	Test    string   `json:",omitempty"`
+	Elapsed *float64 `json:",omitempty"`
+	Output  string   `json:",omitempty"`
+}
+
+// eventWriter stands in for output while a test file runs under -json. What
+// would have been written as text is sent as output events instead, a line to
+// an event, credited to the test running when it was written.
+type eventWriter struct {
+	w       io.Writer
+	test    string
+	pending []byte
+}
+
+func (ew *eventWriter) Write(p []byte) (int, error) {
+	ew.pending = append(ew.pending, p...)
+	for {
+		i := bytes.IndexByte(ew.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		ew.emit(event{Action: "output", Test: ew.test, Output: string(ew.pending[:i+1])})
+		ew.pending = ew.pending[i+1:]
+	}
+}
+
+// flush sends whatever was written without a newline to end it.
+func (ew *eventWriter) flush() {
+	if len(ew.pending) > 0 {
+		ew.emit(event{Action: "output", Test: ew.test, Output: string(ew.pending)})
+		ew.pending = nil
+	}
+}
+
+func (ew *eventWriter) emit(e event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(ew.w, "%s%s\n", eventMarker, b)
+}
+
+// start says a test has begun; output from now on is its own.
+func (ew *eventWriter) start(name string) {
+	ew.flush()
+	ew.test = name
+	ew.emit(event{Action: "run", Test: name})
+}
+
+// end says how a test went, and how long it took.
+func (ew *eventWriter) end(name string, passed bool, elapsed time.Duration) {
+	ew.flush()
+	action := "pass"
+	if !passed {
+		action = "fail"
+	}
+	seconds := elapsed.Seconds()
+	ew.emit(event{Action: action, Test: name, Elapsed: &seconds})
+	ew.test = ""
+}
@@ -0,0 +1,407 @@
+package meowtest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// FuzzShape is the form of a value a fuzz_ function takes, for DecodeFuzz to
+// make one of out of the bytes go's fuzzing hands it. Go fuzzes ints, floats,
+// strings and bools by themselves; a litter, a basket or a kitty it does not
+// know, and a function taking one is handed a []byte instead, decoded as the
+// shapes of all it takes say.
+type FuzzShape struct {
+	Kind FuzzKind
+	// Elem is what a litter or basket holds.
+	Elem *FuzzShape
+	// Name and Fields are a kitty's.
+	Name   string
+	Fields []FuzzField
+}
+
+// FuzzField is a field of a kitty FuzzShape.
+type FuzzField struct {
+	Name  string
+	Shape FuzzShape
+}
+
+// FuzzKind is the kind of thing a FuzzShape is of.
+type FuzzKind int
+
+const (
+	intKind FuzzKind = iota
+	floatKind
+	stringKind
+	boolKind
+	// anyKind is a parameter that says nothing of what it takes, or a
+	// litter or basket of anything: it is decoded as one of the scalars, or
+	// catnap.
+	anyKind
+	listKind
+	basketKind
+	kittyKind
+)
+
+// The shapes of the scalars, and of litters, baskets and kitties of them, for
+// generated code to build a parameter's FuzzShape out of.
+var (
+	FuzzInt    = FuzzShape{Kind: intKind}
+	FuzzFloat  = FuzzShape{Kind: floatKind}
+	FuzzString = FuzzShape{Kind: stringKind}
+	FuzzBool   = FuzzShape{Kind: boolKind}
+	FuzzAny    = FuzzShape{Kind: anyKind}
+)
+
+// FuzzList is the shape of a litter of elem.
+func FuzzList(elem FuzzShape) FuzzShape { return FuzzShape{Kind: listKind, Elem: &elem} }
+
+// FuzzBasket is the shape of a basket of elem.
+func FuzzBasket(elem FuzzShape) FuzzShape { return FuzzShape{Kind: basketKind, Elem: &elem} }
+
+// FuzzKitty is the shape of the kitty name, with fields in the order it
+// declares them.
+func FuzzKitty(name string, fields ...FuzzField) FuzzShape {
+	return FuzzShape{Kind: kittyKind, Name: name, Fields: fields}
+}
+
+// maxLen is the most elements a litter or basket, or bytes a string, is
+// decoded with: its length is one byte of the input.
+const maxLen = math.MaxUint8
+
+// DecodeFuzz makes a value of each of shapes out of data, which is read as:
+//
+//   - an int as 8 bytes, little-endian; a float as the 8 bytes of its bits,
+//     read as 0 where they are not a finite number; a bool as 1 byte, odd for
+//     yarn
+//   - a string as a byte of its length and then its bytes, with ? for any
+//     that are not valid UTF-8
+//   - a litter as a byte of its length and then its elements; a basket the
+//     same, each element a key string and then its value
+//   - a kitty as its fields, in the order it declares them
+//   - anything as a byte saying which of int, float, string, bool and catnap
+//     it is, and then that
+//
+// Whatever data runs out before is read as zeros, so that every input makes
+// values, if short and empty ones.
+func DecodeFuzz(data []byte, shapes ...FuzzShape) []meowrt.Value {
+	d := &decoder{data: data}
+	values := make([]meowrt.Value, len(shapes))
+	for i, s := range shapes {
+		values[i] = d.value(s)
+	}
+	return values
+}
+
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) next(n int) []byte {
+	b := make([]byte, n)
+	copy(b, d.data)
+	d.data = d.data[min(n, len(d.data)):]
+	return b
+}
+
+func (d *decoder) length() int {
+	return int(d.next(1)[0])
+}
+
+func (d *decoder) string() string {
+	return strings.ToValidUTF8(string(d.next(d.length())), "?")
+}
+
+func (d *decoder) value(s FuzzShape) meowrt.Value {
+	switch s.Kind {
+	case intKind:
+		return meowrt.NewInt(int64(binary.LittleEndian.Uint64(d.next(8))))
+	case floatKind:
+		f := math.Float64frombits(binary.LittleEndian.Uint64(d.next(8)))
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			f = 0
+		}
+		return meowrt.NewFloat(f)
+	case stringKind:
+		return meowrt.NewString(d.string())
+	case boolKind:
+		return meowrt.NewBool(d.next(1)[0]&1 == 1)
+	case listKind:
+		items := make([]meowrt.Value, d.length())
+		for i := range items {
+			items[i] = d.value(*s.Elem)
+		}
+		return meowrt.NewList(items...)
+	case basketKind:
+		n := d.length()
+		items := make(map[string]meowrt.Value, n)
+		for range n {
+			k := d.string()
+			items[k] = d.value(*s.Elem)
+		}
+		return meowrt.NewMap(items)
+	case kittyKind:
+		names := make([]string, len(s.Fields))
+		args := make([]meowrt.Value, len(s.Fields))
+		for i, f := range s.Fields {
+			names[i] = f.Name
+			args[i] = d.value(f.Shape)
+		}
+		return meowrt.NewKitty(s.Name, names, args...)
+	default:
+		kinds := []FuzzShape{FuzzInt, FuzzFloat, FuzzString, FuzzBool}
+		k := int(d.next(1)[0]) % (len(kinds) + 1)
+		if k == len(kinds) {
+			return meowrt.NewNil()
+		}
+		return d.value(kinds[k])
+	}
+}
+
+// EncodeFuzz is the data DecodeFuzz makes values of shapes into, for a seed
+// to be handed to go's fuzzing as the input it stands for. A litter, basket or
+// string is cut to the first maxLen of its elements or bytes, and a value
+// that is not of its shape is encoded as the zero of it.
+func EncodeFuzz(shapes []FuzzShape, values ...meowrt.Value) []byte {
+	var b []byte
+	for i, s := range shapes {
+		var v meowrt.Value = meowrt.NewNil()
+		if i < len(values) {
+			v = values[i]
+		}
+		b = encode(b, s, v)
+	}
+	return b
+}
+
+func encode(b []byte, s FuzzShape, v meowrt.Value) []byte {
+	switch s.Kind {
+	case intKind:
+		n, _ := v.(*meowrt.Int)
+		var i int64
+		if n != nil {
+			i = n.Val
+		}
+		return binary.LittleEndian.AppendUint64(b, uint64(i))
+	case floatKind:
+		var f float64
+		switch n := v.(type) {
+		case *meowrt.Float:
+			f = n.Val
+		case *meowrt.Int:
+			f = float64(n.Val)
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+	case stringKind:
+		str, _ := v.(*meowrt.String)
+		if str == nil {
+			return append(b, 0)
+		}
+		return encodeString(b, str.Val)
+	case boolKind:
+		if t, ok := v.(*meowrt.Bool); ok && t.Val {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case listKind:
+		l, _ := v.(*meowrt.List)
+		if l == nil {
+			return append(b, 0)
+		}
+		items := l.Items[:min(len(l.Items), maxLen)]
+		b = append(b, byte(len(items)))
+		for _, item := range items {
+			b = encode(b, *s.Elem, item)
+		}
+		return b
+	case basketKind:
+		m, _ := v.(*meowrt.Map)
+		if m == nil {
+			return append(b, 0)
+		}
+		keys := make([]string, 0, len(m.Items))
+		for k := range m.Items {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		keys = keys[:min(len(keys), maxLen)]
+		b = append(b, byte(len(keys)))
+		for _, k := range keys {
+			b = encodeString(b, k)
+			b = encode(b, *s.Elem, m.Items[k])
+		}
+		return b
+	case kittyKind:
+		k, _ := v.(*meowrt.Kitty)
+		for _, f := range s.Fields {
+			var field meowrt.Value = meowrt.NewNil()
+			if k != nil {
+				if fv, ok := k.Fields[f.Name]; ok {
+					field = fv
+				}
+			}
+			b = encode(b, f.Shape, field)
+		}
+		return b
+	default:
+		switch v.(type) {
+		case *meowrt.Int:
+			return encode(append(b, 0), FuzzInt, v)
+		case *meowrt.Float:
+			return encode(append(b, 1), FuzzFloat, v)
+		case *meowrt.String:
+			return encode(append(b, 2), FuzzString, v)
+		case *meowrt.Bool:
+			return encode(append(b, 3), FuzzBool, v)
+		default:
+			return append(b, 4)
+		}
+	}
+}
+
+func encodeString(b []byte, s string) []byte {
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	b = append(b, byte(len(s)))
+	return append(b, s...)
+}
+
+// SeedCall is the seed() call that hands a fuzz_ function args, as it would
+// be written in a .nyan file, and whether they can be written so: a string
+// that is not valid UTF-8, a float that is not a finite number and a value
+// with no literal, like a function, cannot.
+func SeedCall(args ...meowrt.Value) (string, bool) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		lit, ok := literal(a)
+		if !ok {
+			return "", false
+		}
+		parts[i] = lit
+	}
+	return "seed(" + strings.Join(parts, ", ") + ")", true
+}
+
+// literal is v as meow source.
+func literal(v meowrt.Value) (string, bool) {
+	switch v := v.(type) {
+	case *meowrt.Int:
+		if v.Val == math.MinInt64 {
+			// Its digits without the minus are past what an int holds.
+			return "-9223372036854775807 - 1", true
+		}
+		return strconv.FormatInt(v.Val, 10), true
+	case *meowrt.Float:
+		if math.IsNaN(v.Val) || math.IsInf(v.Val, 0) {
+			return "", false
+		}
+		s := strconv.FormatFloat(v.Val, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s, true
+	case *meowrt.String:
+		return stringLiteral(v.Val)
+	case *meowrt.Bool:
+		if v.Val {
+			return "yarn", true
+		}
+		return "hairball", true
+	case *meowrt.NilValue:
+		return "catnap", true
+	case *meowrt.List:
+		parts := make([]string, len(v.Items))
+		for i, item := range v.Items {
+			lit, ok := literal(item)
+			if !ok {
+				return "", false
+			}
+			parts[i] = lit
+		}
+		return "[" + strings.Join(parts, ", ") + "]", true
+	case *meowrt.Map:
+		keys := make([]string, 0, len(v.Items))
+		for k := range v.Items {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			key, ok := stringLiteral(k)
+			val, vok := literal(v.Items[k])
+			if !ok || !vok {
+				return "", false
+			}
+			parts[i] = key + ": " + val
+		}
+		return "{" + strings.Join(parts, ", ") + "}", true
+	case *meowrt.Kitty:
+		parts := make([]string, len(v.FieldNames))
+		for i, name := range v.FieldNames {
+			lit, ok := literal(v.Fields[name])
+			if !ok {
+				return "", false
+			}
+			parts[i] = lit
+		}
+		return v.TypeName + "(" + strings.Join(parts, ", ") + ")", true
+	default:
+		return "", false
+	}
+}
+
+// stringLiteral is s as a meow string literal, with only the escapes meow
+// has.
+func stringLiteral(s string) (string, bool) {
+	if !utf8.ValidString(s) {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), true
+}
+
+// FuzzFailed fails the fuzz test t, for the furball f its fuzz_ function
+// came back with when handed args. When MEOW_FUZZ_SEED names a file, the
+// seed() call that hands the function args again is written there first:
+// meow runs a failing input once more with it set, to keep the input as a
+// seed beside the file it failed.
+func FuzzFailed(t interface {
+	Helper()
+	Fatal(...any)
+}, f *meowrt.Furball, args ...meowrt.Value) {
+	t.Helper()
+	if path := os.Getenv("MEOW_FUZZ_SEED"); path != "" {
+		if call, ok := SeedCall(args...); ok {
+			if err := os.WriteFile(path, []byte(call+"\n"), 0o644); err != nil {
+				t.Fatal(fmt.Sprintf("%s (and the input could not be saved: %v)", meowrt.Located(f.Message), err))
+			}
+		}
+	}
+	t.Fatal(meowrt.Located(f.Message))
+}
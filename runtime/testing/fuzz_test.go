@@ -0,0 +1,88 @@
+package meowtest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+var orderShape = meowtest.FuzzKitty("Order",
+	meowtest.FuzzField{Name: "id", Shape: meowtest.FuzzString},
+	meowtest.FuzzField{Name: "qty", Shape: meowtest.FuzzInt},
+	meowtest.FuzzField{Name: "tags", Shape: meowtest.FuzzList(meowtest.FuzzString)},
+)
+
+func seedCall(t *testing.T, args ...meowrt.Value) string {
+	t.Helper()
+	call, ok := meowtest.SeedCall(args...)
+	if !ok {
+		t.Fatalf("SeedCall(%v) cannot be written, want it can", args)
+	}
+	return call
+}
+
+func TestEncodeFuzzDecodesBackToTheSameValues(t *testing.T) {
+	shapes := []meowtest.FuzzShape{
+		orderShape,
+		meowtest.FuzzBasket(meowtest.FuzzFloat),
+		meowtest.FuzzList(meowtest.FuzzAny),
+		meowtest.FuzzBool,
+	}
+	values := []meowrt.Value{
+		meowrt.NewKitty("Order", []string{"id", "qty", "tags"},
+			meowrt.NewString("a\"b"), meowrt.NewInt(math.MinInt64),
+			meowrt.NewList(meowrt.NewString("x"), meowrt.NewString(""))),
+		meowrt.NewMap(map[string]meowrt.Value{"w": meowrt.NewFloat(1.5), "h": meowrt.NewFloat(-2)}),
+		meowrt.NewList(meowrt.NewInt(3), meowrt.NewString("s"), meowrt.NewBool(false), meowrt.NewNil(), meowrt.NewFloat(0.25)),
+		meowrt.NewBool(true),
+	}
+	want := seedCall(t, values...)
+	if want != `seed(Order("a\"b", -9223372036854775807 - 1, ["x", ""]), {"h": -2.0, "w": 1.5}, [3, "s", hairball, catnap, 0.25], yarn)` {
+		t.Fatalf("SeedCall = %s", want)
+	}
+	got := seedCall(t, meowtest.DecodeFuzz(meowtest.EncodeFuzz(shapes, values...), shapes...)...)
+	if got != want {
+		t.Errorf("decoded %s, want %s", got, want)
+	}
+}
+
+func TestDecodeFuzzReadsShortDataAsZeros(t *testing.T) {
+	got := seedCall(t, meowtest.DecodeFuzz([]byte{2, 'h'},
+		meowtest.FuzzString, orderShape, meowtest.FuzzBasket(meowtest.FuzzInt), meowtest.FuzzFloat)...)
+	// The NUL the string runs short with is written as itself.
+	if want := "seed(\"h\x00\", Order(\"\", 0, []), {}, 0.0)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDecodeFuzzMakesStringsValidAndFloatsFinite(t *testing.T) {
+	data := []byte{2, 0xff, 'a'}
+	data = append(data, 0, 0, 0, 0, 0, 0, 0xf8, 0x7f) // NaN
+	got := seedCall(t, meowtest.DecodeFuzz(data, meowtest.FuzzString, meowtest.FuzzFloat)...)
+	if want := `seed("?a", 0.0)`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSeedCallCannotWriteWhatHasNoLiteral(t *testing.T) {
+	for _, v := range []meowrt.Value{
+		meowrt.NewFloat(math.NaN()),
+		meowrt.NewFloat(math.Inf(1)),
+		meowrt.NewString("\xff"),
+		meowrt.NewList(meowrt.NewString("\xfe")),
+		meowrt.NewFunc("f", func(args ...meowrt.Value) meowrt.Value { return meowrt.NewNil() }),
+	} {
+		if call, ok := meowtest.SeedCall(meowrt.NewInt(1), v); ok {
+			t.Errorf("SeedCall(1, %v) = %s, want it cannot be written", v, call)
+		}
+	}
+}
+
+func TestSeedCallEscapesStrings(t *testing.T) {
+	got := seedCall(t, meowrt.NewString("a\nb\t\\c"))
+	if want := `seed("a\nb\t\\c")`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package meowtest
+
+import (
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// Hooks are the functions of a test file that set up and clean up around its
+// tests: before_all and after_all once for the file, before_each and
+// after_each around every test. Any of them may be nil.
+type Hooks struct {
+	BeforeAll, AfterAll   func(...meowrt.Value) meowrt.Value
+	BeforeEach, AfterEach func(...meowrt.Value) meowrt.Value
+}
+
+var hooks Hooks
+
+// SetHooks gives the hooks of the file whose tests Suite is about to run.
+func SetHooks(h Hooks) {
+	hooks = h
+}
+
+// runHook calls a hook, within limit when there is one. A file without the
+// hook passes it.
+func runHook(fn func(...meowrt.Value) meowrt.Value, limit time.Duration) (passed bool, msg string, timedOut bool) {
+	if fn == nil {
+		return true, "", false
+	}
+	return call(fn, limit, "")
+}
+
+// beforeAll runs before_all ahead of n tests. When it fails, it is recorded as
+// a failure of its own and the tests are counted as not run: whatever they
+// were to be run against is not there.
+func beforeAll(opts Options, n int) bool {
+	passed, msg, _ := runHook(hooks.BeforeAll, opts.Timeout)
+	if !passed {
+		recordHook("before_all", msg, opts)
+		skipped += n
+	}
+	return passed
+}
+
+// afterAll runs after_all, which is recorded only when it fails. It runs even
+// when before_all failed, to clean up whatever that got as far as.
+func afterAll(opts Options) bool {
+	passed, msg, _ := runHook(hooks.AfterAll, opts.Timeout)
+	if !passed {
+		recordHook("after_all", msg, opts)
+	}
+	return passed
+}
+
+// recordHook records the failure of a hook run for the file as a whole, as
+// if it were a test: under -json a result needs a run ahead of it.
+func recordHook(name, msg string, opts Options) {
+	if events, ok := output.(*eventWriter); ok {
+		events.start(name)
+	}
+	record(name, false, msg, 0, opts.Verbose)
+}
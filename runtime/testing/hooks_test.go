@@ -0,0 +1,76 @@
+package meowtest_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+// hook makes a hook that notes it ran, and fails when fail is set.
+func hook(ran *[]string, name string, fail bool) func(...meowrt.Value) meowrt.Value {
+	return func(...meowrt.Value) meowrt.Value {
+		*ran = append(*ran, name)
+		if fail {
+			return &meowrt.Furball{Message: "no fixture"}
+		}
+		return meowrt.NewNil()
+	}
+}
+
+func TestHooksRunAroundTheTests(t *testing.T) {
+	setup(t)
+	var ran []string
+	meowtest.SetHooks(meowtest.Hooks{
+		BeforeAll:  hook(&ran, "before_all", false),
+		AfterAll:   hook(&ran, "after_all", false),
+		BeforeEach: hook(&ran, "before_each", false),
+		AfterEach:  hook(&ran, "after_each", false),
+	})
+	meowtest.RunSuite(meowtest.Options{}, cases(&ran, "test_a", "test_b")...)
+	want := []string{
+		"before_all",
+		"before_each", "test_a", "after_each",
+		"before_each", "test_b", "after_each",
+		"after_all",
+	}
+	if !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestAFailingBeforeEachFailsTheTestWithoutRunningIt(t *testing.T) {
+	buf, _ := setup(t)
+	var ran []string
+	meowtest.SetHooks(meowtest.Hooks{
+		BeforeEach: hook(&ran, "before_each", true),
+		AfterEach:  hook(&ran, "after_each", false),
+	})
+	meowtest.RunSuite(meowtest.Options{}, cases(&ran, "test_a")...)
+	if !slices.Equal(ran, []string{"before_each", "after_each"}) {
+		t.Errorf("ran %v, want the test skipped and cleaned up after", ran)
+	}
+	if !strings.Contains(buf.String(), "FAIL: test_a - before_each: no fixture") {
+		t.Errorf("output = %q, want the hook named in the failure", buf.String())
+	}
+}
+
+func TestAFailingBeforeAllRunsNoTests(t *testing.T) {
+	buf, code := setup(t)
+	var ran []string
+	meowtest.SetHooks(meowtest.Hooks{
+		BeforeAll: hook(&ran, "before_all", true),
+		AfterAll:  hook(&ran, "after_all", false),
+	})
+	meowtest.RunSuite(meowtest.Options{}, cases(&ran, "test_a", "test_b")...)
+	meowtest.Report()
+	if !slices.Equal(ran, []string{"before_all", "after_all"}) {
+		t.Errorf("ran %v, want no tests between the hooks", ran)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "FAIL: before_all - no fixture") || !strings.Contains(out, "0 passed, 1 failed, 2 not run") || *code != 1 {
+		t.Errorf("output = %q, exit %d; want before_all failed and the tests not run", out, *code)
+	}
+}
@@ -0,0 +1,253 @@
+package meowtest
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	meowclock "github.com/135yshr/meow/runtime/clock"
+	meowenv "github.com/135yshr/meow/runtime/env"
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowrandom "github.com/135yshr/meow/runtime/random"
+)
+
+// The clock, random and the environment are what has a program do something
+// different from one run to the next. freeze, seed, queue and setenv hold them
+// still for the test under way, and what they change is put back when that
+// test ends: a test cannot leave the clock frozen for the next.
+
+// frozenClock is the time the clock reads while it is frozen; nap and advance
+// move it on rather than wait.
+type frozenClock struct {
+	at time.Time
+}
+
+// frozen is the clock freeze stopped, nil when the clock runs.
+var frozen *frozenClock
+
+// Freeze stops the clock at a time: seconds since the Unix epoch, a stamp such
+// as "2024-02-22T22:22:00Z", or the time it is called when none is given.
+// clock.now and the rest read that time until advance moves it on, and
+// clock.nap returns at once, having moved it on by as long as it was to wait.
+// Returns `catnap`.
+func Freeze(args ...meowrt.Value) meowrt.Value {
+	if f := mockable("freeze"); f != nil {
+		return f
+	}
+	at := time.Now()
+	if len(args) >= 1 {
+		switch v := args[0].(type) {
+		case *meowrt.Int:
+			at = time.Unix(v.Val, 0)
+		case *meowrt.String:
+			t, err := time.Parse(time.RFC3339, v.Val)
+			if err != nil {
+				return &meowrt.Furball{Message: fmt.Sprintf("Hiss! freeze expects a time such as \"2024-02-22T22:22:00Z\", got %q, nya~", v.Val)}
+			}
+			at = t
+		case *meowrt.Furball:
+			return v
+		default:
+			return &meowrt.Furball{Message: fmt.Sprintf("Hiss! freeze expects seconds or a stamp, got %s, nya~", v.Type())}
+		}
+	}
+	freeze(at)
+	return meowrt.NewNil()
+}
+
+// freeze stops the clock at at for the test under way.
+func freeze(at time.Time) *frozenClock {
+	c := &frozenClock{at: at}
+	restore := meowclock.Swap(
+		func() time.Time { return c.at },
+		func(d time.Duration) { c.at = c.at.Add(d) },
+	)
+	outer := frozen
+	frozen = c
+	running.cleanups = append(running.cleanups, func() {
+		restore()
+		frozen = outer
+	})
+	return c
+}
+
+// Advance moves the frozen clock on by a number of milliseconds, freezing it
+// first when it is not. Returns `catnap`.
+func Advance(args ...meowrt.Value) meowrt.Value {
+	if f := mockable("advance"); f != nil {
+		return f
+	}
+	if len(args) < 1 {
+		return &meowrt.Furball{Message: "Hiss! advance expects a number of milliseconds, nya~"}
+	}
+	ms, f := meowrt.TryAsInt(args[0])
+	if f != nil {
+		return f
+	}
+	if ms < 0 {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! advance cannot turn the clock back, got %d, nya~", ms)}
+	}
+	c := frozen
+	if c == nil {
+		c = freeze(time.Now())
+	}
+	c.at = c.at.Add(time.Duration(ms) * time.Millisecond)
+	return meowrt.NewNil()
+}
+
+// Seed has random draw the same values every time the test runs, the ones
+// the seed given makes: roll, drift, pick and tuft alike. Returns `catnap`.
+func Seed(args ...meowrt.Value) meowrt.Value {
+	if f := mockable("seed"); f != nil {
+		return f
+	}
+	if len(args) < 1 {
+		return &meowrt.Furball{Message: "Hiss! seed expects an Int, nya~"}
+	}
+	n, f := meowrt.TryAsInt(args[0])
+	if f != nil {
+		return f
+	}
+	r := rand.New(rand.NewPCG(uint64(n), 0))
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], uint64(n))
+	stream := rand.NewChaCha8(key)
+	restore := meowrandom.Swap(r.Int64N, r.Float64, stream.Read)
+	running.cleanups = append(running.cleanups, restore)
+	return meowrt.NewNil()
+}
+
+// queuedDraws are the values queue has given a test for random to draw, each
+// kind in the order it was given.
+type queuedDraws struct {
+	ints   []int64
+	floats []float64
+	bytes  [][]byte
+}
+
+// Queue gives random the values to draw next, in order: an Int is what roll
+// gives, or the index of what pick does; a Float is what drift gives; and a
+// String of hex digits is what tuft does. Once a kind's values are used up,
+// random draws as it did before. Returns `catnap`.
+func Queue(args ...meowrt.Value) meowrt.Value {
+	if f := mockable("queue"); f != nil {
+		return f
+	}
+	if len(args) < 1 {
+		return &meowrt.Furball{Message: "Hiss! queue expects a List of values, nya~"}
+	}
+	values, ok := args[0].(*meowrt.List)
+	if !ok {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! queue expects a List of values, got %s, nya~", args[0].Type())}
+	}
+	var add queuedDraws
+	for _, v := range values.Items {
+		switch v := v.(type) {
+		case *meowrt.Int:
+			if v.Val < 0 {
+				return &meowrt.Furball{Message: fmt.Sprintf("Hiss! queue cannot have roll give %d, which is less than 0, nya~", v.Val)}
+			}
+			add.ints = append(add.ints, v.Val)
+		case *meowrt.Float:
+			if v.Val < 0 || v.Val >= 1 {
+				return &meowrt.Furball{Message: fmt.Sprintf("Hiss! queue cannot have drift give %v, which is outside [0, 1), nya~", v.Val)}
+			}
+			add.floats = append(add.floats, v.Val)
+		case *meowrt.String:
+			b, err := hex.DecodeString(v.Val)
+			if err != nil || len(b) == 0 {
+				return &meowrt.Furball{Message: fmt.Sprintf("Hiss! queue expects hex digits for tuft, got %q, nya~", v.Val)}
+			}
+			add.bytes = append(add.bytes, b)
+		default:
+			return &meowrt.Furball{Message: fmt.Sprintf("Hiss! queue expects Ints, Floats and Strings, got %s, nya~", v.Type())}
+		}
+	}
+
+	t := running
+	if t.queued == nil {
+		t.queued = &queuedDraws{}
+		q := t.queued
+		intN, float, bytes := meowrandom.Current()
+		restore := meowrandom.Swap(
+			func(n int64) int64 {
+				if len(q.ints) == 0 {
+					return intN(n)
+				}
+				v := q.ints[0]
+				q.ints = q.ints[1:]
+				if v >= n {
+					panic(testFailure{message: fmt.Sprintf("queue: %d was queued, for a draw of one below %d", v, n)})
+				}
+				return v
+			},
+			func() float64 {
+				if len(q.floats) == 0 {
+					return float()
+				}
+				v := q.floats[0]
+				q.floats = q.floats[1:]
+				return v
+			},
+			func(b []byte) (int, error) {
+				if len(q.bytes) == 0 {
+					return bytes(b)
+				}
+				v := q.bytes[0]
+				q.bytes = q.bytes[1:]
+				if len(v) != len(b) {
+					panic(testFailure{message: fmt.Sprintf("queue: %d bytes were queued, but tuft wanted %d", len(v), len(b))})
+				}
+				return copy(b, v), nil
+			},
+		)
+		t.cleanups = append(t.cleanups, func() {
+			restore()
+			t.queued = nil
+		})
+	}
+	t.queued.ints = append(t.queued.ints, add.ints...)
+	t.queued.floats = append(t.queued.floats, add.floats...)
+	t.queued.bytes = append(t.queued.bytes, add.bytes...)
+	return meowrt.NewNil()
+}
+
+// Setenv has env.hunt and the rest see a variable as set to a value, or as
+// unset when the value is `catnap`. The process's own environment is left as
+// it is. Returns `catnap`.
+func Setenv(args ...meowrt.Value) meowrt.Value {
+	if f := mockable("setenv"); f != nil {
+		return f
+	}
+	if len(args) < 2 {
+		return &meowrt.Furball{Message: "Hiss! setenv expects 2 arguments (name, value), nya~"}
+	}
+	name, ok := args[0].(*meowrt.String)
+	if !ok || name.Val == "" {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! setenv expects a name, got %s, nya~", show(args[0]))}
+	}
+	var restore func()
+	switch v := args[1].(type) {
+	case *meowrt.String:
+		restore = meowenv.Overlay(name.Val, v.Val, true)
+	case *meowrt.NilValue:
+		restore = meowenv.Overlay(name.Val, "", false)
+	case *meowrt.Furball:
+		return v
+	default:
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! setenv expects a String or catnap, got %s, nya~", v.Type())}
+	}
+	running.cleanups = append(running.cleanups, restore)
+	return meowrt.NewNil()
+}
+
+// mockable is the Furball for calling fn outside a test, where there would be
+// no end of the test to undo it at.
+func mockable(fn string) meowrt.Value {
+	if running == nil {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! %s is for inside a test, which undoes it when it ends, nya~", fn)}
+	}
+	return nil
+}
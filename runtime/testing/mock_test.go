@@ -0,0 +1,119 @@
+package meowtest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/clock"
+	"github.com/135yshr/meow/runtime/env"
+	"github.com/135yshr/meow/runtime/meowrt"
+	"github.com/135yshr/meow/runtime/random"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+// inTest runs fn as a test, and gives back what it printed.
+func inTest(t *testing.T, fn func() meowrt.Value) string {
+	t.Helper()
+	buf, _ := setup(t)
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_mock", Fn: func(...meowrt.Value) meowrt.Value {
+		return fn()
+	}})
+	return buf.String()
+}
+
+func TestFreezeHoldsTheClockUntilTheTestEnds(t *testing.T) {
+	var stamps []string
+	out := inTest(t, func() meowrt.Value {
+		meowtest.Freeze(meowrt.NewString("2024-02-22T22:22:00Z"))
+		stamps = append(stamps, clock.Stamp().String())
+		clock.Nap(meowrt.NewInt(60_000))
+		stamps = append(stamps, clock.Stamp().String())
+		meowtest.Advance(meowrt.NewInt(1_000))
+		stamps = append(stamps, clock.Stamp().String())
+		return meowrt.NewNil()
+	})
+	if !strings.Contains(out, "PASS: test_mock") {
+		t.Fatalf("output = %q", out)
+	}
+	want := []string{"2024-02-22T22:22:00Z", "2024-02-22T22:23:00Z", "2024-02-22T22:23:01Z"}
+	if strings.Join(stamps, " ") != strings.Join(want, " ") {
+		t.Errorf("stamps = %v, want %v", stamps, want)
+	}
+	if got := clock.Stamp().String(); strings.HasPrefix(got, "2024-02-22") {
+		t.Errorf("stamp after the test = %s, want the clock running again", got)
+	}
+}
+
+func TestSeedDrawsTheSameEachTime(t *testing.T) {
+	draw := func() string {
+		var got string
+		inTest(t, func() meowrt.Value {
+			meowtest.Seed(meowrt.NewInt(42))
+			got = random.Roll(meowrt.NewInt(1_000_000)).String() + " " + random.Tuft(meowrt.NewInt(4)).String()
+			return meowrt.NewNil()
+		})
+		return got
+	}
+	if a, b := draw(), draw(); a != b {
+		t.Errorf("seed 42 drew %q and then %q", a, b)
+	}
+}
+
+func TestQueueGivesItsValuesInOrder(t *testing.T) {
+	var got []string
+	out := inTest(t, func() meowrt.Value {
+		meowtest.Queue(meowrt.NewList(meowrt.NewInt(3), meowrt.NewFloat(0.5), meowrt.NewString("beef")))
+		meowtest.Queue(meowrt.NewList(meowrt.NewInt(1)))
+		got = append(got,
+			random.Roll(meowrt.NewInt(10)).String(),
+			random.Drift().String(),
+			random.Tuft(meowrt.NewInt(2)).String(),
+			random.Pick(meowrt.NewList(meowrt.NewString("Tama"), meowrt.NewString("Mike"))).String(),
+		)
+		return meowrt.NewNil()
+	})
+	if !strings.Contains(out, "PASS: test_mock") {
+		t.Fatalf("output = %q", out)
+	}
+	if want := "3 0.5 beef Mike"; strings.Join(got, " ") != want {
+		t.Errorf("draws = %v, want %s", got, want)
+	}
+}
+
+func TestQueueFailsTheTestOnADrawItCannotGive(t *testing.T) {
+	out := inTest(t, func() meowrt.Value {
+		meowtest.Queue(meowrt.NewList(meowrt.NewInt(30)))
+		return random.Roll(meowrt.NewInt(10))
+	})
+	if !strings.Contains(out, "FAIL: test_mock - queue: 30 was queued, for a draw of one below 10") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestSetenvLastsForTheTest(t *testing.T) {
+	t.Setenv("MEOW_MOCK_HOME", "/home/tama")
+	var during []string
+	inTest(t, func() meowrt.Value {
+		meowtest.Setenv(meowrt.NewString("MEOW_MOCK_CAT"), meowrt.NewString("Mike"))
+		meowtest.Setenv(meowrt.NewString("MEOW_MOCK_HOME"), meowrt.NewNil())
+		during = append(during, env.Hunt(meowrt.NewString("MEOW_MOCK_CAT")).String(), env.Sniffed(meowrt.NewString("MEOW_MOCK_HOME")).String())
+		return meowrt.NewNil()
+	})
+	if want := "Mike false"; strings.Join(during, " ") != want {
+		t.Errorf("during the test = %v, want %s", during, want)
+	}
+	if got := env.Hunt(meowrt.NewString("MEOW_MOCK_CAT")); got.String() != "catnap" {
+		t.Errorf("MEOW_MOCK_CAT after the test = %v, want it unset again", got)
+	}
+	if got := env.Hunt(meowrt.NewString("MEOW_MOCK_HOME")); got.String() != "/home/tama" {
+		t.Errorf("MEOW_MOCK_HOME after the test = %v, want it back", got)
+	}
+}
+
+func TestMocksAreForInsideATest(t *testing.T) {
+	setup(t)
+	f, ok := meowtest.Freeze().(*meowrt.Furball)
+	if !ok || !strings.Contains(f.Message, "freeze is for inside a test") {
+		t.Errorf("freeze() = %v, want a Furball", f)
+	}
+}
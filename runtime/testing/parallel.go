@@ -0,0 +1,193 @@
+package meowtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/135yshr/meow/runtime/coverage"
+)
+
+// A parallel test runs in a copy of the test binary of its own, told which
+// test it is there for by envOnly. What it prints is then its own, kept
+// together however many run at once: nya writes to the process's stdout, and
+// in one process the output of tests running side by side could only be
+// interleaved. The copy runs the file's top level again on its way to the
+// test, and what that prints is dropped; childStart marks where the test
+// begins, and childCovered is the coverage it had, handed back to be counted
+// with the rest.
+const (
+	childStart   = "\x1emeow-parallel\n"
+	childCovered = "\x1emeow-covered"
+)
+
+// rawOutput is where output goes unchanged, whether or not it is being sent
+// as events.
+func rawOutput(events *eventWriter) io.Writer {
+	if events != nil {
+		return events.w
+	}
+	return output
+}
+
+// runOnly runs the one test a copy of the binary is there for, and exits with
+// whether it passed. The summary is the parent's to give.
+func runOnly(opts Options, events *eventWriter, cases []Case) {
+	for _, c := range cases {
+		if c.Name != opts.Only {
+			continue
+		}
+		fmt.Fprint(rawOutput(events), childStart)
+		// The top level has run here as it did in the parent, which counted
+		// it; only what runs from here on is the test's to hand back.
+		before := make([]int64, len(coverage.Blocks()))
+		for id, b := range coverage.Blocks() {
+			before[id] = b.Count
+		}
+		beforeTaken := make([]int64, len(coverage.Branches()))
+		for id, b := range coverage.Branches() {
+			beforeTaken[id] = b.Count
+		}
+		// The copy is a file's run of its own, one test long, so the hooks
+		// for the file run in it too.
+		passed := false
+		if beforeAll(opts, 1) {
+			passed, _ = runCase(opts, events, c)
+		}
+		passed = afterAll(opts) && passed
+		if events != nil {
+			events.flush()
+		}
+		var hit []string
+		for id, b := range coverage.Blocks() {
+			if n := b.Count - before[id]; n > 0 {
+				hit = append(hit, fmt.Sprintf("%d:%d", id, n))
+			}
+		}
+		for id, b := range coverage.Branches() {
+			if n := b.Count - beforeTaken[id]; n > 0 {
+				hit = append(hit, fmt.Sprintf("b%d:%d", id, n))
+			}
+		}
+		if len(hit) > 0 {
+			fmt.Fprintf(rawOutput(events), "%s %s\n", childCovered, strings.Join(hit, " "))
+		}
+		if passed {
+			exitFn(0)
+		} else {
+			exitFn(1)
+		}
+		return
+	}
+	fmt.Fprintf(output, "Hiss! There is no test called %s, nya~\n", opts.Only)
+	exitFn(2)
+}
+
+// runParallel runs tests each in a copy of the binary, as many at once as
+// there are CPUs, and writes each one's output whole as it ends.
+func runParallel(opts Options, events *eventWriter, cases []Case) {
+	self, err := os.Executable()
+	if err != nil {
+		for _, c := range cases {
+			record(c.Name, false, fmt.Sprintf("Hiss! Cannot run a parallel test, nya~: %v", err), 0, opts.Verbose)
+		}
+		return
+	}
+
+	var (
+		mu      sync.Mutex
+		stopped bool
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, c := range cases {
+		slots <- struct{}{}
+		mu.Lock()
+		if stopped {
+			skipped++
+			mu.Unlock()
+			<-slots
+			continue
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			out, passed, err := runChild(self, c.Name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			test, covered, taken, ok := splitChild(out)
+			if !ok {
+				// It never got as far as the test, so nothing has said how
+				// the test went; this has to.
+				if events != nil {
+					events.start(c.Name)
+				}
+				record(c.Name, false, fmt.Sprintf("its process failed before it began: %v\n%s", err, out), 0, opts.Verbose)
+			} else {
+				rawOutput(events).Write(test)
+				results = append(results, testResult{name: c.Name, passed: passed})
+				for id, n := range covered {
+					coverage.Add(id, n)
+				}
+				for id, n := range taken {
+					coverage.AddBranch(id, n)
+				}
+			}
+			if !passed && opts.FailFast {
+				stopped = true
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runChild runs the test name in a copy of the binary at self, and gives back
+// all it wrote, and whether it passed.
+func runChild(self, name string) ([]byte, bool, error) {
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), envOnly+"="+name)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.Bytes(), err == nil, err
+}
+
+// splitChild takes apart what a copy of the binary wrote: the test's own
+// output, after the file's top level, and how many times it ran each coverage
+// block it reached and took each branch, the branches' IDs marked with a b.
+func splitChild(out []byte) (test []byte, covered, taken map[int]int64, ok bool) {
+	_, test, ok = bytes.Cut(out, []byte(childStart))
+	if !ok {
+		return nil, nil, nil, false
+	}
+	if i := bytes.LastIndex(test, []byte(childCovered)); i >= 0 {
+		line, _, _ := bytes.Cut(test[i+len(childCovered):], []byte("\n"))
+		covered = make(map[int]int64)
+		taken = make(map[int]int64)
+		for _, f := range strings.Fields(string(line)) {
+			id, n, _ := strings.Cut(f, ":")
+			counts := covered
+			if branch, ok := strings.CutPrefix(id, "b"); ok {
+				id, counts = branch, taken
+			}
+			block, err := strconv.Atoi(id)
+			runs, nerr := strconv.ParseInt(n, 10, 64)
+			if err == nil && nerr == nil {
+				counts[block] += runs
+			}
+		}
+		test = test[:i]
+	}
+	return test, covered, taken, true
+}
@@ -0,0 +1,208 @@
+package meowtest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// The environment `meow test` says where the file's snapshots are in, which
+// file it is, and whether to rewrite them.
+const (
+	envSnapshots = "MEOW_TEST_SNAPSHOTS"
+	envTestFile  = "MEOW_TEST_FILE"
+	envUpdate    = "MEOW_TEST_UPDATE"
+)
+
+// Snapshot compares a value with the snapshot of it the test took before, in
+// testdata/__snapshots__/<file>/<test>.snap beside the test file, <file> being
+// its name without .nyan, so that two files in one directory can each have a
+// test_parse. The first time there is none, and the value is written as the
+// snapshot; after that, a value that differs fails the test with a diff of
+// the two, until `meow test -update` writes it as the snapshot instead. A
+// function is called, and what it prints is what is compared. The second
+// snapshot a test takes is <test>.2.snap, and so on; a name, given second,
+// names the file instead.
+// Returns `catnap`, or a Furball when the value differs.
+func Snapshot(args ...meowrt.Value) meowrt.Value {
+	if len(args) < 1 {
+		return &meowrt.Furball{Message: "Hiss! snapshot expects a value, nya~"}
+	}
+	if f, ok := args[0].(*meowrt.Furball); ok && !f.Handled {
+		return f
+	}
+	t := running
+	if t == nil {
+		return &meowrt.Furball{Message: "Hiss! snapshot is for inside a test, where it has a name to keep the snapshot under, nya~"}
+	}
+
+	got := ""
+	if fn, ok := args[0].(*meowrt.Func); ok {
+		out, f := captureOutput(fn)
+		if f != nil {
+			return f
+		}
+		got = out
+	} else {
+		got = snapshotText(args[0], "")
+	}
+
+	t.snapshots++
+	name := t.name
+	switch {
+	case len(args) >= 2:
+		name += "." + args[1].String()
+	case t.snapshots > 1:
+		name += fmt.Sprintf(".%d", t.snapshots)
+	}
+	file := snapshotFile(name)
+	if of := os.Getenv(envTestFile); of != "" {
+		file = filepath.Join(safeName(of), file)
+	}
+	path := filepath.Join(snapshotDir(), file)
+	rel := filepath.Join("testdata", "__snapshots__", file)
+
+	want, err := os.ReadFile(path)
+	if err == nil && string(want) == got {
+		return meowrt.NewNil()
+	}
+	if os.Getenv(envUpdate) != "" || os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return &meowrt.Furball{Message: fmt.Sprintf("Hiss! Cannot write %s, nya~: %v", rel, err)}
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			return &meowrt.Furball{Message: fmt.Sprintf("Hiss! Cannot write %s, nya~: %v", rel, err)}
+		}
+		fmt.Fprintf(output, "  %s  wrote %s\n", strings.Repeat("  ", t.depth), rel)
+		return meowrt.NewNil()
+	}
+	if err != nil {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! Cannot read %s, nya~: %v", rel, err)}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "got differs from %s (meow test -update rewrites it):", rel)
+	for _, line := range diffLines(got, string(want)) {
+		b.WriteString("\n        ")
+		b.WriteString(line)
+	}
+	return assertionFailure(b.String())
+}
+
+// snapshotDir is where the file's snapshots are: beside it when meow test
+// says where that is, and beside where the binary runs when it does not.
+func snapshotDir() string {
+	if dir := os.Getenv(envSnapshots); dir != "" {
+		return dir
+	}
+	return filepath.Join("testdata", "__snapshots__")
+}
+
+// unsafeInName is what a snapshot's file name cannot have of a test's name.
+var unsafeInName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// snapshotFile is the file, in snapshotDir, the snapshot called name is kept
+// in. A subtest's snapshots are kept in a directory named for the test it is
+// part of.
+func snapshotFile(name string) string {
+	return safeName(name) + ".snap"
+}
+
+// safeName is name made fit to be a path, each part between its slashes a
+// file name.
+func safeName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		p = unsafeInName.ReplaceAllString(p, "_")
+		if strings.Trim(p, ".") == "" {
+			p = "_"
+		}
+		parts[i] = p
+	}
+	return filepath.Join(parts...)
+}
+
+// captureOutput calls fn and gives back what it printed, or the Furball it
+// failed with.
+func captureOutput(fn *meowrt.Func) (string, meowrt.Value) {
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", &meowrt.Furball{Message: fmt.Sprintf("Hiss! cannot create pipe, nya~: %v", err)}
+	}
+	os.Stdout = w
+	captured := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		captured <- buf.String()
+	}()
+	passed, msg, _ := call(fn.Call, 0, "panic: ")
+	w.Close()
+	os.Stdout = oldStdout
+	out := <-captured
+	r.Close()
+	if !passed {
+		return "", assertionFailure(msg)
+	}
+	return out, nil
+}
+
+// snapshotText is how a value is written in a snapshot: a string as it is, and
+// a litter, basket or kitty with an element, key or field to a line, so that a
+// change to one is a change to a line of the diff. indent starts each line
+// inside the value, newline and all; "" is the value as a whole.
+func snapshotText(v meowrt.Value, indent string) string {
+	if indent == "" {
+		if s, ok := v.(*meowrt.String); ok {
+			return s.Val
+		}
+		return snapshotText(v, "\n") + "\n"
+	}
+	inner := indent + "  "
+	var b strings.Builder
+	switch v := v.(type) {
+	case *meowrt.List:
+		if len(v.Items) == 0 {
+			return "[]"
+		}
+		b.WriteString("[")
+		for _, item := range v.Items {
+			b.WriteString(inner + snapshotText(item, inner) + ",")
+		}
+		b.WriteString(indent + "]")
+	case *meowrt.Map:
+		if len(v.Items) == 0 {
+			return "{}"
+		}
+		b.WriteString("{")
+		for _, k := range sortedKeys(v.Items) {
+			fmt.Fprintf(&b, "%s%q: %s,", inner, k, snapshotText(v.Items[k], inner))
+		}
+		b.WriteString(indent + "}")
+	case *meowrt.Kitty:
+		b.WriteString(v.TypeName + "{")
+		for _, name := range v.FieldNames {
+			fmt.Fprintf(&b, "%s%s: %s,", inner, name, snapshotText(v.Fields[name], inner))
+		}
+		b.WriteString(indent + "}")
+	default:
+		return show(v)
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]meowrt.Value) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package meowtest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+// snapshotOf runs a test that snapshots v, and gives back what it wrote.
+func snapshotOf(t *testing.T, v meowrt.Value) string {
+	t.Helper()
+	buf, _ := setup(t)
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_snap", Fn: func(...meowrt.Value) meowrt.Value {
+		return meowtest.Snapshot(v)
+	}})
+	return buf.String()
+}
+
+func TestASnapshotIsWrittenThenComparedWith(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MEOW_TEST_SNAPSHOTS", dir)
+	cats := func(age int64) meowrt.Value {
+		return meowrt.NewList(cat("Tama", 3), cat("Mike", age))
+	}
+
+	if out := snapshotOf(t, cats(2)); !strings.Contains(out, "wrote testdata/__snapshots__/test_snap.snap") || !strings.Contains(out, "PASS: test_snap") {
+		t.Fatalf("output = %q, want the snapshot written", out)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, "test_snap.snap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  Cat{\n    name: \"Tama\",\n    age: 3,\n  },\n  Cat{\n    name: \"Mike\",\n    age: 2,\n  },\n]\n"
+	if string(written) != want {
+		t.Errorf("snapshot =\n%s\nwant\n%s", written, want)
+	}
+
+	if out := snapshotOf(t, cats(2)); !strings.Contains(out, "PASS: test_snap") || strings.Contains(out, "wrote") {
+		t.Errorf("output = %q, want the same value to pass", out)
+	}
+	out := snapshotOf(t, cats(4))
+	if !strings.Contains(out, "FAIL: test_snap - got differs from testdata/__snapshots__/test_snap.snap") ||
+		!strings.Contains(out, "-     age: 2,\n        +     age: 4,") {
+		t.Errorf("output = %q, want the change shown", out)
+	}
+
+	t.Setenv("MEOW_TEST_UPDATE", "1")
+	if out := snapshotOf(t, cats(4)); !strings.Contains(out, "PASS: test_snap") || !strings.Contains(out, "wrote") {
+		t.Errorf("output = %q, want -update to rewrite the snapshot", out)
+	}
+}
+
+func TestASubtestsSnapshotIsUnderItsTest(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MEOW_TEST_SNAPSHOTS", dir)
+	setup(t)
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_snap", Fn: func(...meowrt.Value) meowrt.Value {
+		return meowtest.Run(meowrt.NewString("big cat"), meowrt.NewFunc("sub", func(...meowrt.Value) meowrt.Value {
+			meowtest.Snapshot(meowrt.NewString("first"))
+			return meowtest.Snapshot(meowrt.NewString("second"))
+		}))
+	}})
+	for file, want := range map[string]string{"test_snap/big_cat.snap": "first", "test_snap/big_cat.2.snap": "second"} {
+		got, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", file, got, err, want)
+		}
+	}
+}
+
+func TestEachTestFileHasSnapshotsOfItsOwn(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MEOW_TEST_SNAPSHOTS", dir)
+	for _, file := range []string{"parse_test", "format_test"} {
+		t.Setenv("MEOW_TEST_FILE", file)
+		out := snapshotOf(t, meowrt.NewString(file))
+		if !strings.Contains(out, "wrote testdata/__snapshots__/"+file+"/test_snap.snap") {
+			t.Errorf("output = %q, want the snapshot under %s", out, file)
+		}
+	}
+	for _, file := range []string{"parse_test", "format_test"} {
+		got, err := os.ReadFile(filepath.Join(dir, file, "test_snap.snap"))
+		if err != nil || string(got) != file {
+			t.Errorf("%s's snapshot = %q, %v; want its own", file, got, err)
+		}
+	}
+}
+
+func TestASnapshotNeedsATest(t *testing.T) {
+	setup(t)
+	if _, ok := meowtest.Snapshot(meowrt.NewInt(1)).(*meowrt.Furball); !ok {
+		t.Error("snapshot outside a test did not fail")
+	}
+}
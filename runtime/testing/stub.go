@@ -0,0 +1,177 @@
+package meowtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	meowjson "github.com/135yshr/meow/runtime/json"
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// StubHandle is the type name of what stub gives back.
+const StubHandle = "Stub"
+
+// Stub starts a local HTTP server for a test to point http.pounce and the rest
+// at, rather than at the real thing. It is given a basket of routes, each a
+// path, or a method and a path, "GET /cats", with what to answer:
+//
+//	"text"                                    200, with the text as the body
+//	{"status": 201, "body": ..., "headers": {...}}   all of a response; a
+//	                                          basket or litter body is JSON
+//	paw(req) { ... }                          a handler, given the request,
+//	                                          answering with either of those
+//
+// A request no route matches is answered 404. The handle it gives back has
+// the server's address and what it was sent:
+//
+//	s.url           the address, http://127.0.0.1:port, with no slash after
+//	s.requests()    a litter of the requests so far, each a basket of method,
+//	                path, query, headers and body
+//	s.close()       stop the server
+//
+// A stub started inside a test is stopped when the test ends; one started
+// elsewhere, in before_all, say, is there until the tests are done.
+func Stub(args ...meowrt.Value) meowrt.Value {
+	if len(args) < 1 {
+		return &meowrt.Furball{Message: "Hiss! stub expects a basket of routes, nya~"}
+	}
+	if f, ok := args[0].(*meowrt.Furball); ok && !f.Handled {
+		return f
+	}
+	routes, ok := args[0].(*meowrt.Map)
+	if !ok {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! stub expects a basket of routes, got %s, nya~", args[0].Type())}
+	}
+
+	s := &stubServer{routes: routes.Items}
+	srv := httptest.NewServer(s)
+	if running != nil {
+		running.cleanups = append(running.cleanups, srv.Close)
+	}
+
+	nothing := meowrt.NewNil()
+	return &meowrt.Kitty{
+		TypeName:   StubHandle,
+		FieldNames: []string{"url", "requests", "close"},
+		Fields: map[string]meowrt.Value{
+			"url": meowrt.NewString(srv.URL),
+			"requests": meowrt.NewFuncWithArity("requests", 0, func(...meowrt.Value) meowrt.Value {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				return meowrt.NewList(append([]meowrt.Value(nil), s.received...)...)
+			}),
+			"close": meowrt.NewFuncWithArity("close", 0, func(...meowrt.Value) meowrt.Value {
+				srv.Close()
+				return nothing
+			}),
+		},
+	}
+}
+
+// stubServer answers requests from the routes a stub was given. The requests
+// are handled one at a time: a handler is Meow code, which is written to run
+// on its own.
+type stubServer struct {
+	routes   map[string]meowrt.Value
+	mu       sync.Mutex
+	received []meowrt.Value
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	headers := make(map[string]meowrt.Value, len(r.Header))
+	for k, values := range r.Header {
+		headers[k] = meowrt.NewString(strings.Join(values, ", "))
+	}
+	req := meowrt.NewMap(map[string]meowrt.Value{
+		"method":  meowrt.NewString(r.Method),
+		"path":    meowrt.NewString(r.URL.Path),
+		"query":   meowrt.NewString(r.URL.RawQuery),
+		"headers": meowrt.NewMap(headers),
+		"body":    meowrt.NewString(string(body)),
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, req)
+
+	route, ok := s.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		route, ok = s.routes[r.URL.Path]
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("no stub for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+	if fn, isFunc := route.(*meowrt.Func); isFunc {
+		var answer meowrt.Value
+		passed, msg, _ := call(func(...meowrt.Value) meowrt.Value {
+			answer = fn.Call(req)
+			return answer
+		}, 0, "panic: ")
+		if !passed {
+			http.Error(w, "stub handler failed: "+msg, http.StatusInternalServerError)
+			return
+		}
+		route = answer
+	}
+	writeStubResponse(w, route)
+}
+
+// writeStubResponse answers with what a route or its handler says to: a
+// string as the body, or a basket of status, body and headers.
+func writeStubResponse(w http.ResponseWriter, v meowrt.Value) {
+	status := http.StatusOK
+	var body meowrt.Value = v
+	if m, ok := v.(*meowrt.Map); ok {
+		body = meowrt.NewString("")
+		if s, found := m.Get("status"); found {
+			if n, ok := s.(*meowrt.Int); ok {
+				status = int(n.Val)
+			}
+		}
+		if h, found := m.Get("headers"); found {
+			if hm, ok := h.(*meowrt.Map); ok {
+				for k, hv := range hm.Items {
+					w.Header().Set(k, text(hv))
+				}
+			}
+		}
+		if b, found := m.Get("body"); found {
+			body = b
+		}
+	}
+
+	out := ""
+	switch b := body.(type) {
+	case *meowrt.String:
+		out = b.Val
+	case *meowrt.Map, *meowrt.List:
+		wound := meowjson.Wind(b)
+		if f, ok := wound.(*meowrt.Furball); ok {
+			http.Error(w, "stub body: "+f.Message, http.StatusInternalServerError)
+			return
+		}
+		out = wound.(*meowrt.String).Val
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	case *meowrt.NilValue:
+	default:
+		out = b.String()
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, out)
+}
+
+// text is a header's value as it is sent.
+func text(v meowrt.Value) string {
+	if s, ok := v.(*meowrt.String); ok {
+		return s.Val
+	}
+	return v.String()
+}
@@ -0,0 +1,122 @@
+package meowtest_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+func stubURL(t *testing.T, stub meowrt.Value) string {
+	t.Helper()
+	k, ok := stub.(*meowrt.Kitty)
+	if !ok {
+		t.Fatalf("stub = %v, want a Stub", stub)
+	}
+	return k.Fields["url"].(*meowrt.String).Val
+}
+
+func fetch(t *testing.T, method, url, body string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Cat", "Tama")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, string(b)
+}
+
+func TestStubAnswersFromItsRoutes(t *testing.T) {
+	setup(t)
+	stub := meowtest.Stub(meowrt.NewMap(map[string]meowrt.Value{
+		"GET /cats": meowrt.NewString("Tama"),
+		"/cats":     meowrt.NewString("any method"),
+		"/created": meowrt.NewMap(map[string]meowrt.Value{
+			"status":  meowrt.NewInt(201),
+			"body":    meowrt.NewMap(map[string]meowrt.Value{"id": meowrt.NewInt(7)}),
+			"headers": meowrt.NewMap(map[string]meowrt.Value{"Location": meowrt.NewString("/cats/7")}),
+		}),
+	}))
+	url := stubURL(t, stub)
+	defer stub.(*meowrt.Kitty).Fields["close"].(*meowrt.Func).Call()
+
+	if status, _, body := fetch(t, "GET", url+"/cats", ""); status != 200 || body != "Tama" {
+		t.Errorf("GET /cats = %d %q, want 200 \"Tama\"", status, body)
+	}
+	if _, _, body := fetch(t, "DELETE", url+"/cats", ""); body != "any method" {
+		t.Errorf("DELETE /cats = %q, want the route for any method", body)
+	}
+	status, header, body := fetch(t, "POST", url+"/created", "")
+	if status != 201 || body != `{"id":7}` || header.Get("Location") != "/cats/7" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("POST /created = %d %v %q, want 201 with a JSON body and the headers", status, header, body)
+	}
+	if status, _, body := fetch(t, "GET", url+"/dogs", ""); status != 404 || !strings.Contains(body, "no stub for GET /dogs") {
+		t.Errorf("GET /dogs = %d %q, want a 404 naming it", status, body)
+	}
+}
+
+func TestStubHandlerIsGivenTheRequest(t *testing.T) {
+	setup(t)
+	stub := meowtest.Stub(meowrt.NewMap(map[string]meowrt.Value{
+		"POST /echo": meowrt.NewFunc("handler", func(args ...meowrt.Value) meowrt.Value {
+			req := args[0].(*meowrt.Map)
+			body, _ := req.Get("body")
+			return meowrt.NewMap(map[string]meowrt.Value{"status": meowrt.NewInt(202), "body": body})
+		}),
+		"/broken": meowrt.NewFunc("handler", func(...meowrt.Value) meowrt.Value {
+			return &meowrt.Furball{Message: "Hiss! no cats here, nya~"}
+		}),
+	}))
+	url := stubURL(t, stub)
+	defer stub.(*meowrt.Kitty).Fields["close"].(*meowrt.Func).Call()
+
+	if status, _, body := fetch(t, "POST", url+"/echo?n=1", "nyan"); status != 202 || body != "nyan" {
+		t.Errorf("POST /echo = %d %q, want 202 \"nyan\"", status, body)
+	}
+	if status, _, body := fetch(t, "GET", url+"/broken", ""); status != 500 || !strings.Contains(body, "no cats here") {
+		t.Errorf("GET /broken = %d %q, want a 500 with the Furball", status, body)
+	}
+
+	reqs := stub.(*meowrt.Kitty).Fields["requests"].(*meowrt.Func).Call().(*meowrt.List)
+	if len(reqs.Items) != 2 {
+		t.Fatalf("requests() = %v, want the 2 requests", reqs)
+	}
+	first := reqs.Items[0].(*meowrt.Map)
+	for key, want := range map[string]string{"method": "POST", "path": "/echo", "query": "n=1", "body": "nyan"} {
+		if got, _ := first.Get(key); got.String() != want {
+			t.Errorf("request[%q] = %v, want %q", key, got, want)
+		}
+	}
+	headers, _ := first.Get("headers")
+	if got, _ := headers.(*meowrt.Map).Get("X-Cat"); got.String() != "Tama" {
+		t.Errorf("request headers = %v, want X-Cat: Tama", headers)
+	}
+}
+
+func TestStubClosesWhenTheTestEnds(t *testing.T) {
+	setup(t)
+	var url string
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_stub", Fn: func(...meowrt.Value) meowrt.Value {
+		url = stubURL(t, meowtest.Stub(meowrt.NewMap(map[string]meowrt.Value{"/": meowrt.NewString("up")})))
+		return meowrt.NewNil()
+	}})
+	if _, err := http.Get(url); err == nil {
+		t.Errorf("the stub at %s still answers after its test ended", url)
+	}
+}
+
+func TestStubRejectsWhatIsNotABasket(t *testing.T) {
+	f, ok := meowtest.Stub(meowrt.NewString("/cats")).(*meowrt.Furball)
+	if !ok || !strings.Contains(f.Message, "basket of routes") {
+		t.Errorf("stub(\"/cats\") = %v, want a Furball", f)
+	}
+}
@@ -0,0 +1,207 @@
+package meowtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// runningTest is the test under way, for run and table called inside it to
+// make their functions subtests of. A subtest is named after the tests it is
+// inside, test_parse/empty/trailing_space, and is reported indented under
+// them; it is not counted in the summary on its own, but its failing fails the
+// test it is part of.
+type runningTest struct {
+	name    string
+	depth   int
+	verbose bool
+	parent  *runningTest
+	// subtests and failed count the subtests that have ended, and those of
+	// them that failed.
+	subtests, failed int
+	// snapshots counts the snapshots the test has taken, to name the next.
+	snapshots int
+	// cleanups are what is to be undone when the test ends, such as the stub
+	// servers it started.
+	cleanups []func()
+	// queued is what queue has given the test for random to draw.
+	queued *queuedDraws
+}
+
+var running *runningTest
+
+// outcome is how a test that returned without failing went, given its
+// subtests.
+func (t *runningTest) outcome() (bool, string) {
+	if t == nil || t.failed == 0 {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%d of %d subtests failed", t.failed, t.subtests)
+}
+
+// cleanUp runs the test's cleanups, the last added first.
+func (t *runningTest) cleanUp() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+	t.cleanups = nil
+}
+
+// subtest runs fn as a subtest called name of the test under way.
+func subtest(name string, fn func(...meowrt.Value) meowrt.Value) bool {
+	parent := running
+	t := &runningTest{name: parent.name + "/" + name, depth: parent.depth + 1, verbose: parent.verbose, parent: parent}
+	if !selected(activeOptions().Run, t.name) {
+		return true
+	}
+	indent := strings.Repeat("  ", t.depth)
+	events, _ := output.(*eventWriter)
+	if events != nil {
+		events.start(t.name)
+	}
+	if t.verbose {
+		fmt.Fprintf(output, "  %sRUN:  %s\n", indent, t.name)
+	}
+
+	running = t
+	start := time.Now()
+	passed, msg, _ := call(fn, 0, "")
+	if passed {
+		passed, msg = t.outcome()
+	}
+	t.cleanUp()
+	running = parent
+
+	fmt.Fprint(output, indent)
+	report(t.name, passed, msg, time.Since(start), t.verbose)
+	if events != nil {
+		// The output from here on is the enclosing test's again.
+		events.test = parent.name
+	}
+	parent.subtests++
+	if !passed {
+		parent.failed++
+	}
+	return passed
+}
+
+// Table runs fn on each case of a litter, as a subtest of the test under way
+// named after the case: its name, when it is a basket or a kitty with one,
+// and otherwise its place in the litter, #0 for the first. Each case that
+// fails is reported by that name, and the others still run. Outside a test,
+// each case is a test of its own. Returns `yarn` if every case passed.
+func Table(args ...meowrt.Value) meowrt.Value {
+	if len(args) < 2 {
+		return &meowrt.Furball{Message: "Hiss! table expects 2 arguments (cases, fn), nya~"}
+	}
+	cases, ok := args[0].(*meowrt.List)
+	if !ok {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! table expects a List of cases, got %s, nya~", args[0].Type())}
+	}
+	fn, ok := args[1].(*meowrt.Func)
+	if !ok {
+		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! table expects a Func, got %s, nya~", args[1].Type())}
+	}
+
+	all := true
+	for i, c := range cases.Items {
+		body := func(...meowrt.Value) meowrt.Value { return fn.Call(c) }
+		if !runNamed(caseName(c, i), body) {
+			all = false
+		}
+	}
+	return meowrt.NewBool(all)
+}
+
+// runNamed runs fn as a subtest when there is a test under way, and as a
+// test of its own when there is not — a run at the top level of a file, which
+// -run and -v hold to as they do a test_ function.
+func runNamed(name string, fn func(...meowrt.Value) meowrt.Value) bool {
+	if running != nil {
+		return subtest(name, fn)
+	}
+	opts := activeOptions()
+	if !selected(opts.Run, name) {
+		return true
+	}
+	announce(name, opts.Verbose)
+	topLevel++
+	start := time.Now()
+	running = &runningTest{name: name, verbose: opts.Verbose}
+	defer func() { running = nil }()
+	passed, msg, _ := call(fn, 0, "")
+	if passed {
+		passed, msg = running.outcome()
+	}
+	running.cleanUp()
+	record(name, passed, msg, time.Since(start), opts.Verbose)
+	return passed
+}
+
+// selected reports whether run, the -run pattern, selects the test called
+// name. It is read as go test reads it: split at each slash outside brackets
+// and parentheses, with each part matched against the part of the name at
+// its level, so test_parse/empty runs test_parse and, of its subtests, those
+// matching empty. A subtest deeper than the pattern goes with its parent.
+func selected(run *regexp.Regexp, name string) bool {
+	if run == nil {
+		return true
+	}
+	parts := splitPattern(run.String())
+	for i, elem := range strings.Split(name, "/") {
+		if i >= len(parts) {
+			break
+		}
+		re, err := regexp.Compile(parts[i])
+		if err != nil || !re.MatchString(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPattern splits a -run pattern at its slashes, leaving those inside
+// brackets or parentheses, or escaped, where they are.
+func splitPattern(pattern string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[', '(':
+			depth++
+		case ']', ')':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				parts = append(parts, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, pattern[start:])
+}
+
+// caseName is what a table case is called.
+func caseName(c meowrt.Value, i int) string {
+	var name meowrt.Value
+	switch v := c.(type) {
+	case *meowrt.Map:
+		name, _ = v.Get("name")
+	case *meowrt.Kitty:
+		name = v.Fields["name"]
+	}
+	if s, ok := name.(*meowrt.String); ok {
+		return s.Val
+	}
+	if name != nil {
+		return name.String()
+	}
+	return fmt.Sprintf("#%d", i)
+}
@@ -0,0 +1,120 @@
+package meowtest_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+func str(s string) meowrt.Value { return meowrt.NewString(s) }
+
+// check is a subtest body that fails with msg, or passes when it is empty.
+func check(msg string) *meowrt.Func {
+	return meowrt.NewFunc("check", func(...meowrt.Value) meowrt.Value {
+		if msg != "" {
+			return &meowrt.Furball{Message: msg}
+		}
+		return meowrt.NewNil()
+	})
+}
+
+func TestRunInsideATestIsASubtest(t *testing.T) {
+	buf, _ := setup(t)
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_parse", Fn: func(...meowrt.Value) meowrt.Value {
+		meowtest.Run(str("empty"), check(""))
+		meowtest.Run(str("nested"), meowrt.NewFunc("nested", func(...meowrt.Value) meowrt.Value {
+			return meowtest.Run(str("deep"), check("wrong"))
+		}))
+		return meowrt.NewNil()
+	}})
+	meowtest.Report()
+	out := buf.String()
+	for _, want := range []string{
+		"    PASS: test_parse/empty\n",
+		"      FAIL: test_parse/nested/deep - wrong\n",
+		"    FAIL: test_parse/nested - 1 of 1 subtests failed\n",
+		"  FAIL: test_parse - 1 of 2 subtests failed\n",
+		"0 passed, 1 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q in it", out, want)
+		}
+	}
+}
+
+func TestRunPicksSubtestsByTheirWholeName(t *testing.T) {
+	buf, _ := setup(t)
+	parse := meowtest.Case{Name: "test_parse", Fn: func(...meowrt.Value) meowrt.Value {
+		meowtest.Run(str("empty"), check(""))
+		meowtest.Run(str("trailing"), check("wrong"))
+		return meowrt.NewNil()
+	}}
+	var ran []string
+	meowtest.RunSuite(meowtest.Options{Run: regexp.MustCompile("test_parse/empty")},
+		append([]meowtest.Case{parse}, cases(&ran, "test_other")...)...)
+	meowtest.Report()
+	out := buf.String()
+	if !strings.Contains(out, "PASS: test_parse/empty\n") || !strings.Contains(out, "PASS: test_parse\n") {
+		t.Errorf("output = %q, want test_parse and its subtest empty run", out)
+	}
+	if strings.Contains(out, "trailing") || len(ran) != 0 {
+		t.Errorf("output = %q, ran %v, want nothing else run", out, ran)
+	}
+}
+
+func TestRunAtTheTopLevelGoesByTheOptions(t *testing.T) {
+	t.Setenv("MEOW_TEST_RUN", "test_ok")
+	buf, _ := setup(t)
+	meowtest.Run(str("top_level_add"), check(""))
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want top_level_add left out by -run test_ok", buf.String())
+	}
+
+	t.Setenv("MEOW_TEST_RUN", "top_level")
+	t.Setenv("MEOW_TEST_VERBOSE", "1")
+	buf, _ = setup(t)
+	meowtest.Run(str("top_level_add"), meowrt.NewFunc("add", func(...meowrt.Value) meowrt.Value {
+		return meowtest.Run(str("small"), check(""))
+	}))
+	out := buf.String()
+	if !strings.Contains(out, "RUN:  top_level_add\n") || !regexp.MustCompile(`  PASS: top_level_add \(\d+\.\d\ds\)`).MatchString(out) {
+		t.Errorf("output = %q, want top_level_add announced and timed", out)
+	}
+	if !strings.Contains(out, "PASS: top_level_add/small") {
+		t.Errorf("output = %q, want its run a subtest of it", out)
+	}
+}
+
+func TestTableReportsEachFailingCaseByName(t *testing.T) {
+	buf, _ := setup(t)
+	cases := meowrt.NewList(
+		meowrt.NewMap(map[string]meowrt.Value{"name": str("zero"), "want": meowrt.NewInt(0)}),
+		meowrt.NewMap(map[string]meowrt.Value{"name": str("one"), "want": meowrt.NewInt(1)}),
+		meowrt.NewInt(7),
+	)
+	fn := meowrt.NewFunc("case", func(args ...meowrt.Value) meowrt.Value {
+		return meowtest.Expect(meowrt.NewInt(0), args[0].(*meowrt.Map).Items["want"])
+	})
+	var got meowrt.Value
+	meowtest.RunSuite(meowtest.Options{}, meowtest.Case{Name: "test_table", Fn: func(...meowrt.Value) meowrt.Value {
+		got = meowtest.Table(cases, fn)
+		return meowrt.NewNil()
+	}})
+	out := buf.String()
+	for _, want := range []string{
+		"PASS: test_table/zero\n",
+		"FAIL: test_table/one - expected 1, got 0\n",
+		"FAIL: test_table/#2 - ",
+		"FAIL: test_table - 2 of 3 subtests failed\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q in it", out, want)
+		}
+	}
+	if got.IsTruthy() {
+		t.Error("table answered yarn with cases failing")
+	}
+}
@@ -0,0 +1,279 @@
+package meowtest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+)
+
+// Case is one test_ or catwalk_ function of a test file, as the generated main
+// hands them to Suite.
+type Case struct {
+	Name string
+	Fn   func(...meowrt.Value) meowrt.Value
+	// Catwalk marks a catwalk_ function, whose output has to be Output.
+	Catwalk bool
+	Output  string
+	// Parallel marks a test that began with parallel(), which runs beside
+	// the file's other parallel tests once the rest have run.
+	Parallel bool
+}
+
+// Options are how `meow test` asked for a file's tests to be run. The test
+// binary is handed them in its environment, as it is the coverage profile.
+type Options struct {
+	// Run, when set, is what a test's name has to match for it to run.
+	Run *regexp.Regexp
+	// Verbose announces each test as it starts, and gives how long it took.
+	Verbose bool
+	// Timeout is how long one test may run; 0 is for as long as it takes.
+	Timeout time.Duration
+	// FailFast stops at the first failure, leaving the rest unrun.
+	FailFast bool
+	// Shuffle runs the tests in an order made from Seed, rather than in the
+	// order the file gives them.
+	Shuffle bool
+	Seed    int64
+	// JSON writes what happens as events, for `meow test -json` to read,
+	// rather than as text.
+	JSON bool
+	// Color colors the diffs of expect's failures, for a terminal.
+	Color bool
+	// Only, when set, is the one test to run, in a copy of the binary the
+	// parallel tests are each run in; see runParallel.
+	Only string
+}
+
+// The environment `meow test` passes Options in.
+const (
+	envRun      = "MEOW_TEST_RUN"
+	envVerbose  = "MEOW_TEST_VERBOSE"
+	envTimeout  = "MEOW_TEST_TIMEOUT"
+	envFailFast = "MEOW_TEST_FAILFAST"
+	envShuffle  = "MEOW_TEST_SHUFFLE"
+	envJSON     = "MEOW_TEST_JSON"
+	envOnly     = "MEOW_TEST_ONE"
+	envColor    = "MEOW_TEST_COLOR"
+)
+
+// OptionsFromEnv reads the Options `meow test` passed.
+func OptionsFromEnv() (Options, error) {
+	var opts Options
+	if s := os.Getenv(envRun); s != "" {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return opts, fmt.Errorf("Hiss! -run %q is not a regular expression, nya~: %w", s, err)
+		}
+		for _, part := range splitPattern(s) {
+			if _, err := regexp.Compile(part); err != nil {
+				return opts, fmt.Errorf("Hiss! -run %q is not a regular expression, nya~: %w", s, err)
+			}
+		}
+		opts.Run = re
+	}
+	if s := os.Getenv(envTimeout); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return opts, fmt.Errorf("Hiss! -timeout %q is not a duration, nya~: %w", s, err)
+		}
+		opts.Timeout = d
+	}
+	if s := os.Getenv(envShuffle); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("Hiss! -shuffle %q is not a seed, nya~: %w", s, err)
+		}
+		opts.Shuffle, opts.Seed = true, seed
+	}
+	opts.Verbose = os.Getenv(envVerbose) != ""
+	opts.FailFast = os.Getenv(envFailFast) != ""
+	opts.JSON = os.Getenv(envJSON) != ""
+	opts.Only = os.Getenv(envOnly)
+	opts.Color = os.Getenv(envColor) != ""
+	return opts, nil
+}
+
+// active are the Options of the run under way, for what runs outside
+// RunSuite to go by: a run at the top level of a file is over before the
+// suite starts.
+var active *Options
+
+// activeOptions are the Options the run goes by, read from the environment
+// when RunSuite has not been handed them yet. Suite reports Options that
+// cannot be read; until then the run goes by none.
+func activeOptions() Options {
+	if active == nil {
+		opts, _ := OptionsFromEnv()
+		active = &opts
+	}
+	return *active
+}
+
+// Begin readies a test file's run before its top level, which can run tests
+// of its own with run and catwalk: they go by the Options the suite will, and
+// under -json are told as events from the first.
+func Begin() {
+	opts, err := OptionsFromEnv()
+	if err != nil {
+		fmt.Fprintln(output, err)
+		exitFn(1)
+		return
+	}
+	active = &opts
+	useEvents(opts)
+}
+
+// useEvents has output written as events under -json, and answers what
+// writes them, or nil without -json. Report, called after the tests, writes
+// its summary as events too.
+func useEvents(opts Options) *eventWriter {
+	if !opts.JSON {
+		return nil
+	}
+	if events, ok := output.(*eventWriter); ok {
+		return events
+	}
+	events := &eventWriter{w: output}
+	output = events
+	return events
+}
+
+// Suite runs a test file's tests as `meow test` asked for them to be run.
+func Suite(cases ...Case) {
+	opts, err := OptionsFromEnv()
+	if err != nil {
+		fmt.Fprintln(output, err)
+		exitFn(1)
+		return
+	}
+	RunSuite(opts, cases...)
+}
+
+// RunSuite runs cases as opts say, recording their results for Report.
+//
+// A test that runs past opts.Timeout cannot be stopped while the binary goes
+// on: it is still running, and whatever it shares with the tests after it is
+// in whatever state it left it. So the timeout ends the run there, with the
+// test named and the tests it kept from running counted, and Report is called
+// at once. A hung test is then one clear line rather than a binary that never
+// exits.
+func RunSuite(opts Options, cases ...Case) {
+	active = &opts
+	colored = opts.Color
+	events := useEvents(opts)
+	if opts.Only != "" {
+		runOnly(opts, events, cases)
+		return
+	}
+	if opts.Shuffle {
+		cases = append([]Case(nil), cases...)
+		r := rand.New(rand.NewPCG(uint64(opts.Seed), 0))
+		r.Shuffle(len(cases), func(i, j int) { cases[i], cases[j] = cases[j], cases[i] })
+		// Without the seed an order that fails could not be had again.
+		fmt.Fprintf(output, "  shuffled with -shuffle %d\n", opts.Seed)
+	}
+
+	var run []Case
+	for _, c := range cases {
+		if selected(opts.Run, c.Name) {
+			run = append(run, c)
+		}
+	}
+	if len(run) == 0 && topLevel == 0 && opts.Run != nil {
+		fmt.Fprintf(output, "  no tests match -run %q, nya~\n", opts.Run)
+	}
+
+	// The parallel tests wait for the others, as they do in Go: a test that
+	// did not say it could share the binary has it to itself.
+	var serial, parallel []Case
+	for _, c := range run {
+		if c.Parallel {
+			parallel = append(parallel, c)
+		} else {
+			serial = append(serial, c)
+		}
+	}
+	if len(serial) > 0 {
+		if !runSerial(opts, events, serial, len(parallel)) {
+			return
+		}
+	}
+	if len(parallel) > 0 {
+		runParallel(opts, events, parallel)
+	}
+}
+
+// runSerial runs tests one after another, between before_all and after_all,
+// and says whether the run is to go on to the parallel tests; rest is how
+// many there are, to be counted as not run when it is not.
+func runSerial(opts Options, events *eventWriter, serial []Case, rest int) bool {
+	if !beforeAll(opts, len(serial)) {
+		afterAll(opts)
+		skipped += rest
+		return false
+	}
+	for i, c := range serial {
+		passed, timedOut := runCase(opts, events, c)
+		left := len(serial) - i - 1 + rest
+		if timedOut {
+			// The test is still running, so after_all would clean up
+			// underneath it; the run ends as it is.
+			skipped += left
+			fmt.Fprintf(output, "\nHiss! %s was still running after %s, so the tests stopped there, nya~\n", c.Name, opts.Timeout)
+			Report()
+			return false
+		}
+		if !passed && opts.FailFast {
+			skipped += left
+			afterAll(opts)
+			return false
+		}
+	}
+	afterAll(opts)
+	return true
+}
+
+// runCase runs one test and records how it went.
+func runCase(opts Options, events *eventWriter, c Case) (passed, timedOut bool) {
+	if events != nil {
+		events.start(c.Name)
+	}
+	if opts.Verbose {
+		fmt.Fprintf(output, "  RUN:  %s\n", c.Name)
+	}
+	start := time.Now()
+	running = &runningTest{name: c.Name, verbose: opts.Verbose}
+	defer func() { running = nil }()
+	var msg string
+	// A hook that fails names itself, as the test's own code did not.
+	passed, msg, timedOut = runHook(hooks.BeforeEach, opts.Timeout)
+	if !passed {
+		msg = "before_each: " + msg
+	} else {
+		if c.Catwalk {
+			passed, msg, timedOut = runCatwalk(c.Fn, c.Output, opts.Timeout)
+		} else {
+			passed, msg, timedOut = call(c.Fn, opts.Timeout, "")
+		}
+		if passed {
+			passed, msg = running.outcome()
+		}
+	}
+	// after_each cleans up after a test that failed as much as after one
+	// that passed, but not under one still running.
+	if !timedOut {
+		ok, afterMsg, afterTimedOut := runHook(hooks.AfterEach, opts.Timeout)
+		if passed && !ok {
+			passed, msg = false, "after_each: "+afterMsg
+		}
+		timedOut = afterTimedOut
+		running.cleanUp()
+	}
+	record(c.Name, passed, msg, time.Since(start), opts.Verbose)
+	return passed, timedOut
+}
@@ -0,0 +1,206 @@
+package meowtest_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/135yshr/meow/runtime/meowrt"
+	meowtest "github.com/135yshr/meow/runtime/testing"
+)
+
+// cases makes a passing test of each name, recording the order they ran in.
+func cases(ran *[]string, names ...string) []meowtest.Case {
+	var cs []meowtest.Case
+	for _, name := range names {
+		cs = append(cs, meowtest.Case{Name: name, Fn: func(...meowrt.Value) meowrt.Value {
+			*ran = append(*ran, name)
+			return meowrt.NewNil()
+		}})
+	}
+	return cs
+}
+
+func failing(name string) meowtest.Case {
+	return meowtest.Case{Name: name, Fn: func(...meowrt.Value) meowrt.Value {
+		return &meowrt.Furball{Message: "wrong"}
+	}}
+}
+
+func TestRunPicksTestsByName(t *testing.T) {
+	buf, _ := setup(t)
+	var ran []string
+	meowtest.RunSuite(meowtest.Options{Run: regexp.MustCompile("add")},
+		cases(&ran, "test_add", "test_sub", "catwalk_add_output")...)
+	if !slices.Equal(ran, []string{"test_add", "catwalk_add_output"}) {
+		t.Errorf("ran %v, want the two with add in their names", ran)
+	}
+
+	buf.Reset()
+	meowtest.RunSuite(meowtest.Options{Run: regexp.MustCompile("mul")}, cases(&ran, "test_add")...)
+	if !strings.Contains(buf.String(), `no tests match -run "mul"`) {
+		t.Errorf("output = %q, want it to say nothing matched", buf.String())
+	}
+}
+
+func TestVerboseGivesEachTestsDuration(t *testing.T) {
+	buf, _ := setup(t)
+	var ran []string
+	meowtest.RunSuite(meowtest.Options{Verbose: true}, cases(&ran, "test_nap")...)
+	out := buf.String()
+	if !strings.Contains(out, "RUN:  test_nap\n") || !regexp.MustCompile(`PASS: test_nap \(\d+\.\d\ds\)`).MatchString(out) {
+		t.Errorf("output = %q, want the test announced and timed", out)
+	}
+}
+
+func TestFailFastLeavesTheRestUnrun(t *testing.T) {
+	buf, code := setup(t)
+	var ran []string
+	cs := append([]meowtest.Case{failing("test_first")}, cases(&ran, "test_second", "test_third")...)
+	meowtest.RunSuite(meowtest.Options{FailFast: true}, cs...)
+	meowtest.Report()
+	if len(ran) != 0 {
+		t.Errorf("ran %v after the first failure", ran)
+	}
+	if !strings.Contains(buf.String(), "0 passed, 1 failed, 2 not run") || *code != 1 {
+		t.Errorf("output = %q, exit %d; want the two unrun counted and a failure", buf.String(), *code)
+	}
+}
+
+func TestATestPastItsTimeoutStopsTheRun(t *testing.T) {
+	buf, code := setup(t)
+	hang := make(chan struct{})
+	defer close(hang)
+	var ran []string
+	cs := append([]meowtest.Case{{Name: "test_hang", Fn: func(...meowrt.Value) meowrt.Value {
+		<-hang
+		return meowrt.NewNil()
+	}}}, cases(&ran, "test_after")...)
+
+	meowtest.RunSuite(meowtest.Options{Timeout: 20 * time.Millisecond}, cs...)
+	out := buf.String()
+	if !strings.Contains(out, "FAIL: test_hang - timed out after 20ms") ||
+		!strings.Contains(out, "test_hang was still running after 20ms") ||
+		!strings.Contains(out, "0 passed, 1 failed, 1 not run") {
+		t.Errorf("output = %q, want the hung test named and the run stopped", out)
+	}
+	if len(ran) != 0 || *code != 1 {
+		t.Errorf("ran %v, exit %d; want nothing after the hung test and a failure", ran, *code)
+	}
+}
+
+func TestATimeoutAppliesToCatwalksToo(t *testing.T) {
+	buf, _ := setup(t)
+	hang := make(chan struct{})
+	defer close(hang)
+	meowtest.RunSuite(meowtest.Options{Timeout: 20 * time.Millisecond}, meowtest.Case{
+		Name: "catwalk_hang", Catwalk: true, Output: "nya\n",
+		Fn: func(...meowrt.Value) meowrt.Value {
+			<-hang
+			return meowrt.NewNil()
+		},
+	})
+	if !strings.Contains(buf.String(), "FAIL: catwalk_hang - timed out after 20ms") {
+		t.Errorf("output = %q, want the hung catwalk reported", buf.String())
+	}
+}
+
+func TestAShuffleIsTheSameForTheSameSeed(t *testing.T) {
+	names := []string{"test_a", "test_b", "test_c", "test_d", "test_e", "test_f", "test_g", "test_h"}
+	order := func(seed int64) []string {
+		buf, _ := setup(t)
+		var ran []string
+		meowtest.RunSuite(meowtest.Options{Shuffle: true, Seed: seed}, cases(&ran, names...)...)
+		if !strings.Contains(buf.String(), "shuffled with -shuffle ") {
+			t.Errorf("output = %q, want the seed given", buf.String())
+		}
+		return ran
+	}
+	first := order(7)
+	if !slices.Equal(first, order(7)) {
+		t.Error("the same seed gave two orders")
+	}
+	if slices.Equal(first, names) && slices.Equal(order(8), names) {
+		t.Error("shuffling left the tests in the order they were written")
+	}
+}
+
+func TestJSONWritesEvents(t *testing.T) {
+	buf, _ := setup(t)
+	var ran []string
+	cs := append(cases(&ran, "test_ok"), failing("test_broken"))
+	meowtest.RunSuite(meowtest.Options{JSON: true}, cs...)
+	meowtest.Report()
+
+	var actions []string
+	for line := range strings.Lines(buf.String()) {
+		rest, ok := strings.CutPrefix(line, "\x1emeow-event ")
+		if !ok {
+			t.Fatalf("line %q is not an event", line)
+		}
+		var e struct{ Action, Test string }
+		if err := json.Unmarshal([]byte(rest), &e); err != nil {
+			t.Fatal(err)
+		}
+		actions = append(actions, e.Action+" "+e.Test)
+	}
+	want := []string{
+		"run test_ok", "output test_ok", "pass test_ok",
+		"run test_broken", "output test_broken", "fail test_broken",
+		"output ", "output ",
+	}
+	if !slices.Equal(actions, want) {
+		t.Errorf("events\n%s\nwant\n%s", strings.Join(actions, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestJSONTellsOfARunAtTheTopLevel(t *testing.T) {
+	t.Setenv("MEOW_TEST_JSON", "1")
+	buf, _ := setup(t)
+	meowtest.Begin()
+	meowtest.Run(meowrt.NewString("top_level_add"), meowrt.NewFunc("add", func(...meowrt.Value) meowrt.Value {
+		return meowrt.NewNil()
+	}))
+	meowtest.RunSuite(meowtest.Options{JSON: true}, failing("test_broken"))
+
+	var actions []string
+	for line := range strings.Lines(buf.String()) {
+		rest, ok := strings.CutPrefix(line, "\x1emeow-event ")
+		if !ok {
+			t.Fatalf("line %q is not an event", line)
+		}
+		var e struct{ Action, Test string }
+		if err := json.Unmarshal([]byte(rest), &e); err != nil {
+			t.Fatal(err)
+		}
+		actions = append(actions, e.Action+" "+e.Test)
+	}
+	want := []string{
+		"run top_level_add", "output top_level_add", "pass top_level_add",
+		"run test_broken", "output test_broken", "fail test_broken",
+	}
+	if !slices.Equal(actions, want) {
+		t.Errorf("events\n%s\nwant\n%s", strings.Join(actions, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOnlyRunsTheOneTestAndExitsWithHowItWent(t *testing.T) {
+	buf, code := setup(t)
+	var ran []string
+	cs := append(cases(&ran, "test_a", "test_b"), failing("test_c"))
+	meowtest.RunSuite(meowtest.Options{Only: "test_b"}, cs...)
+	if !slices.Equal(ran, []string{"test_b"}) || *code != 0 {
+		t.Errorf("ran %v, exit %d; want test_b alone, passing", ran, *code)
+	}
+	if strings.Contains(buf.String(), "passed") {
+		t.Errorf("output = %q, want no summary: it is the parent's to give", buf.String())
+	}
+
+	meowtest.RunSuite(meowtest.Options{Only: "test_c"}, cs...)
+	if *code != 1 {
+		t.Errorf("exit %d, want the failing test's run to fail", *code)
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/135yshr/meow/runtime/meowrt"
 )
 
 // testFailure was previously used as a panic sentinel for assertion failures.
 // After the panic-to-Furball migration, assertions return *meowrt.Furball
-// values that propagate via the codegen's short-circuit. The type is kept
-// for any remaining recover() compatibility but is no longer raised.
+// values that propagate via the codegen's short-circuit. It is still raised
+// where a Furball cannot be returned: by the draws queue stands in for, which
+// random calls expecting only a number.
 type testFailure struct {
 	message string
 }
@@ -33,12 +35,24 @@ type testResult struct {
 var (
 	output  io.Writer = os.Stdout
 	results []testResult
-	exitFn  = func(code int) { os.Exit(code) }
+	// skipped counts the tests a run stopped short of, after -failfast or a
+	// timeout.
+	skipped int
+	// topLevel counts the tests run at the top level of the file, before the
+	// suite, by run or catwalk.
+	topLevel int
+	exitFn   = func(code int) { os.Exit(code) }
 )
 
 // Reset clears accumulated test results and reconfigures output/exit.
 func Reset(w io.Writer, exit func(int)) {
 	results = nil
+	skipped = 0
+	topLevel = 0
+	hooks = Hooks{}
+	running = nil
+	active = nil
+	colored = false
 	if w != nil {
 		output = w
 	} else {
@@ -71,7 +85,8 @@ func Judge(args ...meowrt.Value) meowrt.Value {
 	return meowrt.NewNil()
 }
 
-// Expect asserts that two values are equal (by String representation).
+// Expect asserts that two values are equal (by String representation). When
+// they are not, the failure says where: see describeMismatch.
 func Expect(args ...meowrt.Value) meowrt.Value {
 	if len(args) < 2 {
 		return &meowrt.Furball{Message: "Hiss! expect expects at least 2 arguments, nya~"}
@@ -85,9 +100,9 @@ func Expect(args ...meowrt.Value) meowrt.Value {
 	actual := args[0]
 	expected := args[1]
 	if actual.String() != expected.String() {
-		msg := fmt.Sprintf("expected %s, got %s", expected.String(), actual.String())
+		msg := describeMismatch(actual, expected)
 		if len(args) >= 3 {
-			msg = fmt.Sprintf("%s: expected %s, got %s", args[2].String(), expected.String(), actual.String())
+			msg = fmt.Sprintf("%s: %s", args[2].String(), msg)
 		}
 		return assertionFailure(msg)
 	}
@@ -114,7 +129,8 @@ func Refuse(args ...meowrt.Value) meowrt.Value {
 
 // Run executes a named test function, recording the result.
 // A returned *Furball (from a failed assertion that propagated via short-circuit)
-// or any panic is treated as a failure.
+// or any panic is treated as a failure. Called inside a test, the function is
+// a subtest of it.
 func Run(args ...meowrt.Value) meowrt.Value {
 	if len(args) < 2 {
 		return &meowrt.Furball{Message: "Hiss! run expects 2 arguments (name, fn), nya~"}
@@ -128,38 +144,86 @@ func Run(args ...meowrt.Value) meowrt.Value {
 		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! run expects a Func, got %s, nya~", args[1].Type())}
 	}
 
-	passed := true
-	var msg string
-	func() {
+	return meowrt.NewBool(runNamed(name.Val, fn.Call))
+}
+
+// call calls a test function, within limit when there is one. A Furball it
+// returns, or a panic, is a failure with that message, the panic's after
+// panicPrefix; running past the limit is a failure too, and says so with
+// timedOut.
+func call(fn func(...meowrt.Value) meowrt.Value, limit time.Duration, panicPrefix string) (passed bool, msg string, timedOut bool) {
+	passed = true
+	body := func() {
 		defer func() {
 			if r := recover(); r != nil {
 				passed = false
 				if tf, ok := r.(testFailure); ok {
 					msg = tf.message
 				} else {
-					msg = fmt.Sprintf("%v", r)
+					msg = fmt.Sprintf("%s%v", panicPrefix, r)
 				}
 			}
 		}()
-		ret := fn.Call()
+		ret := fn()
 		if f, ok := ret.(*meowrt.Furball); ok {
 			passed = false
 			msg = f.Message
 		}
+	}
+	if limit <= 0 {
+		body()
+		return passed, msg, false
+	}
+	// A goroutine cannot be stopped from outside, so one that runs past its
+	// limit is left where it is: the binary reports it and exits, which
+	// stops it along with everything else.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		body()
 	}()
+	select {
+	case <-done:
+		return passed, msg, false
+	case <-time.After(limit):
+		return false, fmt.Sprintf("timed out after %s", limit), true
+	}
+}
+
+// announce says a test run outside the suite has begun, as runCase does of
+// one in it.
+func announce(name string, verbose bool) {
+	if events, ok := output.(*eventWriter); ok {
+		events.start(name)
+	}
+	if verbose {
+		fmt.Fprintf(output, "  RUN:  %s\n", name)
+	}
+}
 
+// record prints a test's result and keeps it for Report.
+func record(name string, passed bool, msg string, elapsed time.Duration, verbose bool) {
+	report(name, passed, msg, elapsed, verbose)
+	results = append(results, testResult{name: name, passed: passed, msg: msg})
+}
+
+// report prints a test's result.
+func report(name string, passed bool, msg string, elapsed time.Duration, verbose bool) {
 	status := "PASS"
 	if !passed {
 		status = "FAIL"
 	}
-	fmt.Fprintf(output, "  %s: %s", status, name.Val)
+	fmt.Fprintf(output, "  %s: %s", status, name)
+	if verbose {
+		fmt.Fprintf(output, " (%.2fs)", elapsed.Seconds())
+	}
 	if msg != "" {
 		fmt.Fprintf(output, " - %s", msg)
 	}
 	fmt.Fprintln(output)
-
-	results = append(results, testResult{name: name.Val, passed: passed, msg: msg})
-	return meowrt.NewBool(passed)
+	if events, ok := output.(*eventWriter); ok {
+		events.end(name, passed, elapsed)
+	}
 }
 
 // Catwalk executes a named function, captures stdout, and compares it with
@@ -182,11 +246,26 @@ func Catwalk(args ...meowrt.Value) meowrt.Value {
 		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! catwalk expects a String expected, got %s, nya~", args[2].Type())}
 	}
 
+	opts := activeOptions()
+	if !selected(opts.Run, name.Val) {
+		return meowrt.NewBool(true)
+	}
+	announce(name.Val, opts.Verbose)
+	topLevel++
+	start := time.Now()
+	passed, msg, _ := runCatwalk(fn.Call, expected.Val, 0)
+	record(name.Val, passed, msg, time.Since(start), opts.Verbose)
+	return meowrt.NewBool(passed)
+}
+
+// runCatwalk calls a catwalk function, within limit when there is one, and
+// compares what it printed with expected.
+func runCatwalk(fn func(...meowrt.Value) meowrt.Value, expected string, limit time.Duration) (passed bool, msg string, timedOut bool) {
 	// Capture stdout using os.Pipe.
 	oldStdout := os.Stdout
 	r, w, err := os.Pipe()
 	if err != nil {
-		return &meowrt.Furball{Message: fmt.Sprintf("Hiss! cannot create pipe, nya~: %v", err)}
+		return false, fmt.Sprintf("Hiss! cannot create pipe, nya~: %v", err), false
 	}
 	os.Stdout = w
 
@@ -198,50 +277,24 @@ func Catwalk(args ...meowrt.Value) meowrt.Value {
 		captured <- buf.String()
 	}()
 
-	passed := true
-	var msg string
-	func() {
-		defer func() {
-			if rec := recover(); rec != nil {
-				passed = false
-				if tf, ok := rec.(testFailure); ok {
-					msg = tf.message
-				} else {
-					msg = fmt.Sprintf("panic: %v", rec)
-				}
-			}
-		}()
-		ret := fn.Call()
-		if f, ok := ret.(*meowrt.Furball); ok {
-			passed = false
-			msg = f.Message
-		}
-	}()
+	passed, msg, timedOut = call(fn, limit, "panic: ")
 
 	// Close writer and restore stdout.
 	w.Close()
 	os.Stdout = oldStdout
+	if timedOut {
+		// The function may be writing still; what it wrote is not waited for.
+		return passed, msg, timedOut
+	}
 	got := <-captured
 	r.Close()
 
 	// Compare output if function didn't panic.
-	if passed && got != expected.Val {
+	if passed && got != expected {
 		passed = false
-		msg = fmt.Sprintf("output mismatch:\ngot:\n%swant:\n%s", got, expected.Val)
+		msg = fmt.Sprintf("output mismatch:\ngot:\n%swant:\n%s", got, expected)
 	}
-
-	status := "PASS"
-	if !passed {
-		status = "FAIL"
-	}
-	fmt.Fprintf(output, "  %s: %s", status, name.Val)
-	if msg != "" {
-		fmt.Fprintf(output, " - %s", msg)
-	}
-	fmt.Fprintln(output)
-
-	results = append(results, testResult{name: name.Val, passed: passed, msg: msg})
-	return meowrt.NewBool(passed)
+	return passed, msg, false
 }
 
 // Report outputs the test summary. Calls os.Exit(1) if any test failed.
@@ -257,9 +310,12 @@ func Report(args ...meowrt.Value) meowrt.Value {
 	}
 
 	fmt.Fprintln(output)
-	if failed == 0 {
+	switch {
+	case failed == 0:
 		fmt.Fprintf(output, "All %d tests passed, nya~!\n", passed)
-	} else {
+	case skipped > 0:
+		fmt.Fprintf(output, "%d passed, %d failed, %d not run, nya~\n", passed, failed, skipped)
+	default:
 		fmt.Fprintf(output, "%d passed, %d failed, nya~\n", passed, failed)
 	}
 